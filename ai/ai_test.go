@@ -0,0 +1,337 @@
+package ai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/book"
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/tablebase"
+)
+
+func TestPlayGame(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		result, err := PlayGame(NewRandom(seed), NewRandom(seed+100), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Plies > DefaultMaxPlies+1 {
+			t.Fatalf("game should stop at %d plies, got %d", DefaultMaxPlies, result.Plies)
+		}
+		if result.Outcome == rating.Draw && result.Reason != chessgame.TerminationMaxPlies {
+			t.Fatalf("unexpected draw reason %s", result.Reason)
+		}
+		if len(result.Record.Moves) < result.Plies {
+			t.Fatalf("record should contain %d moves, got %d", result.Plies, len(result.Record.Moves))
+		}
+	}
+}
+
+func TestGreedyBeatsRandom(t *testing.T) {
+	score := 0.0
+	for seed := int64(1); seed <= 20; seed++ {
+		//交替先后手
+		var result *GameResult
+		var err error
+		if seed%2 == 0 {
+			result, err = PlayGame(NewGreedy(seed), NewRandom(seed), 0)
+		} else {
+			result, err = PlayGame(NewRandom(seed), NewGreedy(seed), 0)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		greedyWin, greedyLoss := rating.RedWin, rating.BlackWin
+		if seed%2 == 1 {
+			greedyWin, greedyLoss = greedyLoss, greedyWin
+		}
+		switch result.Outcome {
+		case greedyWin:
+			score++
+		case greedyLoss:
+		default:
+			score += 0.5
+		}
+	}
+	if score < 15 {
+		t.Fatalf("greedy engine should beat the random engine, scored %.1f of 20", score)
+	}
+}
+
+func TestPlayBotsWithOpening(t *testing.T) {
+	opening, err := ParseOpening("3k5/9/9/9/9/9/9/9/4R4/4K4 w moves e1e8 # 车杀")
+	if err != nil {
+		t.Fatal(err)
+	}
+	red := NewBot(NewRandom(1))
+	black := NewBot(NewRandom(2))
+	black.SetIsFirst(true)
+	result, err := PlayBots(black, red, GameOptions{Opening: &opening})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Plies < 1 || result.Record.Moves[0].Source == result.Record.Moves[0].Target {
+		t.Fatalf("opening move was not played: %+v", result)
+	}
+
+	//开局中的着法不合法时返回错误
+	bad, _ := ParseOpening("h2e2 h2e2")
+	if _, err = PlayBots(red, black, GameOptions{Opening: &bad}); err == nil {
+		t.Fatal("invalid opening should fail")
+	}
+}
+
+// 总是出错的引擎
+type failingEngine struct {
+	err error
+}
+
+func (e failingEngine) Name() string {
+	return "failing"
+}
+
+func (e failingEngine) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	return player.Statement{}, e.err
+}
+
+// 走不合规则的棋的引擎：让车走马步
+type illegalEngine struct{}
+
+func (illegalEngine) Name() string {
+	return "illegal"
+}
+
+func (illegalEngine) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	m, _ := position.ParseMove("a0b2")
+	return game.ParseMove(m)
+}
+
+// 引擎出错、无棋可走和走不合规则的棋都判引擎一方负，结束原因不同
+func TestEngineLoss(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine Engine
+		reason chessgame.Termination
+	}{
+		{"引擎出错", failingEngine{err: errors.New("engine crashed")}, chessgame.TerminationEngineError},
+		{"无棋可走", failingEngine{err: ErrNoMoves}, chessgame.TerminationNoMoves},
+		{"包装的无棋可走", failingEngine{err: fmt.Errorf("search: %w", ErrNoMoves)}, chessgame.TerminationNoMoves},
+		{"不合规则的着法", illegalEngine{}, chessgame.TerminationIllegalMove},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := PlayGame(c.engine, NewRandom(1), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Outcome != rating.BlackWin || result.Reason != c.reason {
+				t.Fatalf("expect black win by %s, got %v %s", c.reason, result.Outcome, result.Reason)
+			}
+		})
+	}
+}
+
+func TestUCCI(t *testing.T) {
+	t.Setenv("XIANGQI_FAKE_UCCI", "1")
+	engine, err := NewUCCI(os.Args[0], []string{"-test.run=TestFakeUCCIEngine"}, UCCIOptions{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if engine.Name() != "fake" {
+		t.Fatalf("engine name = %q", engine.Name())
+	}
+
+	red := NewBot(engine)
+	red.SetIsFirst(true)
+	black := NewBot(engine)
+	result, err := PlayBots(red, black, GameOptions{MaxPlies: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Plies != 2 || result.Reason != chessgame.TerminationMaxPlies {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	a, err := engine.Analyze(position.Initial())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best, ok := a.BestMove(); !ok || best.String() != "h2e2" || a.Depth != 1 {
+		t.Fatalf("unexpected analysis %+v", a)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		line  string
+		score int
+		mate  bool
+		pv    string
+	}{
+		{"depth 8 score 35 pv h2e2 h9g7", 35, false, "h2e2 h9g7"},
+		{"depth 10 seldepth 12 score cp -120 nodes 1000 pv b0c2", -120, false, "b0c2"},
+		{"depth 5 score mate 3 pv h2e2", MateScore - 3, true, "h2e2"},
+		{"depth 5 score mate -2", -MateScore + 2, true, ""},
+		{"depth 12 score 9995", 9995, true, ""},
+	}
+	for _, tt := range tests {
+		a, ok := parseInfo(strings.Fields(tt.line))
+		if !ok || a.Score != tt.score || a.Mate != tt.mate {
+			t.Errorf("parseInfo(%q) = %+v", tt.line, a)
+			continue
+		}
+		pv := make([]string, len(a.PV))
+		for i, m := range a.PV {
+			pv[i] = m.String()
+		}
+		if got := strings.Join(pv, " "); got != tt.pv {
+			t.Errorf("parseInfo(%q) pv = %q", tt.line, got)
+		}
+	}
+	if _, ok := parseInfo(strings.Fields("depth 3 nodes 100")); ok {
+		t.Error("info without score")
+	}
+}
+
+// 测试UCCI时作为引擎进程运行：红方走炮二平五，黑方走马8进7
+func TestFakeUCCIEngine(t *testing.T) {
+	if os.Getenv("XIANGQI_FAKE_UCCI") != "1" {
+		return
+	}
+	redToMove := true
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "ucci":
+			fmt.Println("id name fake")
+			fmt.Println("ucciok")
+		case "position":
+			redToMove = len(fields) > 3 && fields[3] == "w"
+		case "go":
+			fmt.Println("info depth 1 score 0")
+			if redToMove {
+				fmt.Println("bestmove h2e2")
+			} else {
+				fmt.Println("bestmove h9g7")
+			}
+		case "quit":
+			os.Exit(0)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestBookEngine(t *testing.T) {
+	b := book.New()
+	m, _ := position.ParseMove("h2e2")
+	b.Add(position.Initial(), m, 1)
+	engine := NewBookEngine(b, NewRandom(1), 1)
+
+	red := NewBot(engine)
+	red.SetIsFirst(true)
+	black := NewBot(engine)
+	result, err := PlayBots(red, black, GameOptions{MaxPlies: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := result.Record.PositionMoves()[0]
+	if first != m {
+		t.Fatalf("expect book move %s, got %s", m, first)
+	}
+}
+
+func TestTablebaseEngine(t *testing.T) {
+	tb := tablebase.New()
+	m, _ := tablebase.ParseMaterial("KR-K")
+	if _, err := tb.Generate(m); err != nil {
+		t.Fatal(err)
+	}
+	red := NewBot(NewTablebaseEngine(tb, NewRandom(1)))
+	red.SetIsFirst(true)
+	black := NewBot(NewRandom(2))
+	opening := &Opening{Name: "单车对单将", FEN: "9/4k4/9/9/9/9/9/9/9/R2K5 w"}
+	result, err := PlayBots(red, black, GameOptions{MaxPlies: 20, Opening: opening})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//车胜单将最多4步，加上吃将不超过5步
+	if result.Record.Result != rating.RedWin || result.Plies > 5 {
+		t.Fatalf("expect red to win quickly, got %v after %d plies", result.Record.Result, result.Plies)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		fen   string
+		depth int
+		want  string
+	}{
+		{"3k5/9/9/9/9/9/9/9/9/3R1K3 w", 1, "d0d9"},   //直接吃将
+		{"4k4/9/9/9/4r4/9/9/9/4R4/3K5 w", 2, "e1e5"}, //吃掉没有保护的车
+		{"3k5/9/9/9/9/9/9/9/9/3r1K3 b", 3, "d0f0"},   //黑方吃帅
+	}
+	for _, tt := range tests {
+		pos, err := position.ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := NewSearch(tt.depth, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := e.Search(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.String() != tt.want {
+			t.Fatalf("%s: want %s, got %s", tt.fen, tt.want, m)
+		}
+	}
+}
+
+func TestSearchBeatsRandom(t *testing.T) {
+	for seed := int64(1); seed <= 4; seed++ {
+		e, err := NewSearch(2, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := PlayGame(e, NewRandom(seed), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Outcome != rating.RedWin {
+			t.Fatalf("seed %d: search engine should beat the random engine, got %v (%s)", seed, result.Outcome, result.Reason)
+		}
+	}
+}
+
+// 上一局被Stop的Bot绑定新的棋局之后继续由引擎下棋
+func TestBotAttachAfterStop(t *testing.T) {
+	engineErr := errors.New("engine asked")
+	bot := NewBot(failingEngine{err: engineErr})
+	bot.Attach(new(chessgame.ChessGame))
+	bot.Stop()
+	quit := make(chan struct{})
+	if _, err := bot.ReceiveStatement(nil, quit); err == nil || errors.Is(err, engineErr) {
+		t.Fatalf("a stopped bot should not ask the engine, got %v", err)
+	}
+
+	bot.Attach(new(chessgame.ChessGame))
+	if _, err := bot.ReceiveStatement(nil, quit); !errors.Is(err, engineErr) {
+		t.Fatalf("expect the engine to be asked again, got %v", err)
+	}
+}
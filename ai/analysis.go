@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"strconv"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// MateScore 杀棋的分数，算出n步杀时分数为MateScore-n
+const MateScore = 10000
+
+// Analysis 引擎对一个局面的分析，分数从走棋方的角度计算，单位为分（一个兵约100分）
+type Analysis struct {
+	Depth int             //搜索深度
+	Score int             //分数，正数表示走棋方占优
+	Mate  bool            //算出了杀棋，Score为正时走棋方能杀，为负时走棋方被杀
+	PV    []position.Move //主要变例，第一步是最佳着法
+}
+
+// BestMove 最佳着法，没有着法时返回false
+func (a Analysis) BestMove() (position.Move, bool) {
+	if len(a.PV) == 0 {
+		return position.Move{}, false
+	}
+	return a.PV[0], true
+}
+
+// 解析info指令的参数，例如 depth 8 score 35 pv h2e2 h9g7。
+// 分数可以是UCCI的 score 35，也可以是 score cp 35 和 score mate 3，没有分数时返回false
+func parseInfo(fields []string) (Analysis, bool) {
+	var a Analysis
+	hasScore := false
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "depth":
+			if i+1 < len(fields) {
+				a.Depth, _ = strconv.Atoi(fields[i+1])
+				i++
+			}
+		case "score":
+			if i+1 >= len(fields) {
+				continue
+			}
+			unit := ""
+			if fields[i+1] == "cp" || fields[i+1] == "mate" {
+				unit = fields[i+1]
+				i++
+			}
+			if i+1 >= len(fields) {
+				continue
+			}
+			score, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				continue
+			}
+			i++
+			hasScore = true
+			a.Score = score
+			switch {
+			case unit == "mate" && score >= 0:
+				a.Score, a.Mate = MateScore-score, true
+			case unit == "mate":
+				a.Score, a.Mate = -MateScore-score, true
+			case score >= MateScore-1000 || score <= -MateScore+1000:
+				//UCCI引擎用接近杀棋分数的分值表示杀棋
+				a.Mate = true
+			}
+		case "pv":
+			for _, f := range fields[i+1:] {
+				m, err := position.ParseMove(f)
+				if err != nil {
+					break
+				}
+				a.PV = append(a.PV, m)
+			}
+			i = len(fields)
+		}
+	}
+	return a, hasScore
+}
@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/CXeon/xiangqi/book"
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+)
+
+// BookEngine 先查开局库，开局库中没有当前局面时再交给其他引擎计算
+type BookEngine struct {
+	book   *book.Book
+	engine Engine
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewBookEngine 创建使用开局库的引擎，seed决定开局库中多个着法的选择
+func NewBookEngine(b *book.Book, engine Engine, seed int64) *BookEngine {
+	return &BookEngine{book: b, engine: engine, rand: rand.New(rand.NewSource(seed))}
+}
+
+func (e *BookEngine) Name() string {
+	return e.engine.Name() + "+book"
+}
+
+// BestMove 按权重随机选择开局库中的着法，开局库中的着法不合法时忽略
+func (e *BookEngine) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	if st, ok := e.BookMove(game, group); ok {
+		return st, nil
+	}
+	return e.engine.BestMove(game, group)
+}
+
+// BookMove 从开局库中选择一步棋，也可以用于提示功能
+func (e *BookEngine) BookMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, bool) {
+	e.mu.Lock()
+	entry, ok := e.book.Pick(game.GetPosition(), e.rand)
+	e.mu.Unlock()
+	if !ok {
+		return player.Statement{}, false
+	}
+
+	//哈希值可能冲突，只接受当前局面下合法的着法
+	st, err := game.ParseMove(entry.Move)
+	if err != nil || st.Group != group {
+		return player.Statement{}, false
+	}
+	for _, legal := range game.GetLegalStatements(group) {
+		if legal == st {
+			return st, true
+		}
+	}
+	return player.Statement{}, false
+}
+
+// Close 关闭被包装的引擎
+func (e *BookEngine) Close() error {
+	if c, ok := e.engine.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Bot 由引擎自动下棋的玩家。
+// Bot不从通道读取下棋意图，内核询问下一步棋时直接由引擎根据绑定棋局的当前局面计算，
+// 因此两个Bot对局时内核可以完全自动地运行
+type Bot struct {
+	*player.Player
+	engine Engine
+	game   chessgame.ChessGameInterface
+
+	mu      sync.Mutex
+	stopped bool
+	err     error   //引擎最近一次返回的错误
+	script  *script //开局阶段双方按顺序走的着法
+}
+
+// 开局着法，对局双方共用
+type script struct {
+	mu    sync.Mutex
+	moves []position.Move
+	next  int
+}
+
+// 取出下一步开局着法，没有开局或者开局已经走完时返回false
+func (s *script) popIfAny() (position.Move, bool) {
+	if s == nil {
+		return position.Move{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next >= len(s.moves) {
+		return position.Move{}, false
+	}
+	m := s.moves[s.next]
+	s.next++
+	return m, true
+}
+
+func NewBot(engine Engine) *Bot {
+	return &Bot{
+		Player: player.NewPlayer(),
+		engine: engine,
+	}
+}
+
+// Attach 绑定Bot参与的棋局，必须在棋局运行之前调用。
+// 上一局被Stop的Bot绑定新的棋局之后重新开始下棋
+func (b *Bot) Attach(game chessgame.ChessGameInterface) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.game = game
+	b.stopped = false
+	b.err = nil
+}
+
+// GetEngine 获取Bot使用的引擎
+func (b *Bot) GetEngine() Engine {
+	return b.engine
+}
+
+// Stop 让Bot不再下棋，内核下一次询问时返回错误，棋局随之结束
+func (b *Bot) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+}
+
+// 开始新的对局之前重置状态
+func (b *Bot) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = false
+	b.err = nil
+	b.script = nil
+}
+
+// Err 获取引擎最近一次返回的错误，例如没有可以走的棋
+func (b *Bot) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// LossReason 内核拒绝了Bot的下棋意图时Bot判负的原因：引擎没有给出着法时是无棋可走或者引擎出错，
+// 给出的着法不符合规则时是走了不合规则的棋
+func (b *Bot) LossReason(rejected *chessgame.MoveRejected) chessgame.Termination {
+	if rejected == nil || rejected.Reason != chessgame.RejectNoStatement {
+		return chessgame.TerminationIllegalMove
+	}
+	if errors.Is(b.Err(), ErrNoMoves) {
+		return chessgame.TerminationNoMoves
+	}
+	return chessgame.TerminationEngineError
+}
+
+// ReceiveStatement 由引擎计算下一步棋，ch不会被读取
+func (b *Bot) ReceiveStatement(ch chan player.Statement, quit chan struct{}) (player.Statement, error) {
+	select {
+	case <-quit:
+		return player.Statement{}, player.ErrQuit
+	default:
+	}
+
+	b.mu.Lock()
+	stopped, s, game := b.stopped, b.script, b.game
+	b.mu.Unlock()
+	if stopped {
+		return player.Statement{}, errors.New("bot is stopped")
+	}
+	if game == nil {
+		return player.Statement{}, errors.New("bot is not attached to a game")
+	}
+
+	var st player.Statement
+	var err error
+	if m, ok := s.popIfAny(); ok {
+		//开局阶段按照开局着法走棋
+		st, err = game.ParseMove(m)
+		if err == nil && st.Group != b.GetGroup() {
+			err = fmt.Errorf("opening move %s is not a move of the side to move", m)
+		}
+	} else {
+		st, err = b.engine.BestMove(game, b.GetGroup())
+	}
+	b.mu.Lock()
+	b.err = err
+	b.mu.Unlock()
+	return st, err
+}
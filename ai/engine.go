@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// ErrNoMoves 没有可以走的棋，按规则判负
+var ErrNoMoves = errors.New("no legal moves")
+
+// Engine 根据当前局面计算下一步棋
+type Engine interface {
+	Name() string
+	//为阵营计算下一步棋，调用时棋局不会被修改
+	BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error)
+}
+
+// 棋子的子力价值
+var pieceValues = map[core.ChessmanCode]int{
+	core.JiangShuai: 10000,
+	core.Ju:         900,
+	core.Pao:        450,
+	core.Ma:         400,
+	core.Xiang:      200,
+	core.Shi:        200,
+	core.BingZu:     100,
+}
+
+// Random 随机走棋的引擎
+type Random struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewRandom 创建随机走棋的引擎，seed相同时走法相同
+func NewRandom(seed int64) *Random {
+	return &Random{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (e *Random) Name() string {
+	return "random"
+}
+
+// BestMove 从所有可以走的棋中随机选择一步
+func (e *Random) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	statements := game.GetLegalStatements(group)
+	if len(statements) == 0 {
+		return player.Statement{}, ErrNoMoves
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return statements[e.rand.Intn(len(statements))], nil
+}
+
+// Greedy 贪吃的引擎：总是吃掉价值最高的棋子，没有棋子可吃时随机走棋
+type Greedy struct {
+	random *Random
+}
+
+// NewGreedy 创建贪吃的引擎，seed决定没有棋子可吃时的走法
+func NewGreedy(seed int64) *Greedy {
+	return &Greedy{random: NewRandom(seed)}
+}
+
+func (e *Greedy) Name() string {
+	return "greedy"
+}
+
+// BestMove 选择吃子价值最高的一步，价值相同时随机选择
+func (e *Greedy) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	statements := game.GetLegalStatements(group)
+	if len(statements) == 0 {
+		return player.Statement{}, ErrNoMoves
+	}
+
+	pos := game.GetPosition()
+	redIsDown := game.GetRedIsDown()
+	best := make([]player.Statement, 0)
+	bestValue := -1
+	for _, st := range statements {
+		value := 0
+		if piece := pos.At(position.ToSquare(st.Target, redIsDown)); len(piece.Code) > 0 {
+			value = pieceValues[piece.Code]
+		}
+		if value > bestValue {
+			best = best[:0]
+			bestValue = value
+		}
+		if value == bestValue {
+			best = append(best, st)
+		}
+	}
+
+	e.random.mu.Lock()
+	defer e.random.mu.Unlock()
+	return best[e.random.rand.Intn(len(best))], nil
+}
+
+// NewEngine 按名称创建内置引擎，名称无效时返回错误
+func NewEngine(name string, seed int64) (Engine, error) {
+	switch name {
+	case "random":
+		return NewRandom(seed), nil
+	case "greedy":
+		return NewGreedy(seed), nil
+	case "search":
+		e, err := NewSearch(DefaultSearchDepth, seed)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	return nil, errors.New("unknown engine " + name)
+}
@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// DefaultMaxPlies 自动对局默认的步数限制，双方各走一步算两步
+const DefaultMaxPlies = 300
+
+// GameOptions 自动对局的设置
+type GameOptions struct {
+	MaxPlies int      //步数限制，超过后判和，小于等于0时使用DefaultMaxPlies
+	Opening  *Opening //开局，为nil时从初始局面开始
+}
+
+// GameResult 自动对局的结果
+type GameResult struct {
+	Outcome rating.Outcome
+	Reason  chessgame.Termination
+	Plies   int                //双方一共走了多少步，包括开局的着法
+	Record  storage.GameRecord //对局记录，玩家id需要调用方填写
+}
+
+// PlayGame 让两个引擎在内核中自动下完一局，红方先手
+func PlayGame(redEngine, blackEngine Engine, maxPlies int) (*GameResult, error) {
+	red := NewBot(redEngine)
+	red.SetIsFirst(true)
+	black := NewBot(blackEngine)
+	return PlayBots(red, black, GameOptions{MaxPlies: maxPlies})
+}
+
+// PlayBots 让两个Bot在内核中自动下完一局。先手由SetIsFirst决定，先手执红并位于棋盘下方。
+// 同一对Bot可以反复对局，每局开始前会重置Bot的状态
+func PlayBots(first, second *Bot, opts GameOptions) (*GameResult, error) {
+	if first.GetIsFirst() == second.GetIsFirst() {
+		return nil, errors.New("exactly one bot must be the first player")
+	}
+	maxPlies := opts.MaxPlies
+	if maxPlies <= 0 {
+		maxPlies = DefaultMaxPlies
+	}
+
+	red, black := first, second
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	red.SetGroup(core.Group1)
+	red.SetIsDown(true)
+	black.SetGroup(core.Group2)
+	black.SetIsDown(false)
+	for _, b := range []*Bot{red, black} {
+		b.reset()
+		b.ClearOwnChessman()
+		b.ClearLostChessman()
+		b.ClearWonChessman()
+	}
+
+	game := new(chessgame.ChessGame)
+	if err := game.InitialGame(red, black); err != nil {
+		return nil, err
+	}
+	openingPlies := 0
+	if opts.Opening != nil {
+		if len(opts.Opening.FEN) > 0 {
+			pos, err := position.ParseFEN(opts.Opening.FEN)
+			if err != nil {
+				return nil, err
+			}
+			if err = game.SetPosition(pos); err != nil {
+				return nil, err
+			}
+		}
+		s := &script{moves: opts.Opening.Moves}
+		red.script = s
+		black.script = s
+		openingPlies = len(opts.Opening.Moves)
+	}
+	red.Attach(game)
+	black.Attach(game)
+
+	toMove, waiting := red, black
+	if game.GetNextRoundGroup() != red.GetGroup() {
+		toMove, waiting = black, red
+	}
+
+	startedAt := time.Now()
+	//Bot不读取通道，内核直接询问引擎
+	msgChan := game.Run(nil, nil)
+	plies := 0
+	var final *chessgame.GameMsg
+	var openingErr error
+	for msg := range msgChan {
+		if final != nil || openingErr != nil {
+			//已经判定结果，等待内核退出
+			continue
+		}
+		switch msg.Event {
+		case chessgame.Done:
+			plies++
+			toMove, waiting = waiting, toMove
+			if plies >= maxPlies {
+				over := chessgame.NewGameOver(core.GroupNone, chessgame.TerminationMaxPlies)
+				final = &over
+			}
+		case chessgame.Fin:
+			plies++
+			final = &msg
+		case chessgame.Err:
+			if plies < openingPlies {
+				openingErr = fmt.Errorf("invalid opening move %d: %s", plies+1, msg.Rejected.Detail)
+				break
+			}
+			over := chessgame.NewGameOver(waiting.GetGroup(), toMove.LossReason(msg.Rejected))
+			final = &over
+		}
+		if final != nil || openingErr != nil {
+			red.Stop()
+			black.Stop()
+		}
+	}
+
+	if openingErr != nil || final == nil {
+		game.Close()
+		if openingErr == nil {
+			openingErr = errors.New("game stopped without result")
+		}
+		return nil, openingErr
+	}
+
+	outcome, _ := rating.OutcomeOf(*final, red.GetGroup())
+	result := &GameResult{
+		Outcome: outcome,
+		Reason:  final.GameOver.Termination,
+		Plies:   plies,
+		Record:  storage.NewGameRecord(game, red, black, *final, startedAt),
+	}
+	game.Close()
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Opening 自动对局的开局：从FEN局面（为空时从初始局面）开始，双方先按顺序走完Moves
+type Opening struct {
+	Name  string
+	FEN   string
+	Moves []position.Move
+}
+
+// ParseOpening 解析开局文件中的一行。
+// 一行可以是一个FEN局面，也可以是从初始局面开始的ICCS着法序列，二者之间用moves连接也可以；
+// 行尾 # 之后的内容作为开局名称
+func ParseOpening(line string) (Opening, error) {
+	var op Opening
+	if i := strings.Index(line, "#"); i >= 0 {
+		op.Name = strings.TrimSpace(line[i+1:])
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+
+	//FEN的第一段包含斜杠，后面可能跟着走棋方等字段
+	if len(fields) > 0 && strings.Contains(fields[0], "/") {
+		end := len(fields)
+		for i, f := range fields {
+			if f == "moves" {
+				end = i
+				break
+			}
+		}
+		op.FEN = strings.Join(fields[:end], " ")
+		if _, err := position.ParseFEN(op.FEN); err != nil {
+			return op, err
+		}
+		fields = fields[end:]
+	}
+	if len(fields) > 0 && fields[0] == "moves" {
+		fields = fields[1:]
+	}
+
+	for _, f := range fields {
+		m, err := position.ParseMove(f)
+		if err != nil {
+			return op, err
+		}
+		op.Moves = append(op.Moves, m)
+	}
+	return op, nil
+}
+
+// LoadOpenings 读取开局文件，每行一个开局，忽略空行和以 # 开头的注释行
+func LoadOpenings(r io.Reader) ([]Opening, error) {
+	openings := make([]Opening, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		op, err := ParseOpening(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		openings = append(openings, op)
+	}
+	return openings, scanner.Err()
+}
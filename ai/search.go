@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// DefaultSearchDepth 搜索引擎默认的搜索深度
+const DefaultSearchDepth = 2
+
+// 过河兵卒额外的价值
+const crossedPawnBonus = 100
+
+// Search 用alpha-beta剪枝的极大极小搜索走棋的引擎，局面只按子力和过河兵卒评价。
+// 搜索depth步，depth越大越强，也越慢：开局阶段深度3每步约0.1秒，深度4约0.5秒
+type Search struct {
+	depth int
+
+	mu      sync.Mutex
+	referee *chessgame.Referee
+	rand    *rand.Rand
+}
+
+// NewSearch 创建搜索引擎，分数相同的着法按seed随机选择
+func NewSearch(depth int, seed int64) (*Search, error) {
+	referee, err := chessgame.NewReferee()
+	if err != nil {
+		return nil, err
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return &Search{depth: depth, referee: referee, rand: rand.New(rand.NewSource(seed))}, nil
+}
+
+func (e *Search) Name() string {
+	return "search"
+}
+
+// Depth 搜索深度
+func (e *Search) Depth() int {
+	return e.depth
+}
+
+// BestMove 搜索当前局面，选择分数最高的着法
+func (e *Search) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	m, err := e.Search(game.GetPosition())
+	if err != nil {
+		return player.Statement{}, err
+	}
+	return game.ParseMove(m)
+}
+
+// Search 搜索红方视角的局面，返回走棋方分数最高的着法，分数相同时随机选择
+func (e *Search) Search(pos position.Position) (position.Move, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	moves, err := e.referee.LegalMoves(pos)
+	if err != nil {
+		return position.Move{}, err
+	}
+	if len(moves) == 0 {
+		return position.Move{}, ErrNoMoves
+	}
+	orderMoves(pos, moves)
+
+	best := make([]position.Move, 0)
+	bestScore := -MateScore - 1
+	for _, m := range moves {
+		child := pos
+		captured := child.Play(m)
+		score := MateScore - 1
+		if captured.Code != core.JiangShuai {
+			//窗口下界比最高分低1，和最高分相同的着法也能得到准确的分数
+			s, err := e.negamax(child, e.depth-1, 1, -MateScore-1, -(bestScore - 1))
+			if err != nil {
+				return position.Move{}, err
+			}
+			score = -s
+		}
+		if score > bestScore {
+			best = best[:0]
+			bestScore = score
+		}
+		if score == bestScore {
+			best = append(best, m)
+		}
+	}
+	return best[e.rand.Intn(len(best))], nil
+}
+
+// 从走棋方的角度搜索局面的分数，ply是距离根局面的步数，越快的杀棋分数越高
+func (e *Search) negamax(pos position.Position, depth, ply, alpha, beta int) (int, error) {
+	if depth == 0 {
+		return evaluate(pos), nil
+	}
+	moves, err := e.referee.LegalMoves(pos)
+	if err != nil {
+		return 0, err
+	}
+	if len(moves) == 0 {
+		//没有可以走的棋判负
+		return -MateScore + ply, nil
+	}
+	orderMoves(pos, moves)
+	for _, m := range moves {
+		child := pos
+		score := MateScore - ply - 1
+		if captured := child.Play(m); captured.Code != core.JiangShuai {
+			s, err := e.negamax(child, depth-1, ply+1, -beta, -alpha)
+			if err != nil {
+				return 0, err
+			}
+			score = -s
+		}
+		if score >= beta {
+			return beta, nil
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha, nil
+}
+
+// 从走棋方的角度评价局面：双方子力之差，过河的兵卒价值更高
+func evaluate(pos position.Position) int {
+	score := 0
+	for rank, row := range pos.Squares {
+		for _, p := range row {
+			if len(p.Code) == 0 {
+				continue
+			}
+			value := pieceValues[p.Code]
+			if p.Code == core.BingZu && (p.Red && rank >= 5 || !p.Red && rank <= 4) {
+				value += crossedPawnBonus
+			}
+			if p.Red != pos.RedToMove {
+				value = -value
+			}
+			score += value
+		}
+	}
+	return score
+}
+
+// 吃子的着法排在前面，被吃的棋子价值越高越靠前，剪枝效果更好
+func orderMoves(pos position.Position, moves []position.Move) {
+	sort.SliceStable(moves, func(i, j int) bool {
+		return pieceValues[pos.At(moves[i].To).Code] > pieceValues[pos.At(moves[j].To).Code]
+	})
+}
@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/tablebase"
+)
+
+// TablebaseEngine 局面在残局库中时按残局库走棋，否则交给其他引擎计算
+type TablebaseEngine struct {
+	tb     *tablebase.Tablebase
+	engine Engine
+}
+
+// NewTablebaseEngine 创建使用残局库的引擎
+func NewTablebaseEngine(tb *tablebase.Tablebase, engine Engine) *TablebaseEngine {
+	return &TablebaseEngine{tb: tb, engine: engine}
+}
+
+func (e *TablebaseEngine) Name() string {
+	return e.engine.Name() + "+tb"
+}
+
+// BestMove 必胜时走最快将死对方的着法，必负时走最慢被将死的着法，否则走保持和棋的着法
+func (e *TablebaseEngine) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	if st, _, ok := e.TablebaseMove(game, group); ok {
+		return st, nil
+	}
+	return e.engine.BestMove(game, group)
+}
+
+// TablebaseMove 从残局库中选择一步棋，同时返回走棋之前局面的结果，局面不在残局库中时返回false
+func (e *TablebaseEngine) TablebaseMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, tablebase.Result, bool) {
+	pos := game.GetPosition()
+	if m, ok := tablebase.MaterialOf(pos); !ok {
+		return player.Statement{}, tablebase.Result{}, false
+	} else if _, _, ok := e.tb.Table(m); !ok {
+		return player.Statement{}, tablebase.Result{}, false
+	}
+	statements := game.GetLegalStatements(group)
+	//对方送将时直接吃将，这样的局面在残局库中是不合法的
+	for _, st := range statements {
+		if pos.At(game.FormatMove(st).To).Code == core.JiangShuai {
+			return st, tablebase.Result{WDL: tablebase.Win, DTM: 1}, true
+		}
+	}
+	result, ok := e.tb.Probe(pos)
+	if !ok {
+		return player.Statement{}, tablebase.Result{}, false
+	}
+	moves, ok := e.tb.ProbeMoves(pos)
+	if !ok || len(moves) == 0 {
+		return player.Statement{}, tablebase.Result{}, false
+	}
+	st, err := game.ParseMove(moves[0].Move)
+	if err != nil || st.Group != group {
+		return player.Statement{}, tablebase.Result{}, false
+	}
+	for _, legal := range statements {
+		if legal == st {
+			return st, result, true
+		}
+	}
+	return player.Statement{}, tablebase.Result{}, false
+}
+
+// Close 关闭被包装的引擎
+func (e *TablebaseEngine) Close() error {
+	if c, ok := e.engine.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package ai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// UCCIOptions UCCI引擎的搜索设置，Depth和MoveTime都为0时使用深度DefaultUCCIDepth
+type UCCIOptions struct {
+	Depth    int           //搜索深度
+	MoveTime time.Duration //每步的思考时间，设置了Depth时忽略
+	Timeout  time.Duration //等待引擎回复的最长时间，为0时使用DefaultUCCITimeout
+}
+
+// UCCI引擎的默认设置
+const (
+	DefaultUCCIDepth   = 8
+	DefaultUCCITimeout = time.Minute
+)
+
+// UCCI 通过标准输入输出和UCCI协议的象棋引擎进程通信
+type UCCI struct {
+	mu    sync.Mutex
+	name  string
+	opts  UCCIOptions
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string //引擎输出的每一行，引擎退出后关闭
+}
+
+// NewUCCI 启动引擎进程并完成ucci握手
+func NewUCCI(path string, args []string, opts UCCIOptions) (*UCCI, error) {
+	if opts.Depth <= 0 && opts.MoveTime <= 0 {
+		opts.Depth = DefaultUCCIDepth
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultUCCITimeout
+	}
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &UCCI{name: path, opts: opts, cmd: cmd, stdin: stdin, lines: make(chan string, 64)}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(e.lines)
+	}()
+
+	if err = e.send("ucci"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			e.Close()
+			return nil, err
+		}
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			e.name = name
+		}
+		if line == "ucciok" {
+			return e, nil
+		}
+	}
+}
+
+func (e *UCCI) Name() string {
+	return e.name
+}
+
+// BestMove 把当前局面发送给引擎，等待引擎给出的着法
+func (e *UCCI) BestMove(game chessgame.ChessGameInterface, group core.ChessmanGroup) (player.Statement, error) {
+	best, _, err := e.search(game.GetPosition())
+	if err != nil {
+		return player.Statement{}, err
+	}
+	if best == nil {
+		return player.Statement{}, ErrNoMoves
+	}
+	st, err := game.ParseMove(*best)
+	if err != nil {
+		return player.Statement{}, err
+	}
+	if st.Group != group {
+		return player.Statement{}, fmt.Errorf("engine moved the opponent's chessman: %s", best)
+	}
+	return st, nil
+}
+
+// Analyze 分析局面，返回引擎最后输出的搜索信息。走棋方无棋可走时返回被将死的分数
+func (e *UCCI) Analyze(pos position.Position) (Analysis, error) {
+	best, info, err := e.search(pos)
+	if err != nil {
+		return Analysis{}, err
+	}
+	if best == nil {
+		return Analysis{Score: -MateScore, Mate: true}, nil
+	}
+	//引擎没有输出主要变例时，至少包含最佳着法
+	if len(info.PV) == 0 || info.PV[0] != *best {
+		info.PV = []position.Move{*best}
+	}
+	return info, nil
+}
+
+// 让引擎搜索局面，返回最佳着法和最后一条搜索信息。引擎回复nobestmove时最佳着法为nil
+func (e *UCCI) search(pos position.Position) (*position.Move, Analysis, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var info Analysis
+	if err := e.send("position fen " + pos.FEN() + " - - 0 1"); err != nil {
+		return nil, info, err
+	}
+	goCmd := fmt.Sprintf("go depth %d", e.opts.Depth)
+	if e.opts.Depth <= 0 {
+		goCmd = fmt.Sprintf("go time %d movestogo 1", e.opts.MoveTime.Milliseconds())
+	}
+	if err := e.send(goCmd); err != nil {
+		return nil, info, err
+	}
+
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			return nil, info, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "info":
+			if a, ok := parseInfo(fields[1:]); ok {
+				info = a
+			}
+		case "nobestmove":
+			return nil, info, nil
+		case "bestmove":
+			if len(fields) < 2 {
+				return nil, info, fmt.Errorf("invalid engine output: %q", line)
+			}
+			m, err := position.ParseMove(fields[1])
+			if err != nil {
+				return nil, info, err
+			}
+			return &m, info, nil
+		}
+	}
+}
+
+// Close 通知引擎退出并等待进程结束
+func (e *UCCI) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_ = e.send("quit")
+	_ = e.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(e.opts.Timeout):
+		_ = e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// 向引擎发送一条指令
+func (e *UCCI) send(line string) error {
+	_, err := io.WriteString(e.stdin, line+"\n")
+	return err
+}
+
+// 读取引擎输出的一行，超时或者引擎退出时返回错误
+func (e *UCCI) readLine() (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", errors.New("engine exited")
+		}
+		return line, nil
+	case <-time.After(e.opts.Timeout):
+		return "", errors.New("engine timed out")
+	}
+}
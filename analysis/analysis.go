@@ -0,0 +1,205 @@
+// Package analysis 用引擎分析对局。
+// 对局中每个局面都交给引擎分析，根据走棋前后走棋方分数的下降判断每步棋是否是缓着、失误或者败着
+package analysis
+
+import (
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Analyzer 分析一个局面，ai.UCCI实现了这个接口
+type Analyzer interface {
+	Analyze(pos position.Position) (ai.Analysis, error)
+}
+
+// Judgement 对一步棋的评价
+type Judgement int
+
+const (
+	Good       Judgement = iota //正常的着法
+	Inaccuracy                  //缓着
+	Mistake                     //失误
+	Blunder                     //败着
+)
+
+func (j Judgement) String() string {
+	return [...]string{"", "缓着", "失误", "败着"}[j]
+}
+
+// Symbol 棋谱中的评注符号
+func (j Judgement) Symbol() string {
+	return [...]string{"", "?!", "?", "??"}[j]
+}
+
+// Thresholds 走棋方分数下降多少分时判为缓着、失误和败着
+type Thresholds struct {
+	Inaccuracy int
+	Mistake    int
+	Blunder    int
+}
+
+// DefaultThresholds 默认的判断标准
+var DefaultThresholds = Thresholds{Inaccuracy: 50, Mistake: 150, Blunder: 300}
+
+// Judge 按分数的下降判断
+func (th Thresholds) Judge(drop int) Judgement {
+	switch {
+	case drop >= th.Blunder:
+		return Blunder
+	case drop >= th.Mistake:
+		return Mistake
+	case drop >= th.Inaccuracy:
+		return Inaccuracy
+	}
+	return Good
+}
+
+// Report 一局棋的分析结果。局面i是走了i步之后的局面，第i步棋从局面i-1走到局面i
+type Report struct {
+	Thresholds Thresholds
+
+	moves     []position.Move
+	positions []position.Position
+	results   []*ai.Analysis //每个局面的分析，还没有分析时为nil
+}
+
+// NewReport 按起始局面和着法生成所有局面，等待分析
+func NewReport(start position.Position, moves []position.Move) *Report {
+	r := &Report{
+		Thresholds: DefaultThresholds,
+		moves:      moves,
+		positions:  make([]position.Position, 0, len(moves)+1),
+		results:    make([]*ai.Analysis, len(moves)+1),
+	}
+	pos := start
+	r.positions = append(r.positions, pos)
+	for _, m := range moves {
+		pos.Play(m)
+		r.positions = append(r.positions, pos)
+	}
+	return r
+}
+
+// Plies 着法的步数
+func (r *Report) Plies() int {
+	return len(r.moves)
+}
+
+// Position 走了i步之后的局面
+func (r *Report) Position(i int) position.Position {
+	return r.positions[i]
+}
+
+// Move 第i步棋，i从1开始
+func (r *Report) Move(i int) position.Move {
+	return r.moves[i-1]
+}
+
+// Notation 第i步棋的中文记谱
+func (r *Report) Notation(i int) string {
+	return r.positions[i-1].ChineseMove(r.moves[i-1])
+}
+
+// Set 记录局面i的分析
+func (r *Report) Set(i int, a ai.Analysis) {
+	r.results[i] = &a
+}
+
+// Analysis 获取局面i的分析，还没有分析时返回false
+func (r *Report) Analysis(i int) (ai.Analysis, bool) {
+	if r.results[i] == nil {
+		return ai.Analysis{}, false
+	}
+	return *r.results[i], true
+}
+
+// Analyzed 已经分析的局面数
+func (r *Report) Analyzed() int {
+	n := 0
+	for _, a := range r.results {
+		if a != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Done 所有局面是否都已经分析
+func (r *Report) Done() bool {
+	return r.Analyzed() == len(r.results)
+}
+
+// RedScore 局面i从红方角度计算的分数，还没有分析时返回false
+func (r *Report) RedScore(i int) (int, bool) {
+	a, ok := r.Analysis(i)
+	if !ok {
+		return 0, false
+	}
+	if r.positions[i].RedToMove {
+		return a.Score, true
+	}
+	return -a.Score, true
+}
+
+// Judge 评价第i步棋，返回评价和走棋方分数的下降。前后两个局面都分析完之后才返回true。
+// 走出引擎的最佳着法时总是正常的着法
+func (r *Report) Judge(i int) (Judgement, int, bool) {
+	before, ok1 := r.Analysis(i - 1)
+	after, ok2 := r.Analysis(i)
+	if !ok1 || !ok2 {
+		return Good, 0, false
+	}
+	//走棋之后轮到对方走棋，对方的分数就是走棋方分数的相反数
+	drop := before.Score + after.Score
+	if best, ok := before.BestMove(); ok && best == r.moves[i-1] {
+		return Good, drop, true
+	}
+	return r.Thresholds.Judge(drop), drop, true
+}
+
+// Flagged 已经分析出的缓着、失误和败着所在的步数
+func (r *Report) Flagged() []int {
+	flagged := make([]int, 0)
+	for i := 1; i <= len(r.moves); i++ {
+		if j, _, ok := r.Judge(i); ok && j != Good {
+			flagged = append(flagged, i)
+		}
+	}
+	return flagged
+}
+
+// Analyze 分析局面i。走棋方的将帅已经被吃掉时不需要引擎，直接记为被杀
+func (r *Report) Analyze(a Analyzer, i int) (ai.Analysis, error) {
+	pos := r.positions[i]
+	if !hasKing(pos, pos.RedToMove) {
+		return ai.Analysis{Score: -ai.MateScore, Mate: true}, nil
+	}
+	return a.Analyze(pos)
+}
+
+// Run 依次分析所有局面，每分析完一个局面调用一次progress
+func (r *Report) Run(a Analyzer, progress func(i int)) error {
+	for i := range r.positions {
+		result, err := r.Analyze(a, i)
+		if err != nil {
+			return err
+		}
+		r.Set(i, result)
+		if progress != nil {
+			progress(i)
+		}
+	}
+	return nil
+}
+
+func hasKing(pos position.Position, red bool) bool {
+	for _, row := range pos.Squares {
+		for _, p := range row {
+			if p.Code == core.JiangShuai && p.Red == red {
+				return true
+			}
+		}
+	}
+	return false
+}
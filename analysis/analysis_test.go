@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+func parseMoves(t *testing.T, moves ...string) []position.Move {
+	t.Helper()
+	result := make([]position.Move, len(moves))
+	for i, s := range moves {
+		m, err := position.ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[i] = m
+	}
+	return result
+}
+
+// 按给定的分数和最佳着法回答，局面依次分析
+type fakeAnalyzer struct {
+	results []ai.Analysis
+	calls   int
+}
+
+func (f *fakeAnalyzer) Analyze(pos position.Position) (ai.Analysis, error) {
+	a := f.results[f.calls]
+	f.calls++
+	return a, nil
+}
+
+func TestJudge(t *testing.T) {
+	moves := parseMoves(t, "h2e2", "h9g7", "e2e6", "g7e6")
+	r := NewReport(position.Initial(), moves)
+	if r.Plies() != 4 || r.Notation(1) != "炮二平五" || r.Notation(4) != "马7进5" {
+		t.Fatalf("plies = %d, notation = %s %s", r.Plies(), r.Notation(1), r.Notation(4))
+	}
+	fake := &fakeAnalyzer{results: []ai.Analysis{
+		{Score: 20, PV: parseMoves(t, "h2e2")},
+		{Score: -20, PV: parseMoves(t, "h9g7")},
+		{Score: 30, PV: parseMoves(t, "b0c2")},
+		{Score: 300, PV: parseMoves(t, "g7e6")}, //炮打中兵被马吃掉，黑方大优
+		{Score: -320, PV: parseMoves(t, "b0c2")},
+	}}
+	if _, _, ok := r.Judge(1); ok {
+		t.Fatal("judged before analysis")
+	}
+	var progress []int
+	if err := r.Run(fake, func(i int) { progress = append(progress, i) }); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Done() || len(progress) != 5 {
+		t.Fatalf("progress = %v", progress)
+	}
+
+	if score, _ := r.RedScore(3); score != -300 {
+		t.Errorf("red score = %d", score)
+	}
+	for i, want := range []Judgement{Good, Good, Blunder, Good} {
+		if j, drop, _ := r.Judge(i + 1); j != want {
+			t.Errorf("move %d: %v (drop %d), want %v", i+1, j, drop, want)
+		}
+	}
+	if flagged := r.Flagged(); len(flagged) != 1 || flagged[0] != 3 {
+		t.Errorf("flagged = %v", flagged)
+	}
+
+	r.Thresholds = Thresholds{Inaccuracy: 100, Mistake: 300, Blunder: 1000}
+	if j, drop, _ := r.Judge(3); j != Mistake || drop != 330 {
+		t.Errorf("judge with thresholds = %v %d", j, drop)
+	}
+}
+
+// 将帅被吃掉之后的局面不交给引擎
+func TestCapturedKing(t *testing.T) {
+	start, err := position.ParseFEN("4k4/9/9/9/9/9/9/9/9/4K4 w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReport(start, parseMoves(t, "e0e9"))
+	fake := &fakeAnalyzer{results: []ai.Analysis{{Score: ai.MateScore - 1, Mate: true, PV: parseMoves(t, "e0e9")}}}
+	if err := r.Run(fake, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fake.calls != 1 {
+		t.Fatalf("engine called %d times", fake.calls)
+	}
+	if score, _ := r.RedScore(1); score != ai.MateScore {
+		t.Errorf("red score = %d", score)
+	}
+	if j, _, _ := r.Judge(1); j != Good {
+		t.Errorf("judge = %v", j)
+	}
+}
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/analysis"
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/storage"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 分析结果中显示的最佳变例步数
+const bestLinePlies = 5
+
+// 后台分析一个局面的结果
+type analysisResult struct {
+	index    int
+	analysis ai.Analysis
+	err      error
+}
+
+// 分析模式的状态
+type analysisMode struct {
+	report  *analysis.Report
+	engine  analysis.Analyzer
+	index   int                 //当前显示的局面
+	results chan analysisResult //后台分析的结果
+	quit    chan struct{}       //通知后台停止分析
+	err     error               //引擎出错时停止分析
+}
+
+// LoadLocalGame 读取本机保存的对局记录，id为0时读取最近的一局
+func LoadLocalGame(id int) (storage.GameRecord, error) {
+	repo := newLocalRepository()
+	defer repo.Close()
+	if id != 0 {
+		rec, ok := repo.GetGame(id)
+		if !ok {
+			return rec, fmt.Errorf("game %d not found", id)
+		}
+		return rec, nil
+	}
+	var latest storage.GameRecord
+	for _, rec := range repo.ListGames(0) {
+		if rec.ID > latest.ID {
+			latest = rec
+		}
+	}
+	if latest.ID == 0 {
+		return latest, errors.New("no saved games")
+	}
+	return latest, nil
+}
+
+// NewAnalysisGame 创建分析模式的游戏：回放对局记录，引擎在后台依次分析每个局面。
+// 红方总是位于棋盘下方。左右方向键前后翻看，Home/End 回到开始/结尾，M 跳到下一个有问题的着法。
+// 引擎实现了io.Closer时，游戏关闭时一并关闭引擎
+func NewAnalysisGame(rec storage.GameRecord, engine analysis.Analyzer) (*Game, error) {
+	start, err := rec.StartPosition()
+	if err != nil {
+		return nil, err
+	}
+	p1 := player.NewPlayer()
+	p1.SetID(localPlayer1ID)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p1.SetGroup(core.Group1)
+	p2 := player.NewPlayer()
+	p2.SetID(localPlayer2ID)
+	p2.SetGroup(core.Group2)
+
+	g := newGame(p1, p2)
+	am := &analysisMode{
+		report:  analysis.NewReport(start, rec.PositionMoves()),
+		engine:  engine,
+		results: make(chan analysisResult, 16),
+		quit:    make(chan struct{}),
+	}
+	g.analysis = am
+	g.showAnalysisPosition()
+	go am.run()
+	return g, nil
+}
+
+// 在后台依次分析所有局面，出错或者收到停止通知时结束
+func (am *analysisMode) run() {
+	for i := 0; i <= am.report.Plies(); i++ {
+		select {
+		case <-am.quit:
+			return
+		default:
+		}
+		a, err := am.report.Analyze(am.engine, i)
+		select {
+		case am.results <- analysisResult{index: i, analysis: a, err: err}:
+		case <-am.quit:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// 停止后台分析并关闭引擎
+func (am *analysisMode) close() {
+	close(am.quit)
+	if c, ok := am.engine.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Printf("close engine: %v", err)
+		}
+	}
+}
+
+// 收取后台分析的结果
+func (am *analysisMode) poll() {
+	for {
+		select {
+		case r := <-am.results:
+			if r.err != nil {
+				am.err = r.err
+				continue
+			}
+			am.report.Set(r.index, r.analysis)
+		default:
+			return
+		}
+	}
+}
+
+// 按当前局面重新摆棋，并高亮显示走到这里的一步棋
+func (g *Game) showAnalysisPosition() {
+	am := g.analysis
+	g.placeSprites(am.report.Position(am.index))
+	g.clickedSprite = nil
+	g.gameMsg = nil
+	if am.index == 0 {
+		return
+	}
+	m := am.report.Move(am.index)
+	g.lastMove = &player.Statement{Source: position.ToCoordinate(m.From, true), Target: position.ToCoordinate(m.To, true)}
+}
+
+// 分析模式的输入
+func (g *Game) updateAnalysis() error {
+	am := g.analysis
+	am.poll()
+	index := am.index
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		index = min(index+1, am.report.Plies())
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		index = max(index-1, 0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		index = 0
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		index = am.report.Plies()
+	case inpututil.IsKeyJustPressed(ebiten.KeyM):
+		//跳到下一个有问题的着法，到结尾之后从头开始
+		flagged := am.report.Flagged()
+		for _, i := range flagged {
+			if i > am.index {
+				index = i
+				break
+			}
+		}
+		if index == am.index && len(flagged) > 0 {
+			index = flagged[0]
+		}
+	}
+	if index != am.index {
+		am.index = index
+		g.showAnalysisPosition()
+	}
+	return nil
+}
+
+// 绘制评分条、当前着法的评价和最佳变例
+func (g *Game) drawAnalysis(screen *ebiten.Image) {
+	am := g.analysis
+	r := am.report
+	g.drawEvalBar(screen)
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	header := i18n.T("分析 第%d/%d步", am.index, r.Plies())
+	if am.index > 0 {
+		header += " " + i18n.Move(r.Notation(am.index))
+		if j, drop, ok := r.Judge(am.index); ok && j != analysis.Good {
+			header += i18n.T(" %s%s（-%d）", j.Symbol(), i18n.T(j.String()), drop)
+		}
+	}
+	if score, ok := r.RedScore(am.index); ok {
+		header += "  " + formatScore(score)
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), 4)
+	drawText(screen, header, f, op)
+
+	lines := make([]string, 0, 3)
+	if a, ok := r.Analysis(am.index); ok && len(a.PV) > 0 {
+		lines = append(lines, i18n.T("最佳（深度%d）：%s", a.Depth, notationLine(r.Position(am.index), a.PV)))
+	}
+	status := i18n.T("已分析%d/%d个局面", r.Analyzed(), r.Plies()+1)
+	switch {
+	case am.err != nil:
+		status = i18n.T("引擎出错：%s", am.err)
+	case r.Done():
+		status = i18n.T("分析完成，%d步有问题", len(r.Flagged()))
+	}
+	lines = append(lines, status, i18n.T("← → 翻看  Home/End 开始/结尾  M 下一个问题手"))
+
+	f.Size = 16
+	y := float64(g.boardLogicZeroPoint.y + 9*gridLength + 4)
+	for _, line := range lines {
+		op = &text.DrawOptions{}
+		op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), y)
+		drawText(screen, line, f, op)
+		y += 20
+	}
+}
+
+// 在棋盘左侧绘制评分条，红色部分越长红方越占优
+func (g *Game) drawEvalBar(screen *ebiten.Image) {
+	am := g.analysis
+	x := float32(g.boardLogicZeroPoint.x) - 26
+	y := float32(g.boardLogicZeroPoint.y)
+	width, height := float32(12), float32(9*gridLength)
+	fillRect(screen, x, y, width, height, color.RGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}, false)
+	score, ok := am.report.RedScore(am.index)
+	if !ok {
+		return
+	}
+	red := height * float32(redShare(score))
+	fillRect(screen, x, y+height-red, width, red, color.RGBA{R: 0xc0, G: 0x20, B: 0x20, A: 0xff}, false)
+	strokeLine(screen, x, y+height/2, x+width, y+height/2, 1, color.White, false)
+}
+
+// 红方分数换算成评分条中红色部分的比例，相差约一个车时接近九成
+func redShare(score int) float64 {
+	return 1 / (1 + math.Exp(-float64(score)/400))
+}
+
+// 红方视角的分数，算出杀棋时显示哪一方能杀
+func formatScore(score int) string {
+	switch {
+	case score >= ai.MateScore-1000:
+		return i18n.T("红方杀棋")
+	case score <= -ai.MateScore+1000:
+		return i18n.T("黑方杀棋")
+	}
+	return i18n.T("红方%+d", score)
+}
+
+// 变例的记谱，按照界面语言显示，最多显示bestLinePlies步
+func notationLine(pos position.Position, moves []position.Move) string {
+	names := make([]string, 0, bestLinePlies)
+	for i, m := range moves {
+		if i == bestLinePlies {
+			names = append(names, "…")
+			break
+		}
+		names = append(names, i18n.Move(pos.ChineseMove(m)))
+		pos.Play(m)
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/core/chessclock"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// Difficulties 人机对战的难度，难度n的电脑搜索n步
+var Difficulties = []string{"入门", "初级", "中级", "高级"}
+
+// 电脑玩家的id，每个难度单独记录评分
+const computerPlayerID = 100
+
+// 创建指定难度的电脑玩家
+func newComputer(level int) (*ai.Bot, error) {
+	if level < 1 || level > len(Difficulties) {
+		return nil, fmt.Errorf("unknown difficulty %d", level)
+	}
+	engine, err := ai.NewSearch(level, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	bot := ai.NewBot(engine)
+	bot.SetID(computerPlayerID + level)
+	return bot, nil
+}
+
+// 电脑玩家在积分榜上的名字
+func computerName(level int) string {
+	return fmt.Sprintf("电脑（%s）", Difficulties[level-1])
+}
+
+// 电脑是否正在思考
+func (g *Game) computerThinking() bool {
+	return g.computer != nil && len(g.winner) == 0 && g.nextRoundGroup == g.computer.GetGroup()
+}
+
+// 电脑的着法由内核的协程计算，界面不等待，每帧检查一次是否算好
+func (g *Game) pollComputer() {
+	if !g.computerThinking() {
+		return
+	}
+	select {
+	case msg, ok := <-g.coreCh:
+		if !ok {
+			return
+		}
+		if msg.Event == chessgame.Err {
+			//电脑走了不符合规则的棋、无棋可走或者引擎出错，判电脑负。
+			//被拒绝之后内核会再次询问电脑，先让电脑停止下棋，内核随之退出
+			g.computer.Stop()
+			g.endGame(chessgame.NewGameOver(g.player1.GetGroup(), g.computer.LossReason(msg.Rejected)))
+			return
+		}
+		g.onResult(msg)
+	default:
+	}
+}
+
+// 结束单机对战的内核，界面不等待：电脑停止下棋，关闭双方的意图通道，内核随之退出。
+// 电脑可能还在思考，所以在后台读取内核剩余的消息直到管道关闭，再关闭内核，避免棋盘关闭之后引擎还在读取。
+// 返回的通道在内核关闭之后关闭
+func (g *Game) stopCore() chan struct{} {
+	if g.computer != nil {
+		g.computer.Stop()
+	}
+	close(g.p1Ch)
+	close(g.p2Ch)
+	gameCore, coreCh := g.gameCore, g.coreCh
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range coreCh {
+		}
+		gameCore.Close()
+	}()
+	return done
+}
+
+// 按照用时设置开始新的棋钟，红方先计时
+func (g *Game) startLocalClock() {
+	g.localClock = nil
+	if g.timeControl <= 0 {
+		return
+	}
+	g.localClock = chessclock.NewClock(g.timeControl)
+	g.localClock.Start(g.gameCore.GetNextRoundGroup())
+}
+
+// 走完一步棋之后轮到对方计时
+func (g *Game) switchLocalClock() {
+	if g.localClock != nil && len(g.winner) == 0 {
+		g.localClock.Start(g.nextRoundGroup)
+	}
+}
+
+// 走棋方用完时间判负
+func (g *Game) checkLocalClock() {
+	if g.localClock == nil || len(g.winner) > 0 {
+		return
+	}
+	group, expired := g.localClock.Expired()
+	if !expired {
+		return
+	}
+	won := g.player1.GetGroup()
+	if won == group {
+		won = g.player2.GetGroup()
+	}
+	g.endGame(chessgame.NewGameOver(won, chessgame.TerminationTimeout))
+}
+
+// 在着法列表下方显示单机对战的棋钟
+func (g *Game) drawLocalClock(screen *ebiten.Image, y int) {
+	if g.localClock == nil {
+		return
+	}
+	red, black := g.player1, g.player2
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	str := i18n.T("红方 %s  黑方 %s", formatRemaining(g.localClock.Remaining(red.GetGroup())), formatRemaining(g.localClock.Remaining(black.GetGroup())))
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      16,
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(panelX+panelPadding), float64(y))
+	drawText(screen, str, f, op)
+}
+
+// 剩余时间显示为分:秒，超时之后显示为0
+func formatRemaining(remaining time.Duration) string {
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int(remaining.Seconds())
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
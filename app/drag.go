@@ -0,0 +1,112 @@
+package app
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	moveFrames    = 12 //走棋动画的帧数
+	fadeFrames    = 12 //被吃掉的棋子淡出的帧数
+	dragThreshold = 4  //鼠标移动超过这么多像素才算拖动，否则是点击
+)
+
+// 拖动棋子的状态
+type dragState struct {
+	sprite           *Sprite
+	offsetX, offsetY int  //按下鼠标的位置相对棋子图片左上角的偏移
+	pressX, pressY   int  //按下鼠标的位置
+	moved            bool //已经拖动过，松开鼠标时落子
+}
+
+// 被吃掉之后正在淡出的棋子
+type fadingSprite struct {
+	sprite *Sprite
+	frame  int
+}
+
+// 在选中的棋子上按下鼠标，开始拖动
+func (g *Game) startDrag(sp *Sprite) {
+	x, y := cursorPosition()
+	g.drag = &dragState{sprite: sp, offsetX: x - sp.x, offsetY: y - sp.y, pressX: x, pressY: y}
+}
+
+// 拖动过程中让棋子跟随鼠标。松开鼠标时，如果把棋子拖到了棋盘上的另一个位置，返回修正到棋盘格之后的目标坐标；
+// 没有拖动时只是一次点击，棋子保持选中，仍然可以点击目标位置走棋
+func (g *Game) updateDrag() (core.Coordinate, bool) {
+	d := g.drag
+	if d == nil {
+		return core.Coordinate{}, false
+	}
+	x, y := cursorPosition()
+	if !d.moved && abs(x-d.pressX)+abs(y-d.pressY) > dragThreshold {
+		d.moved = true
+	}
+	if d.moved {
+		d.sprite.DragTo(x-d.offsetX, y-d.offsetY)
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		return core.Coordinate{}, false
+	}
+
+	g.drag = nil
+	if !d.moved {
+		return core.Coordinate{}, false
+	}
+	//先让棋子滑回原位，走棋生效之后再从松开的位置滑到目标位置
+	d.sprite.AnimateTo(d.sprite.x, d.sprite.y, moveFrames)
+	if g.clickedSprite != d.sprite || !g.InBoard(x, y) {
+		return core.Coordinate{}, false
+	}
+	tx, ty := g.transformCoordinate(g.revisesCoordinate(x, y))
+	sx, sy := g.transformCoordinate(d.sprite.x-g.spriteReparation, d.sprite.y-g.spriteReparation)
+	if tx == sx && ty == sy {
+		return core.Coordinate{}, false
+	}
+	return core.Coordinate{X: tx, Y: ty}, true
+}
+
+// 被吃掉的棋子从棋盘上删除，并在原位淡出
+func (g *Game) captureSprite(sp *Sprite) {
+	g.deleteSprite(sp)
+	sp.clicked = false
+	g.fading = append(g.fading, &fadingSprite{sprite: sp})
+}
+
+// 推进所有棋子的动画
+func (g *Game) updateAnimations() {
+	for _, sp := range g.sprites {
+		sp.Update()
+	}
+	fading := g.fading[:0]
+	for _, f := range g.fading {
+		f.frame++
+		if f.frame < fadeFrames {
+			fading = append(fading, f)
+		}
+	}
+	g.fading = fading
+}
+
+// 立即结束所有动画，例如断线重连之后一次摆好所有棋子
+func (g *Game) finishAnimations() {
+	for _, sp := range g.sprites {
+		sp.StopAnimation()
+	}
+	g.fading = nil
+	g.drag = nil
+}
+
+// 绘制淡出的棋子
+func (g *Game) drawFading(screen *ebiten.Image) {
+	for _, f := range g.fading {
+		f.sprite.Draw(screen, 1-float32(f.frame)/float32(fadeFrames))
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,14 +1,20 @@
 package app
 
 import (
-	"fmt"
+	"github.com/CXeon/xiangqi/ai"
 	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessclock"
 	"github.com/CXeon/xiangqi/core/chessgame"
 	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+	"github.com/CXeon/xiangqi/transport"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
-	"log"
+	"time"
 )
 
 type Game struct {
@@ -19,43 +25,227 @@ type Game struct {
 	spriteReparation    int        //设置sprite坐标时，x,y坐标的补偿值
 
 	gameMsg *chessgame.GameMsg //如果不为nil，需要显示在屏幕消息区
+	failMsg string             //服务器拒绝请求的原因，需要显示在屏幕消息区
 
 	winner       string        //游戏胜利者记录
 	onceAgainBtn *onceAgainBtn //再来一次按钮
 
 	//业务逻辑相关
-	player1 player.PlayerInterface //玩家1 在棋盘下方
-	player2 player.PlayerInterface //玩家2 在棋盘上方
+	player1 player.PlayerInterface //玩家1 默认在棋盘下方
+	player2 player.PlayerInterface //玩家2 默认在棋盘上方
 
 	p1Ch chan player.Statement //玩家1的下棋意图
 	p2Ch chan player.Statement //玩家2的下棋意图
 
 	clickedSprite  *Sprite            //当前棋盘被选中的棋子
 	nextRoundGroup core.ChessmanGroup //下一回合应该下棋的阵营
+	flipped        bool               //翻转棋盘，界面上下颠倒显示内核的棋盘
 
 	gameCore chessgame.ChessGameInterface //游戏内核
 	coreCh   chan chessgame.GameMsg       //管道接收内核返回的消息
 
+	//网络对战相关
+	client       *transport.Client //不为nil时处于网络对战模式，规则校验由服务器完成
+	waiting      bool              //已经提交下棋意图，等待服务器回复
+	opponentAway bool              //对手断线，服务器在宽限期内保留座位
+	clock        *chessclock.State //服务器最近一次下发的棋钟状态
+	clockAt      time.Time         //收到棋钟状态的时间
+
+	//存储和评分相关
+	repo          storage.Repository   //单机对战的玩家资料、对局记录和评分
+	startedAt     time.Time            //本局开始的时间
+	ratings       *rating.Service      //单机对战的评分服务
+	ratingLines   []string             //对局结束后显示的评分和积分榜
+	resultRatings []rating.Rating      //对局结束后双方的评分
+	leadersCh     chan []rating.Rating //后台查询积分榜的结果
+
+	//开局相关
+	moves   []position.Move //红方视角的着法记录，用于判断开局
+	opening string          //当前的ECCO开局
+
+	//做题相关
+	puzzle *puzzleMode //不为nil时处于做题模式，不运行内核
+
+	//排局欣赏相关
+	study *studyMode //不为nil时处于排局欣赏模式，不运行内核
+
+	//对局分析相关
+	analysis *analysisMode //不为nil时处于分析模式，不运行内核
+
+	//走棋提示相关
+	hints    *moveHints        //选中棋子的可走位置和将军状态
+	lastMove *player.Statement //上一步棋，高亮显示起点和终点
+
+	//拖动和动画相关
+	drag   *dragState      //正在拖动的棋子
+	fading []*fadingSprite //被吃掉之后正在淡出的棋子
+
+	captures map[core.ChessmanGroup][]core.ChessmanCode //双方吃掉的棋子，key是吃子的阵营
+
+	//着法列表和复盘相关
+	record         []recordEntry     //本局生效的着法
+	recordStart    position.Position //着法记录的起始局面
+	review         *reviewState      //不为nil时正在复盘，棋盘显示历史局面
+	moveListScroll int               //着法列表从最新的着法向前滚动的行数
+
+	//人机对战和用时相关
+	computer    *ai.Bot           //不为nil时玩家2由电脑操作
+	timeControl time.Duration     //单机对战每方的用时，0表示不限时
+	localClock  *chessclock.Clock //单机对战的棋钟
+	restarting  chan struct{}     //不为nil时正在等待上一局的内核退出，内核关闭之后通道关闭
+	reseat      func()            //上一局的内核退出之后、新的一局开始之前修改座位
 }
 
-func NewGame() *Game {
+// Seat 单机对战时玩家1的座位，玩家2坐在另一边
+type Seat struct {
+	First bool //玩家1执红先手
+	Down  bool //玩家1位于棋盘下方
+}
+
+// DefaultSeat 默认玩家1执红先手，位于棋盘下方
+var DefaultSeat = Seat{First: true, Down: true}
+
+// LocalOptions 单机对战的设置
+type LocalOptions struct {
+	Seat     Seat          //玩家1的座位
+	Computer int           //玩家2由电脑操作时电脑的难度，从1开始，0表示两个人轮流下棋
+	Time     time.Duration //每方的用时，用完判负，0表示不限时
+}
+
+// NewGame 创建单机对战的游戏，玩家1按照座位就座。第一步棋之前可以按 C 交换红黑、按 S 交换上下，随时可以按 F 翻转棋盘。
+// 人机对战时电脑在内核的协程里思考，不阻塞界面
+func NewGame(opts LocalOptions) (*Game, error) {
 
 	var p1, p2 player.PlayerInterface
 
 	p1 = player.NewPlayer()
-	p1.SetIsFirst(true) //默认p1先手
-	p1.SetIsDown(true)  //默认p1在棋盘下方
+	p1.SetID(localPlayer1ID)
+	p1.SetIsFirst(opts.Seat.First)
+	p1.SetIsDown(opts.Seat.Down)
 	p1.SetGroup(core.Group1)
 
+	var computer *ai.Bot
+	if opts.Computer > 0 {
+		bot, err := newComputer(opts.Computer)
+		if err != nil {
+			return nil, err
+		}
+		computer = bot
+		p2 = bot
+	} else {
+		p2 = player.NewPlayer()
+		p2.SetID(localPlayer2ID)
+	}
+	p2.SetIsFirst(!opts.Seat.First)
+	p2.SetIsDown(!opts.Seat.Down)
+	p2.SetGroup(core.Group2)
+
+	//先手执红棋，根据先手创建棋子
+	g := newGame(p1, p2)
+	g.repo = newLocalRepository()
+	if computer != nil {
+		g.repo.EnsurePlayer(computer.GetID(), computerName(opts.Computer))
+	}
+	g.ratings = rating.NewService(rating.DefaultConfig(), g.repo.Ratings())
+	g.computer = computer
+	g.timeControl = opts.Time
+
+	//启动内核
+	if err := g.startCore(); err != nil {
+		g.repo.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// 创建并运行单机对战的内核，电脑绑定到新的棋局，棋钟从红方开始计时。
+// 上一局的内核退出时关闭了双方的意图通道，每一局重新创建
+func (g *Game) startCore() error {
+	g.p1Ch = make(chan player.Statement, 1)
+	g.p2Ch = make(chan player.Statement, 1)
+	g.gameCore = new(chessgame.ChessGame)
+	if err := g.gameCore.InitialGame(g.player1, g.player2); err != nil {
+		return err
+	}
+	if g.computer != nil {
+		g.computer.Attach(g.gameCore)
+	}
+	g.coreCh = g.gameCore.Run(g.p1Ch, g.p2Ch)
+	g.startedAt = time.Now()
+	g.startLocalClock()
+	return nil
+}
+
+// NewClientGame 创建网络对战模式的游戏，连接服务器并加入房间。
+// 本地玩家总是位于棋盘下方，只能移动自己阵营的棋子，规则校验由服务器的内核完成。
+// playerID不为0时服务器记录对局结果并更新评分
+func NewClientGame(addr, room string, playerID int) (*Game, error) {
+	client, err := transport.Dial(addr, room, playerID)
+	if err != nil {
+		return nil, err
+	}
+	return newClientGame(client, playerID), nil
+}
+
+// NewMatchGame 创建自动匹配的网络对战游戏，排队直到服务器匹配到评分接近的对手并安排好房间。
+// 自动匹配需要实名玩家，timeControl为0时使用服务器的对局时间
+func NewMatchGame(addr string, playerID int, timeControl time.Duration) (*Game, error) {
+	client, err := transport.DialQueue(addr, playerID, timeControl)
+	if err != nil {
+		return nil, err
+	}
+	return newClientGame(client, playerID), nil
+}
+
+// 按照服务器分配的座位创建网络对战的游戏界面
+func newClientGame(client *transport.Client, playerID int) *Game {
+	var p1, p2 player.PlayerInterface
+
+	p1 = player.NewPlayer()
+	p1.SetID(playerID)
+	p1.SetGroup(client.GetGroup())
+	p1.SetIsFirst(client.GetIsFirst())
+	p1.SetIsDown(true)
+
 	p2 = player.NewPlayer()
+	if client.GetGroup() == core.Group1 {
+		p2.SetGroup(core.Group2)
+	} else {
+		p2.SetGroup(core.Group1)
+	}
+	p2.SetIsFirst(!client.GetIsFirst())
+
+	g := newGame(p1, p2)
+	g.client = client
+
+	return g
+}
 
+// NewSpectatorGame 创建观战模式的游戏，以先手方在棋盘下方的视角观看房间内的对局，不能下棋
+func NewSpectatorGame(addr, room string) (*Game, error) {
+	client, err := transport.Watch(addr, room)
+	if err != nil {
+		return nil, err
+	}
+
+	var p1, p2 player.PlayerInterface
+
+	p1 = player.NewPlayer()
+	p1.SetGroup(client.GetGroup())
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+
+	p2 = player.NewPlayer()
 	p2.SetGroup(core.Group2)
 
-	p1Ch := make(chan player.Statement, 1)
-	p2Ch := make(chan player.Statement, 1)
+	g := newGame(p1, p2)
+	g.client = client
 
-	//先手执红棋，根据先手创建棋子
+	return g, nil
+}
 
+// 根据玩家摆好棋子，创建游戏界面
+func newGame(p1, p2 player.PlayerInterface) *Game {
 	g := &Game{
 		sprites:             nil,
 		boardLogicZeroPoint: coordinate{x: boardLogicZeroX, y: boardLogicZeroY},
@@ -63,18 +253,12 @@ func NewGame() *Game {
 		spriteReparation:    spriteReparation,
 		player1:             p1,
 		player2:             p2,
-		p1Ch:                p1Ch,
-		p2Ch:                p2Ch,
 		gameCore:            nil,
 		coreCh:              nil,
+		hints:               newMoveHints(),
 	}
 
-	g.initSprites(p1, p2)
-	if p1.GetIsFirst() {
-		g.nextRoundGroup = p1.GetGroup()
-	} else {
-		g.nextRoundGroup = p2.GetGroup()
-	}
+	g.initSprites()
 
 	g.winner = ""
 	g.onceAgainBtn = &onceAgainBtn{
@@ -84,66 +268,94 @@ func NewGame() *Game {
 		y:          g.boardLogicZeroPoint.y + 7*g.gridLength,
 	}
 
-	//启动内核
-	g.gameCore = new(chessgame.ChessGame)
-	err := g.gameCore.InitialGame(p1, p2)
-	if err != nil {
-		log.Fatal(err)
-	}
-	g.coreCh = g.gameCore.Run(p1Ch, p2Ch)
-
 	return g
 }
 
 func (g *Game) Update() error {
+	g.updateAnimations()
+
+	//任何模式下都可以切换主题、语言和调节音量
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		nextTheme()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		nextLang()
+	}
+	updateVolume()
+
+	if g.puzzle != nil {
+		return g.updatePuzzle()
+	}
+	if g.study != nil {
+		return g.updateStudy()
+	}
+	if g.analysis != nil {
+		return g.updateAnalysis()
+	}
+
+	//网络对战模式下，先处理服务器推送的消息
+	if g.client != nil {
+		g.pollNetwork()
+		g.pollLeaderboard()
+	}
+
+	//单机对战时检查棋钟，并接收电脑在后台算好的着法。重新开局时等到上一局的内核退出
+	if g.client == nil {
+		if waiting, err := g.finishRestart(); waiting || err != nil {
+			return err
+		}
+		g.checkLocalClock()
+		g.pollComputer()
+	}
+
+	//翻转棋盘和选择座位
+	if err := g.updateSeat(); err != nil {
+		return err
+	}
+
+	//着法列表和复盘的操作，复盘时不能走棋
+	if g.updatePanel() {
+		return nil
+	}
+
+	//拖动棋子到目标位置松开，和点击目标位置一样交给内核校验
+	if target, ok := g.updateDrag(); ok && len(g.winner) == 0 {
+		if g.client == nil || (!g.waiting && g.client.GetStatus() == transport.Playing) {
+			g.submitStatement(target)
+		}
+		return nil
+	}
 
 	//如果发生鼠标左键点击事件
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		g.gameMsg = nil
+		g.failMsg = ""
 
 		//如果已经产生胜者，只需要判断是否点击了再来一局按钮
 		if len(g.winner) > 0 {
-			if g.onceAgainBtn.In(ebiten.CursorPosition()) {
-				//重置棋局
-
-				//切换先手
-				if g.player1.GetIsFirst() {
-					g.player2.SetIsFirst(true)
-					g.player1.SetIsFirst(false)
-				} else {
-					g.player1.SetIsFirst(true)
-					g.player2.SetIsFirst(false)
-				}
-
-				//重新摆棋
-				g.initSprites(g.player1, g.player2)
-				if g.player1.GetIsFirst() {
-					g.nextRoundGroup = g.player1.GetGroup()
-				} else {
-					g.nextRoundGroup = g.player2.GetGroup()
-				}
-
-				//内核也要重置棋局
-				err := g.gameCore.ResetGame()
-				if err != nil {
-					return err
-				}
-				g.coreCh = g.gameCore.Run(g.p1Ch, g.p2Ch)
-
-				//重置获胜记录和其他信息
-				g.winner = ""
-				g.gameMsg = nil
-				g.clickedSprite = nil
-
+			//网络对战模式下不支持再来一局
+			if g.client == nil && g.onceAgainBtn.In(cursorPosition()) {
+				//重置棋局，切换先手
+				//超时判负时内核还在运行，所以重新创建内核，然后按照内核的棋盘重新摆棋
+				g.restartLocalGame(func() {
+					first := g.player1.GetIsFirst()
+					g.player1.SetIsFirst(!first)
+					g.player2.SetIsFirst(first)
+				})
 			}
 
 			return nil
 		}
 
+		//网络对战模式下，对局没有开始或者正在等待服务器回复时不接受操作
+		if g.client != nil && (g.waiting || g.client.GetStatus() != transport.Playing) {
+			return nil
+		}
+
 		//是否点击了棋子
-		if sp := g.spriteAt(ebiten.CursorPosition()); sp != nil {
-			//如果选中的棋子就是当前应该下棋的阵营
-			if sp.group == g.nextRoundGroup {
+		if sp := g.spriteAt(cursorPosition()); sp != nil {
+			//如果选中的棋子就是当前应该下棋的阵营，并且由本地玩家操作
+			if sp.group == g.nextRoundGroup && g.isLocalGroup(sp.group) {
 				//将棋子设置为点击状态并记录
 				if g.clickedSprite != nil {
 					g.clickedSprite.clicked = false
@@ -151,91 +363,24 @@ func (g *Game) Update() error {
 
 				sp.clicked = true
 				g.clickedSprite = sp
+				g.startDrag(sp)
 			}
 			if sp.group != g.nextRoundGroup {
 				//如果之前没有棋子被选中，就不记录。如果之前已经有棋子被选中说明是要吃棋
 				if g.clickedSprite != nil {
-					g.moveSpriteToFront(sp)
-
 					// 将操作传给核心层校验，然后移动被选中的棋子到目标坐标
-					sourceCoreX, sourceCoreY := g.transformCoordinate(g.clickedSprite.x-g.spriteReparation, g.clickedSprite.y-g.spriteReparation)
 					targetCoreX, targetCoreY := g.transformCoordinate(sp.x-g.spriteReparation, sp.y-g.spriteReparation)
-					if g.player1.GetGroup() == g.nextRoundGroup {
-
-						g.p1Ch <- player.Statement{
-							Group: g.player1.GetGroup(),
-							Code:  g.clickedSprite.code,
-							Source: core.Coordinate{
-								X: sourceCoreX,
-								Y: sourceCoreY,
-							},
-							Target: core.Coordinate{
-								X: targetCoreX,
-								Y: targetCoreY,
-							},
-						}
-					} else {
-						g.p2Ch <- player.Statement{
-							Group: g.player2.GetGroup(),
-							Code:  g.clickedSprite.code,
-							Source: core.Coordinate{
-								X: sourceCoreX,
-								Y: sourceCoreY,
-							},
-							Target: core.Coordinate{
-								X: targetCoreX,
-								Y: targetCoreY,
-							},
-						}
-					}
-
-					//接收回复
-					msg := <-g.coreCh
-					//fmt.Println(msg)
-					g.gameMsg = &msg
-
-					if msg.Event == chessgame.Done || msg.Event == chessgame.Fin {
-						//校验通过，移动游戏界面的棋子
-						g.clickedSprite.MoveTo(sp.x, sp.y)
-						//修改坐标
-						g.clickedSprite.x = sp.x
-						g.clickedSprite.y = sp.y
-
-						//删除原来在这个坐标的棋子
-						g.deleteSprite(sp)
-						//重置棋子选中状态和修改下一回合标记
-						g.clickedSprite.clicked = false
-						g.clickedSprite = nil
-						if g.nextRoundGroup == g.player1.GetGroup() {
-							g.nextRoundGroup = g.player2.GetGroup()
-						} else {
-							g.nextRoundGroup = g.player1.GetGroup()
-						}
-
-						//TODO 如果出现赢家
-						if msg.Event == chessgame.Fin {
-							//获取胜利阵营
-							group := msg.WonGroup
-							//判断玩家哪个属于这个阵营
-							pl := g.getPlayerByGroup(group)
-							if pl.GetIsFirst() {
-								g.winner = "红方"
-							} else {
-								g.winner = "黑方"
-							}
-
-						}
-					}
+					g.submitStatement(core.Coordinate{X: targetCoreX, Y: targetCoreY})
 				}
 			}
 
 		} else {
 			//没有点击到棋子。判断是否点击的是棋盘格
-			if !g.InBoard(ebiten.CursorPosition()) {
+			if !g.InBoard(cursorPosition()) {
 				return nil
 			}
 			//修正坐标
-			x, y := g.revisesCoordinate(ebiten.CursorPosition())
+			x, y := g.revisesCoordinate(cursorPosition())
 			//fmt.Printf("revisesCoordinate to [%d,%d]\n", x, y)
 			coreX, coreY := g.transformCoordinate(x, y)
 			//如果已经有棋子被选中，说明玩家想把棋子移动到选中坐标
@@ -246,1216 +391,378 @@ func (g *Game) Update() error {
 			if g.clickedSprite.group != g.nextRoundGroup {
 				return nil
 			}
-			g.moveSpriteToFront(g.clickedSprite)
 			// 将操作传给核心层校验，然后移动被选中的棋子到目标坐标
-			sourceCoreX, sourceCoreY := g.transformCoordinate(g.clickedSprite.x-g.spriteReparation, g.clickedSprite.y-g.spriteReparation)
-			if g.player1.GetGroup() == g.nextRoundGroup {
-				g.p1Ch <- player.Statement{
-					Group: g.player1.GetGroup(),
-					Code:  g.clickedSprite.code,
-					Source: core.Coordinate{
-						X: sourceCoreX,
-						Y: sourceCoreY,
-					},
-					Target: core.Coordinate{
-						X: coreX,
-						Y: coreY,
-					},
-				}
-			} else {
-				g.p2Ch <- player.Statement{
-					Group: g.player2.GetGroup(),
-					Code:  g.clickedSprite.code,
-					Source: core.Coordinate{
-						X: sourceCoreX,
-						Y: sourceCoreY,
-					},
-					Target: core.Coordinate{
-						X: coreX,
-						Y: coreY,
-					},
-				}
-			}
+			g.submitStatement(core.Coordinate{X: coreX, Y: coreY})
+		}
+	}
+	return nil
+}
 
-			//接收回复
-			msg := <-g.coreCh
-			//fmt.Println(msg)
-			g.gameMsg = &msg
-
-			if msg.Event == chessgame.Done || msg.Event == chessgame.Fin {
-				//校验通过，移动游戏界面的棋子
-				g.clickedSprite.MoveTo(x+g.spriteReparation, y+g.spriteReparation)
-
-				//重置棋子选中状态和修改下一回合标记
-				g.clickedSprite.clicked = false
-				g.clickedSprite = nil
-				if g.nextRoundGroup == g.player1.GetGroup() {
-					g.nextRoundGroup = g.player2.GetGroup()
-				} else {
-					g.nextRoundGroup = g.player1.GetGroup()
-				}
+// 被选中的棋子移动到目标坐标的下棋意图
+func (g *Game) selectedStatement(target core.Coordinate) player.Statement {
+	sourceCoreX, sourceCoreY := g.transformCoordinate(g.clickedSprite.x-g.spriteReparation, g.clickedSprite.y-g.spriteReparation)
+	return player.Statement{
+		Group: g.nextRoundGroup,
+		Code:  g.clickedSprite.code,
+		Source: core.Coordinate{
+			X: sourceCoreX,
+			Y: sourceCoreY,
+		},
+		Target: target,
+	}
+}
 
-				if msg.Event == chessgame.Fin {
-					//获取胜利阵营
-					group := msg.WonGroup
-					//判断玩家哪个属于这个阵营
-					pl := g.getPlayerByGroup(group)
-					if pl.GetIsFirst() {
-						g.winner = "红方"
-					} else {
-						g.winner = "黑方"
-					}
+// 将被选中棋子的下棋意图交给内核校验。网络对战模式下交给服务器，结果异步返回
+func (g *Game) submitStatement(target core.Coordinate) {
+	st := g.selectedStatement(target)
 
-				}
+	if g.client != nil {
+		if err := g.client.SendStatement(st); err == nil {
+			g.waiting = true
+		}
+		return
+	}
 
-			}
+	if g.player1.GetGroup() == g.nextRoundGroup {
+		g.p1Ch <- st
+	} else {
+		g.p2Ch <- st
+	}
 
-		}
+	//接收回复
+	msg := <-g.coreCh
+	g.onResult(msg)
+}
+
+// 处理内核返回的事件，着法生效时同步移动游戏界面的棋子
+func (g *Game) onResult(msg chessgame.GameMsg) {
+	g.gameMsg = &msg
+	g.failMsg = ""
+
+	if msg.Event == chessgame.Err {
+		playSound(soundIllegal)
+	}
+	if msg.Move == nil {
+		return
+	}
+
+	//校验通过，移动游戏界面的棋子，轮到对方计时
+	g.moveSprite(msg.Move.Statement())
+	if code := msg.Captured(); code != "" {
+		g.trackCapture(msg.Move.Group, code)
+	}
+	g.switchLocalClock()
+	if msg.Event == chessgame.Done {
+		g.playMoveSound(msg)
+	}
+
+	//重置棋子选中状态
+	if g.clickedSprite != nil {
+		g.clickedSprite.clicked = false
+		g.clickedSprite = nil
+	}
+
+	//如果出现赢家
+	if msg.Event == chessgame.Fin {
+		g.endGame(msg)
 	}
-	return nil
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	//绘制棋盘
-	screen.DrawImage(ebitenBoardImage, &ebiten.DrawImageOptions{})
+// 对局结束，记录胜利的一方。单机对战时停止棋钟并保存结果
+func (g *Game) endGame(msg chessgame.GameMsg) {
+	g.gameMsg = &msg
+	g.setWinner(msg.Winner())
+	if g.client != nil {
+		return
+	}
+	if g.localClock != nil {
+		g.localClock.Stop()
+	}
+	g.recordLocalResult(msg)
+}
 
-	//绘制棋子
-	for _, s := range g.sprites {
-		if s.clicked {
-			s.Draw(screen, 0.7)
-		} else {
-			s.Draw(screen, 1)
-		}
+// 按照已经生效的下棋意图移动游戏界面的棋子，删除原来在目标坐标的棋子，并修改下一回合标记。
+// 棋子从当前的绘制位置滑到目标坐标，被吃掉的棋子淡出
+func (g *Game) moveSprite(st player.Statement) {
+	sp := g.spriteAtCoordinate(st.Source)
+	if sp == nil {
+		return
 	}
+	before := g.spritePosition()
+	if won := g.spriteAtCoordinate(st.Target); won != nil {
+		g.captureSprite(won)
+	}
+	x, y := g.untransformCoordinate(st.Target.X, st.Target.Y)
+	sp.AnimateTo(x+g.spriteReparation, y+g.spriteReparation, moveFrames)
+	g.moveSpriteToFront(sp)
+	g.classifyOpening(st)
+	g.recordMove(st, before)
+	g.lastMove = &st
 
-	g.ShowGameMsg(screen)
+	if g.nextRoundGroup == g.player1.GetGroup() {
+		g.nextRoundGroup = g.player2.GetGroup()
+	} else {
+		g.nextRoundGroup = g.player1.GetGroup()
+	}
+}
 
-	if len(g.winner) > 0 {
-		g.drawWinner(screen)
+// 没有内核消息时按照下棋记录移动棋子，吃子按照目标坐标上的棋子记录。用于断线重连和残局练习
+func (g *Game) replaySprite(st player.Statement) {
+	if sp := g.spriteAtCoordinate(st.Source); sp != nil {
+		if won := g.spriteAtCoordinate(st.Target); won != nil {
+			g.trackCapture(sp.group, won.code)
+		}
 	}
+	g.moveSprite(st)
+}
 
+// 记录胜利的一方
+func (g *Game) setWinner(group core.ChessmanGroup) {
+	//判断玩家哪个属于这个阵营
+	pl := g.getPlayerByGroup(group)
+	if pl == nil {
+		return
+	}
+	if pl.GetIsFirst() {
+		g.winner = "红方"
+	} else {
+		g.winner = "黑方"
+	}
+	g.playResultSound(group)
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return ScreenWidth, ScreenHeight
+// 断线重连后，按照服务器下发的下棋记录重新摆棋
+func (g *Game) resync(history []player.Statement) {
+	g.initSprites()
+	for _, st := range history {
+		g.replaySprite(st)
+	}
+	g.finishAnimations()
+
+	g.clickedSprite = nil
+	g.waiting = false
+	g.winner = ""
 }
 
-func (g *Game) Close() {
-	close(g.p1Ch)
-	close(g.p2Ch)
-	g.gameCore.Close()
+// 处理服务器推送的数据包，不阻塞界面刷新
+func (g *Game) pollNetwork() {
+	for {
+		select {
+		case p, ok := <-g.client.Packets():
+			if !ok {
+				g.waiting = false
+				return
+			}
+			g.handlePacket(p)
+		default:
+			return
+		}
+	}
 }
 
-// 初始化棋盘上各个棋子的精灵：先手执红棋，并且根据玩家意愿确定坐在那一方
-func (g *Game) initSprites(p1, p2 player.PlayerInterface) {
-	g.sprites = make([]*Sprite, 32)
+func (g *Game) handlePacket(p transport.Packet) {
+	if p.Clock != nil {
+		g.clock = p.Clock
+		g.clockAt = time.Now()
+	}
 
-	if p1.GetIsFirst() {
-		//确定p1执红棋
-		if p1.GetIsDown() {
-			//红棋在棋盘下边儿
-			g.initSpritesP1IsFirstAndDown(p1, p2)
-		} else {
-			//红棋在棋盘上边儿
-			g.initSpritesP1IsFirstAndUp(p1, p2)
+	switch p.Type {
+	case transport.Result:
+		if p.GameMsg == nil {
+			return
 		}
+		if p.GameMsg.Event == chessgame.Fin && !g.client.IsSpectator() {
+			g.queryLeaderboard(g.client.Addr(), p.Ratings)
+		}
+		if p.Statement != nil && p.Statement.Group == g.player1.GetGroup() {
+			g.waiting = false
+		}
+		if p.GameMsg.Event == chessgame.Fin && p.GameMsg.Move == nil {
+			//超时或者弃局，没有对应的着法
+			g.waiting = false
+			g.endGame(*p.GameMsg)
+			return
+		}
+		//对手的着法和自己的着法一样，按照服务器的事件移动棋子
+		g.onResult(*p.GameMsg)
+	case transport.Sync:
+		g.resync(p.History)
+	case transport.Vacant:
+		g.opponentAway = p.Group != g.player1.GetGroup()
+	case transport.Resume:
+		g.opponentAway = false
+	case transport.Fail:
+		g.waiting = false
+		g.gameMsg = nil
+		g.failMsg = p.Msg
+		playSound(soundIllegal)
+	}
+}
 
-		return
+// 判断阵营的棋子是否由本地玩家操作
+func (g *Game) isLocalGroup(group core.ChessmanGroup) bool {
+	if g.client == nil {
+		return g.computer == nil || g.computer.GetGroup() != group
+	}
+	if g.client.IsSpectator() {
+		return false
 	}
+	return g.player1.GetGroup() == group
+}
 
-	//p2先手执红棋
-	if p2.GetIsDown() {
-		//p2在棋盘下边儿
-		g.initSpritesP2IsFirstAndDown(p1, p2)
+func (g *Game) Draw(screen *ebiten.Image) {
+	//绘制棋盘
+	drawBoard(screen)
+	if g.review != nil {
+		g.drawReview(screen)
 	} else {
-		//p2在棋盘上边儿
-		g.initSpritesP2IsFirstAndUp(p1, p2)
+		g.drawSprites(screen)
+	}
 
+	if g.puzzle != nil {
+		g.drawPuzzle(screen)
+		return
+	}
+	if g.study != nil {
+		g.drawStudy(screen)
+		return
+	}
+	if g.analysis != nil {
+		g.drawAnalysis(screen)
+		return
 	}
 
-	return
-}
+	g.drawPanel(screen)
+	g.ShowGameMsg(screen)
+	g.drawOpening(screen)
 
-func (g *Game) initSpritesP1IsFirstAndDown(p1, p2 player.PlayerInterface) {
-
-	//p1先手执红棋
-	g.sprites[0] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[1] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[2] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[3] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[4] = &Sprite{
-		image:      ebitenBlackJiangImage,
-		alphaImage: ebitenBlackJiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[5] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[6] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[7] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[8] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[9] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[10] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[11] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[12] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[13] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[14] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[15] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[16] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[17] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[18] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[19] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[20] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[21] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[22] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[23] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[24] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 1*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[25] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[26] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[27] = &Sprite{
-		image:      ebitenRedShuaiImage,
-		alphaImage: ebitenRedShuaiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[28] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-
-	g.sprites[29] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[30] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[31] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
+	if g.client != nil {
+		g.drawConnStatus(screen)
 	}
 
+	//复盘时不遮挡历史局面
+	if len(g.winner) > 0 && g.review == nil {
+		g.drawWinner(screen)
+		g.drawRatings(screen)
+	}
+
+}
+
+// 绘制对局中的棋子和走棋提示，拖动中的棋子最后绘制
+func (g *Game) drawSprites(screen *ebiten.Image) {
+	g.drawLastMove(screen, g.lastMove)
+	g.drawFading(screen)
+
+	for _, s := range g.sprites {
+		switch {
+		case s.dragging:
+			continue
+		case s.clicked:
+			s.Draw(screen, 0.7)
+		default:
+			s.Draw(screen, 1)
+		}
+	}
+	g.drawHints(screen)
+	if g.drag != nil && g.drag.sprite.dragging {
+		g.drag.sprite.Draw(screen, 0.7)
+	}
 }
 
-func (g *Game) initSpritesP1IsFirstAndUp(p1, p2 player.PlayerInterface) {
-
-	g.sprites[0] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[1] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[2] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[3] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[4] = &Sprite{
-		image:      ebitenRedShuaiImage,
-		alphaImage: ebitenRedShuaiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[5] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[6] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[7] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[8] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[9] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[10] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[11] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[12] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[13] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[14] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[15] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[16] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[17] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[18] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[19] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[20] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[21] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[22] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[23] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[24] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 1*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[25] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[26] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[27] = &Sprite{
-		image:      ebitenBlackJiangImage,
-		alphaImage: ebitenBlackJiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[28] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-
-	g.sprites[29] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[30] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[31] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
+// Layout 按照窗口大小缩放界面。做题、排局欣赏和分析模式不显示着法列表面板，只有棋盘的宽度
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	width := ScreenWidth
+	if g.puzzle != nil || g.study != nil || g.analysis != nil {
+		width = BoardWidth
 	}
+	return scaledLayout(width, ScreenHeight, outsideWidth, outsideHeight)
+}
 
+func (g *Game) Close() {
+	if g.puzzle != nil || g.study != nil {
+		return
+	}
+	if g.analysis != nil {
+		g.analysis.close()
+		return
+	}
+	if g.client != nil {
+		g.client.Close()
+		return
+	}
+	//重新开局时上一局的内核已经在后台退出，新的内核还没有创建
+	if g.restarting == nil {
+		g.stopCore()
+	}
+	if g.repo != nil {
+		g.repo.Close()
+	}
 }
 
-func (g *Game) initSpritesP2IsFirstAndUp(p1, p2 player.PlayerInterface) {
-
-	//p2先手执红棋
-	g.sprites[0] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[1] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[2] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[3] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[4] = &Sprite{
-		image:      ebitenRedShuaiImage,
-		alphaImage: ebitenRedShuaiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[5] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[6] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[7] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[8] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[9] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[10] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[11] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[12] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[13] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[14] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[15] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[16] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[17] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[18] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[19] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[20] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[21] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[22] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[23] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[24] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 1*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[25] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[26] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[27] = &Sprite{
-		image:      ebitenBlackJiangImage,
-		alphaImage: ebitenBlackJiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[28] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-
-	g.sprites[29] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[30] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[31] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
+// 初始化棋盘上各个棋子的精灵：先手执红棋，棋子的位置由玩家的座位决定。
+// 单机对战按照内核棋盘上的局面摆棋，网络对战本地没有内核，从初始局面开始
+func (g *Game) initSprites() {
+	pos := position.Initial()
+	if g.gameCore != nil {
+		pos = g.gameCore.GetPosition()
 	}
+	g.placeSprites(pos)
+
+	//重新摆棋时重新判断开局
+	g.moves = nil
+	g.opening = ""
+}
 
+// 按照红方视角的局面摆放棋子精灵，用于从任意局面开始的棋局
+func (g *Game) placeSprites(pos position.Position) {
+	red, black := g.player1, g.player2
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	g.sprites = g.buildSprites(pos)
+	g.lastMove = nil
+	g.fading = nil
+	g.drag = nil
+	g.resetRecord(pos)
+	g.clearCaptures()
+	if pos.RedToMove {
+		g.nextRoundGroup = red.GetGroup()
+	} else {
+		g.nextRoundGroup = black.GetGroup()
+	}
 }
 
-func (g *Game) initSpritesP2IsFirstAndDown(p1, p2 player.PlayerInterface) {
-
-	g.sprites[0] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[1] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[2] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[3] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[4] = &Sprite{
-		image:      ebitenBlackJiangImage,
-		alphaImage: ebitenBlackJiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[5] = &Sprite{
-		image:      ebitenBlackShiImage,
-		alphaImage: ebitenBlackShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[6] = &Sprite{
-		image:      ebitenBlackXiangImage,
-		alphaImage: ebitenBlackXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[7] = &Sprite{
-		image:      ebitenBlackMaImage,
-		alphaImage: ebitenBlackMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[8] = &Sprite{
-		image:      ebitenBlackJuImage,
-		alphaImage: ebitenBlackJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[9] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[10] = &Sprite{
-		image:      ebitenBlackPaoImage,
-		alphaImage: ebitenBlackPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 2*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.Pao,
-	}
-	g.sprites[11] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[12] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[13] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[14] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-	g.sprites[15] = &Sprite{
-		image:      ebitenBlackZuImage,
-		alphaImage: ebitenBlackZuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 3*g.gridLength + g.spriteReparation,
-		group:      p1.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[16] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[17] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[18] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[19] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[20] = &Sprite{
-		image:      ebitenRedBingImage,
-		alphaImage: ebitenRedBingAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 6*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.BingZu,
-	}
-
-	g.sprites[21] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[22] = &Sprite{
-		image:      ebitenRedPaoImage,
-		alphaImage: ebitenRedPaoAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 7*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Pao,
-	}
-
-	g.sprites[23] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
-	}
-	g.sprites[24] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 1*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[25] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 2*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[26] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 3*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-	g.sprites[27] = &Sprite{
-		image:      ebitenRedShuaiImage,
-		alphaImage: ebitenRedShuaiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 4*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.JiangShuai,
-	}
-	g.sprites[28] = &Sprite{
-		image:      ebitenRedShiImage,
-		alphaImage: ebitenRedShiAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 5*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Shi,
-	}
-
-	g.sprites[29] = &Sprite{
-		image:      ebitenRedXiangImage,
-		alphaImage: ebitenRedXiangAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 6*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Xiang,
-	}
-	g.sprites[30] = &Sprite{
-		image:      ebitenRedMaImage,
-		alphaImage: ebitenRedMaAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 7*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ma,
-	}
-	g.sprites[31] = &Sprite{
-		image:      ebitenRedJuImage,
-		alphaImage: ebitenRedJuAlphaImage,
-		x:          g.boardLogicZeroPoint.x + 8*g.gridLength + g.spriteReparation,
-		y:          g.boardLogicZeroPoint.y + 9*g.gridLength + g.spriteReparation,
-		group:      p2.GetGroup(),
-		code:       core.Ju,
+// 按照红方视角的局面生成棋子精灵
+func (g *Game) buildSprites(pos position.Position) []*Sprite {
+	red, black := g.player1, g.player2
+	if !red.GetIsFirst() {
+		red, black = black, red
 	}
+	redIsDown := red.GetIsDown()
 
+	sprites := make([]*Sprite, 0, 32)
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := pos.Squares[rank][file]
+			if len(piece.Code) == 0 {
+				continue
+			}
+			group := black.GetGroup()
+			if piece.Red {
+				group = red.GetGroup()
+			}
+			co := position.ToCoordinate(position.Square{File: file, Rank: rank}, redIsDown)
+			x, y := g.untransformCoordinate(co.X, co.Y)
+			sprites = append(sprites, &Sprite{
+				x:     x + g.spriteReparation,
+				y:     y + g.spriteReparation,
+				group: group,
+				code:  piece.Code,
+				red:   piece.Red,
+			})
+		}
+	}
+	return sprites
 }
 
 // 判断坐标是否在sprite范围内
@@ -1534,27 +841,44 @@ func (g *Game) transformCoordinate(x, y int) (coreX, coreY int) {
 
 	coreX = (dx - x) / gridLength
 	coreY = (dy - y) / gridLength
+	if g.flipped {
+		coreX, coreY = 8-coreX, 9-coreY
+	}
 
 	return coreX, coreY
 }
 
-func (g *Game) ShowGameMsg(screen *ebiten.Image) {
-	if g.gameMsg == nil {
-		return
-	}
-	str := ""
-	str += fmt.Sprintf("结果：%s. ", g.gameMsg.Event)
+// 将核心层棋盘逻辑坐标转换成游戏界面坐标
+func (g *Game) untransformCoordinate(coreX, coreY int) (x, y int) {
+
+	//计算棋盘终点
+	dx := boardLogicZeroX + 8*gridLength
+	dy := boardLogicZeroY + 9*gridLength
 
-	if len(g.gameMsg.WonChessmanCode) > 0 {
-		str += fmt.Sprintf("吃棋：%s. ", g.gameMsg.WonChessmanCode)
+	if g.flipped {
+		coreX, coreY = 8-coreX, 9-coreY
 	}
+	x = dx - coreX*gridLength
+	y = dy - coreY*gridLength
+
+	return x, y
+}
 
-	if len(g.gameMsg.Msg) > 0 {
-		str += fmt.Sprintf("消息：%s. ", g.gameMsg.Msg)
+// 查找位于核心层棋盘逻辑坐标上的sprite
+func (g *Game) spriteAtCoordinate(co core.Coordinate) *Sprite {
+	x, y := g.untransformCoordinate(co.X, co.Y)
+	for _, s := range g.sprites {
+		if s.x == x+g.spriteReparation && s.y == y+g.spriteReparation {
+			return s
+		}
 	}
+	return nil
+}
 
-	if g.gameMsg.WonGroup > 0 {
-		str += fmt.Sprintf("对局结束，胜：%d. ", g.gameMsg.WonGroup)
+func (g *Game) ShowGameMsg(screen *ebiten.Image) {
+	str := g.gameMsgText()
+	if len(str) == 0 {
+		return
 	}
 
 	f := &text.GoTextFace{
@@ -1565,10 +889,51 @@ func (g *Game) ShowGameMsg(screen *ebiten.Image) {
 	}
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), float64(g.boardLogicZeroPoint.y+9*gridLength+30))
-	text.Draw(screen, str, f, op)
+	drawText(screen, str, f, op)
 	//ebitenutil.DebugPrintAt(screen, str, g.boardLogicZeroPoint.x, g.boardLogicZeroPoint.y+9*gridLength+30)
 }
 
+// 消息区显示的内容：事件类型、着法吃掉的棋子、将军、拒绝或者结束的原因和胜负
+func (g *Game) gameMsgText() string {
+	if len(g.failMsg) > 0 {
+		return i18n.T("消息：%s. ", i18n.Reason(g.failMsg))
+	}
+	msg := g.gameMsg
+	if msg == nil {
+		return ""
+	}
+	str := i18n.T("结果：%s. ", i18n.Reason(string(msg.Event)))
+
+	if captured := msg.Captured(); len(captured) > 0 {
+		//被吃掉的是走棋一方对手的棋子
+		red := false
+		if p := g.getPlayerByGroup(msg.Move.Group); p != nil {
+			red = !p.GetIsFirst()
+		}
+		str += i18n.T("吃棋：%s. ", i18n.PieceName(captured, red))
+	}
+	if msg.Check != nil {
+		str += i18n.T("将军. ")
+	}
+	if msg.Rejected != nil {
+		str += i18n.T("消息：%s. ", i18n.Reason(string(msg.Rejected.Reason)))
+	}
+
+	if over := msg.GameOver; over != nil {
+		str += i18n.T("消息：%s. ", i18n.Reason(string(over.Termination)))
+		if p := g.getPlayerByGroup(over.Winner); p != nil {
+			side := "黑方"
+			if p.GetIsFirst() {
+				side = "红方"
+			}
+			str += i18n.T("对局结束，胜：%s. ", i18n.T(side))
+		} else {
+			str += i18n.T("对局结束，和棋. ")
+		}
+	}
+	return str
+}
+
 // 根据阵营获取玩家信息
 func (g *Game) getPlayerByGroup(group core.ChessmanGroup) player.PlayerInterface {
 	if g.player1.GetGroup() == group {
@@ -1586,7 +951,7 @@ func (g *Game) drawWinner(screen *ebiten.Image) {
 	winLogoX := g.boardLogicZeroPoint.x + 2*g.gridLength
 	winLogoY := g.boardLogicZeroPoint.y + 3*g.gridLength
 	op.GeoM.Translate(float64(winLogoX), float64(winLogoY))
-	screen.DrawImage(ebitenWinImage, op)
+	drawImage(screen, ebitenWinImage, op)
 
 	//ebitenutil.DebugPrintAt(screen, g.winner, winLogoX+2*g.gridLength, winLogoY+3*g.gridLength)
 
@@ -1598,9 +963,12 @@ func (g *Game) drawWinner(screen *ebiten.Image) {
 	}
 	op2 := &text.DrawOptions{}
 	op2.GeoM.Translate(float64(winLogoX+3*g.gridLength/2), float64(winLogoY+3*g.gridLength))
-	text.Draw(screen, g.winner, f, op2)
+	drawText(screen, i18n.T(g.winner), f, op2)
 
-	//绘制再来一次按钮
+	//绘制再来一次按钮，网络对战模式下不支持再来一局
+	if g.client != nil {
+		return
+	}
 	onceAgainBtn := &onceAgainBtn{
 		image:      ebitenOnceAgainBtnImage,
 		alphaImage: ebitenOnceAgainBtnAlphaImage,
@@ -1610,3 +978,56 @@ func (g *Game) drawWinner(screen *ebiten.Image) {
 	onceAgainBtn.Draw(screen, 1)
 
 }
+
+var connStatusText = map[transport.ConnStatus]string{
+	transport.Connecting:   "正在连接服务器",
+	transport.Waiting:      "等待对手加入",
+	transport.Playing:      "对局中",
+	transport.Reconnecting: "连接断开，正在重连",
+	transport.Disconnected: "连接已断开",
+}
+
+// 绘制网络对战的连接状态和棋钟
+func (g *Game) drawConnStatus(screen *ebiten.Image) {
+	status := g.client.GetStatus()
+	str := i18n.T(connStatusText[status])
+	if g.client.IsSpectator() {
+		if status == transport.Playing {
+			str = i18n.T("观战中")
+		}
+	} else if status == transport.Playing && len(g.winner) == 0 {
+		if g.opponentAway {
+			str += i18n.T("，对手断线，等待重连")
+		} else if g.nextRoundGroup == g.player1.GetGroup() {
+			str += i18n.T("，轮到你下棋")
+		} else {
+			str += i18n.T("，等待对手下棋")
+		}
+	}
+
+	if g.clock != nil {
+		red, black := g.player1, g.player2
+		if !red.GetIsFirst() {
+			red, black = black, red
+		}
+		str += i18n.T("  红方 %s 黑方 %s", g.clockText(red.GetGroup()), g.clockText(black.GetGroup()))
+	}
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), 4)
+	drawText(screen, str, f, op)
+}
+
+// 阵营的剩余时间，正在计时的一方需要扣除收到棋钟状态之后经过的时间
+func (g *Game) clockText(group core.ChessmanGroup) string {
+	remaining := g.clock.Remaining[group]
+	if g.clock.Running == group {
+		remaining -= time.Since(g.clockAt)
+	}
+	return formatRemaining(remaining)
+}
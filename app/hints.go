@@ -0,0 +1,159 @@
+package app
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 走棋提示的颜色
+var (
+	lastMoveColor = color.RGBA{R: 0x30, G: 0x90, B: 0xe0, A: 0x60}
+	targetColor   = color.RGBA{R: 0x20, G: 0xa0, B: 0x40, A: 0xc0}
+	checkColor    = color.RGBA{R: 0xe0, G: 0x10, B: 0x10, A: 0xff}
+)
+
+// 走棋提示：选中棋子可以到达的位置和将军状态，按照界面上的局面由内核的裁判计算。
+// 网络对战时本地没有运行内核，同样可以计算
+type moveHints struct {
+	referee *chessgame.Referee
+	pos     position.Position //计算提示时的局面，局面变化之后重新计算
+	valid   bool              //局面是否合法，例如将帅被吃掉之后不再计算提示
+	inCheck bool              //走棋方是否被将军
+
+	from    position.Square   //计算可走位置的棋子
+	targets []core.Coordinate //选中棋子可以到达的坐标
+}
+
+func newMoveHints() *moveHints {
+	referee, err := chessgame.NewReferee()
+	if err != nil {
+		log.Printf("create referee: %v", err)
+		return nil
+	}
+	return &moveHints{referee: referee}
+}
+
+// 红方（先手方）是否位于棋盘下方
+func (g *Game) redIsDown() bool {
+	if g.player1.GetIsFirst() {
+		return g.player1.GetIsDown()
+	}
+	return g.player2.GetIsDown()
+}
+
+// 界面上的棋子组成的红方视角局面
+func (g *Game) spritePosition() position.Position {
+	red := g.player1
+	if !red.GetIsFirst() {
+		red = g.player2
+	}
+	redIsDown := g.redIsDown()
+
+	var pos position.Position
+	for _, sp := range g.sprites {
+		x, y := g.transformCoordinate(sp.x-g.spriteReparation, sp.y-g.spriteReparation)
+		sq := position.ToSquare(core.Coordinate{X: x, Y: y}, redIsDown)
+		if !sq.Valid() {
+			continue
+		}
+		pos.Squares[sq.Rank][sq.File] = position.Piece{Code: sp.code, Red: sp.group == red.GetGroup()}
+	}
+	pos.RedToMove = g.nextRoundGroup == red.GetGroup()
+	return pos
+}
+
+// 按照当前局面更新将军状态和选中棋子的可走位置
+func (g *Game) updateHints() {
+	h := g.hints
+	if h == nil {
+		return
+	}
+	redIsDown := g.redIsDown()
+	pos := g.spritePosition()
+	if pos != h.pos {
+		h.pos = pos
+		h.targets = nil
+		check, err := h.referee.InCheck(pos)
+		h.valid = err == nil
+		h.inCheck = check
+	}
+
+	sp := g.clickedSprite
+	if !h.valid || sp == nil || sp.group != g.nextRoundGroup {
+		h.targets = nil
+		return
+	}
+	x, y := g.transformCoordinate(sp.x-g.spriteReparation, sp.y-g.spriteReparation)
+	from := position.ToSquare(core.Coordinate{X: x, Y: y}, redIsDown)
+	if h.targets != nil && from == h.from {
+		return
+	}
+	squares, err := h.referee.LegalTargets(pos, from)
+	if err != nil {
+		h.targets = nil
+		return
+	}
+	h.from = from
+	h.targets = make([]core.Coordinate, len(squares))
+	for i, sq := range squares {
+		h.targets[i] = position.ToCoordinate(sq, redIsDown)
+	}
+}
+
+// 在棋子下面绘制上一步棋的起点和终点
+func (g *Game) drawLastMove(screen *ebiten.Image, st *player.Statement) {
+	if st == nil {
+		return
+	}
+	size := float32(gridLength) * 0.85
+	for _, co := range []core.Coordinate{st.Source, st.Target} {
+		x, y := g.untransformCoordinate(co.X, co.Y)
+		fillRect(screen, float32(x)-size/2, float32(y)-size/2, size, size, lastMoveColor, false)
+	}
+}
+
+// 在棋子上面绘制选中棋子可以到达的位置，吃子的位置画圆圈，并提示将军
+func (g *Game) drawHints(screen *ebiten.Image) {
+	g.updateHints()
+	h := g.hints
+	if h == nil || !h.valid {
+		return
+	}
+	for _, co := range h.targets {
+		x, y := g.untransformCoordinate(co.X, co.Y)
+		if g.spriteAtCoordinate(co) != nil {
+			strokeCircle(screen, float32(x), float32(y), float32(-g.spriteReparation), 3, targetColor, true)
+		} else {
+			fillCircle(screen, float32(x), float32(y), 7, targetColor, true)
+		}
+	}
+
+	if !h.inCheck || len(g.winner) > 0 {
+		return
+	}
+	//被将军的将帅画红色圆圈，旁边显示将军
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      24,
+	}
+	for _, sp := range g.sprites {
+		if sp.code != core.JiangShuai || sp.group != g.nextRoundGroup {
+			continue
+		}
+		r := float32(-g.spriteReparation)
+		strokeCircle(screen, float32(sp.x)+r, float32(sp.y)+r, r+2, 3, checkColor, true)
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(sp.x-2*g.spriteReparation+6), float64(sp.y-g.spriteReparation-12))
+		op.ColorScale.ScaleWithColor(checkColor)
+		drawText(screen, i18n.T("将军"), f, op)
+	}
+}
@@ -0,0 +1,355 @@
+package app
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 启动菜单中的对局模式
+const (
+	modeHotSeat  = iota //两个人轮流下棋
+	modeComputer        //人机对战
+	modeNetwork         //网络对战
+)
+
+// 启动菜单可以选择的用时
+var timeControls = []time.Duration{0, 5 * time.Minute, 10 * time.Minute, 20 * time.Minute}
+
+// 启动菜单的布局
+const (
+	menuLabelX      = 120 //每行标题的位置
+	menuOptionX     = 240 //第一个选项的位置
+	menuTop         = 110 //第一行的位置
+	menuRowHeight   = 50  //每行的高度
+	menuButtonW     = 116 //选项按钮的宽度
+	menuButtonH     = 40  //选项按钮的高度
+	menuButtonGap   = 12  //选项按钮之间的距离
+	menuInputWidth  = 360 //输入框的宽度
+	menuStartWidth  = 160 //开始按钮的宽度
+	menuStartHeight = 48  //开始按钮的高度
+)
+
+var (
+	menuButtonColor   = color.RGBA{R: 0x50, G: 0x48, B: 0x40, A: 0xff}
+	menuSelectedColor = color.RGBA{R: 0xb0, G: 0x30, B: 0x20, A: 0xff}
+	menuDisabledColor = color.RGBA{R: 0x38, G: 0x34, B: 0x30, A: 0xff}
+	menuFocusColor    = color.RGBA{R: 0xf0, G: 0xc0, B: 0x30, A: 0xff}
+)
+
+// 菜单中的一组选项，只能选择其中一个
+type menuChoice struct {
+	label   string
+	options []string
+	index   int
+}
+
+// 菜单中可以输入文字的一项
+type menuInput struct {
+	label string
+	value string
+}
+
+// 菜单中可以点击的按钮
+type menuButton struct {
+	x, y, w, h int
+	text       string
+	selected   bool //选项被选中或者输入框正在输入
+	enabled    bool
+	click      func()
+}
+
+func (b menuButton) In(x, y int) bool {
+	return x >= b.x && x < b.x+b.w && y >= b.y && y < b.y+b.h
+}
+
+// 连接服务器的结果
+type launchResult struct {
+	game *Game
+	err  error
+}
+
+// Launcher 启动菜单：选择对局模式、电脑的难度、执子和用时，网络对战时填写服务器和房间。
+// 点击开始之后创建对应的游戏，之后的输入和绘制都交给游戏
+type Launcher struct {
+	mode       menuChoice
+	difficulty menuChoice
+	color      menuChoice
+	time       menuChoice
+	sound      menuChoice
+	lang       menuChoice
+	server     menuInput
+	room       menuInput
+	focus      *menuInput //正在输入的输入框
+	playerID   int        //网络对战的玩家id
+
+	game       *Game
+	connecting chan launchResult //不为nil时正在后台连接服务器
+	message    string            //创建游戏失败的原因
+}
+
+// NewLauncher 创建启动菜单，server、room和playerID是网络对战的默认设置
+func NewLauncher(server, room string, playerID int) *Launcher {
+	return &Launcher{
+		mode:       menuChoice{label: "模式", options: []string{"双人对战", "人机对战", "网络对战"}},
+		difficulty: menuChoice{label: "难度", options: Difficulties, index: 1},
+		color:      menuChoice{label: "执子", options: []string{"执红先手", "执黑后手"}},
+		time:       menuChoice{label: "用时", options: []string{"不限时", "5分钟", "10分钟", "20分钟"}},
+		sound:      menuChoice{label: "音效", options: soundLevelNames, index: soundLevel()},
+		lang:       menuChoice{label: "语言", options: langNames(), index: langIndex()},
+		server:     menuInput{label: "服务器", value: server},
+		room:       menuInput{label: "房间", value: room},
+		playerID:   playerID,
+	}
+}
+
+// 菜单中所有的按钮，不可用的选项变灰
+func (l *Launcher) buttons() []menuButton {
+	network := l.mode.index == modeNetwork
+	rows := []struct {
+		choice  *menuChoice
+		enabled bool
+	}{
+		{&l.mode, true},
+		{&l.difficulty, l.mode.index == modeComputer},
+		{&l.color, !network},
+		{&l.time, !network},
+		{&l.sound, true},
+		{&l.lang, true},
+	}
+
+	buttons := make([]menuButton, 0)
+	y := menuTop
+	for _, row := range rows {
+		c := row.choice
+		for i, option := range c.options {
+			buttons = append(buttons, menuButton{
+				x:        menuOptionX + i*(menuButtonW+menuButtonGap),
+				y:        y,
+				w:        menuButtonW,
+				h:        menuButtonH,
+				text:     i18n.T(option),
+				selected: i == c.index,
+				enabled:  row.enabled,
+				click:    func() { c.index = i },
+			})
+		}
+		y += menuRowHeight
+	}
+	//主题可能很多，点击切换到下一个
+	buttons = append(buttons, menuButton{
+		x:       menuOptionX,
+		y:       y,
+		w:       menuInputWidth,
+		h:       menuButtonH,
+		text:    themeLabel(),
+		enabled: true,
+		click:   nextTheme,
+	})
+	y += menuRowHeight
+	for _, in := range []*menuInput{&l.server, &l.room} {
+		buttons = append(buttons, menuButton{
+			x:        menuOptionX,
+			y:        y,
+			w:        menuInputWidth,
+			h:        menuButtonH,
+			text:     in.value,
+			selected: l.focus == in,
+			enabled:  network,
+			click:    func() { l.focus = in },
+		})
+		y += menuRowHeight
+	}
+	buttons = append(buttons, menuButton{
+		x:       (ScreenWidth - menuStartWidth) / 2,
+		y:       y + 16,
+		w:       menuStartWidth,
+		h:       menuStartHeight,
+		text:    i18n.T("开始"),
+		enabled: l.connecting == nil,
+		click:   l.start,
+	})
+	return buttons
+}
+
+// 按照菜单的选择创建游戏。网络对战在后台连接服务器，不阻塞界面
+func (l *Launcher) start() {
+	l.message = ""
+	setSoundLevel(l.sound.index)
+	if l.mode.index == modeNetwork {
+		server, room, playerID := l.server.value, l.room.value, l.playerID
+		l.connecting = make(chan launchResult, 1)
+		go func(ch chan launchResult) {
+			g, err := NewClientGame(server, room, playerID)
+			ch <- launchResult{game: g, err: err}
+		}(l.connecting)
+		l.message = i18n.T("正在连接服务器…")
+		return
+	}
+
+	opts := LocalOptions{
+		Seat: Seat{First: l.color.index == 0, Down: true},
+		Time: timeControls[l.time.index],
+	}
+	if l.mode.index == modeComputer {
+		opts.Computer = l.difficulty.index + 1
+	}
+	g, err := NewGame(opts)
+	if err != nil {
+		l.message = err.Error()
+		return
+	}
+	l.game = g
+}
+
+func (l *Launcher) Update() error {
+	if l.game != nil {
+		return l.game.Update()
+	}
+	if l.connecting != nil {
+		select {
+		case r := <-l.connecting:
+			l.connecting = nil
+			if r.err != nil {
+				l.message = i18n.T("连接失败：%s", r.err)
+				return nil
+			}
+			l.game = r.game
+		default:
+		}
+		return nil
+	}
+
+	//输入服务器地址和房间名称
+	if l.focus != nil {
+		l.focus.value = string(ebiten.AppendInputChars([]rune(l.focus.value)))
+		if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(l.focus.value) > 0 {
+			runes := []rune(l.focus.value)
+			l.focus.value = string(runes[:len(runes)-1])
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		l.start()
+		return nil
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := cursorPosition()
+		l.focus = nil
+		for _, b := range l.buttons() {
+			if b.enabled && b.In(x, y) {
+				b.click()
+				break
+			}
+		}
+		//选择语言之后菜单立即换成新的语言
+		i18n.SetLang(i18n.Langs[l.lang.index])
+	}
+	return nil
+}
+
+func (l *Launcher) Draw(screen *ebiten.Image) {
+	if l.game != nil {
+		l.game.Draw(screen)
+		return
+	}
+	screen.Fill(panelColor)
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      40,
+	}
+	drawCentered(screen, i18n.T("中国象棋"), f, ScreenWidth/2, 60)
+
+	f.Size = 20
+	labels := []string{l.mode.label, l.difficulty.label, l.color.label, l.time.label, l.sound.label, l.lang.label, "主题", l.server.label, l.room.label}
+	for i, label := range labels {
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(menuLabelX, float64(menuTop+i*menuRowHeight+8))
+		drawText(screen, i18n.T(label), f, op)
+	}
+
+	for _, b := range l.buttons() {
+		c := menuButtonColor
+		switch {
+		case !b.enabled:
+			c = menuDisabledColor
+		case b.selected:
+			c = menuSelectedColor
+		}
+		fillRect(screen, float32(b.x), float32(b.y), float32(b.w), float32(b.h), c, false)
+		if b.enabled && b.selected && b.w == menuInputWidth {
+			strokeRect(screen, float32(b.x), float32(b.y), float32(b.w), float32(b.h), 2, menuFocusColor, false)
+		}
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(b.x+10), float64(b.y+8))
+		if !b.enabled {
+			op.ColorScale.ScaleAlpha(0.4)
+		}
+		drawText(screen, b.text, f, op)
+	}
+
+	if len(l.message) > 0 {
+		f.Size = 18
+		drawCentered(screen, l.message, f, ScreenWidth/2, ScreenHeight-20)
+	}
+}
+
+func (l *Launcher) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if l.game != nil {
+		return l.game.Layout(outsideWidth, outsideHeight)
+	}
+	return scaledLayout(ScreenWidth, ScreenHeight, outsideWidth, outsideHeight)
+}
+
+// Close 关闭菜单创建的游戏，正在连接服务器时连接成功之后立即关闭
+func (l *Launcher) Close() {
+	if l.game != nil {
+		l.game.Close()
+		return
+	}
+	if l.connecting != nil {
+		go func(ch chan launchResult) {
+			if r := <-ch; r.game != nil {
+				r.game.Close()
+			}
+		}(l.connecting)
+	}
+}
+
+// 菜单中的语言选项，用各自的语言书写
+func langNames() []string {
+	names := make([]string, len(i18n.Langs))
+	for i, l := range i18n.Langs {
+		names[i] = l.Name()
+	}
+	return names
+}
+
+// 当前语言在菜单中的位置
+func langIndex() int {
+	for i, l := range i18n.Langs {
+		if l == i18n.Current() {
+			return i
+		}
+	}
+	return 0
+}
+
+// 切换到下一种语言
+func nextLang() {
+	i18n.SetLang(i18n.Langs[(langIndex()+1)%len(i18n.Langs)])
+}
+
+// 以(x, y)为中心绘制一行文字
+func drawCentered(screen *ebiten.Image, s string, f *text.GoTextFace, x, y int) {
+	width, height := text.Measure(s, f, 0)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(x)-width/2, float64(y)-height/2)
+	drawText(screen, s, f, op)
+}
@@ -21,5 +21,5 @@ func (oab *onceAgainBtn) Draw(screen *ebiten.Image, alpha float32) {
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Translate(float64(oab.x), float64(oab.y))
 	op.ColorScale.ScaleAlpha(alpha)
-	screen.DrawImage(oab.image, op)
+	drawImage(screen, oab.image, op)
 }
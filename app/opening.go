@@ -0,0 +1,44 @@
+package app
+
+import (
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/ecco"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 记录生效的下棋意图，并按照ECCO判断当前的开局
+func (g *Game) classifyOpening(st player.Statement) {
+	//做题从残局开始，不判断开局
+	if g.puzzle != nil {
+		return
+	}
+	redIsDown := g.redIsDown()
+	g.moves = append(g.moves, position.Move{
+		From: position.ToSquare(st.Source, redIsDown),
+		To:   position.ToSquare(st.Target, redIsDown),
+	})
+	if len(g.moves) > ecco.MaxPlies {
+		return
+	}
+	if op, ok := ecco.Default().Classify(position.Initial(), g.moves); ok {
+		g.opening = op.String()
+	}
+}
+
+// 在棋盘右上方显示开局名称
+func (g *Game) drawOpening(screen *ebiten.Image) {
+	if len(g.opening) == 0 {
+		return
+	}
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	width, _ := text.Measure(g.opening, f, 0)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(BoardWidth-g.boardLogicZeroPoint.x)-width, 4)
+	drawText(screen, g.opening, f, op)
+}
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 棋盘右侧着法列表和吃子区域的布局
+const (
+	panelX         = BoardWidth     //面板左边缘
+	panelWidth     = 224            //面板宽度
+	panelPadding   = 8              //面板内容的边距
+	moveListTop    = 40             //着法列表第一行的位置
+	moveRowHeight  = 22             //着法列表每行的高度
+	moveListRows   = 15             //着法列表最多显示的行数
+	moveNumberW    = 36             //回合序号所占的宽度
+	moveCellWidth  = 88             //每步着法所占的宽度
+	trayTop        = 410            //吃子区域的位置
+	trayPieceScale = 0.5            //吃子区域棋子图片的缩放比例
+	trayPerRow     = 6              //吃子区域每行的棋子数
+	trayPieceSize  = gridLength / 2 //吃子区域每个棋子所占的宽度
+)
+
+var (
+	panelColor        = color.RGBA{R: 0x28, G: 0x24, B: 0x20, A: 0xff}
+	currentMoveColor  = color.RGBA{R: 0x30, G: 0x90, B: 0xe0, A: 0x80}
+	reviewBannerColor = color.RGBA{R: 0xf0, G: 0xc0, B: 0x30, A: 0xff}
+)
+
+// 对局中生效的一步棋，用于着法列表和复盘
+type recordEntry struct {
+	st       player.Statement
+	notation string            //中文记谱
+	pos      position.Position //走完这步棋之后红方视角的局面
+}
+
+// 复盘状态：棋盘显示对局中的某个历史局面，不能走棋
+type reviewState struct {
+	index   int       //显示走了index步之后的局面
+	sprites []*Sprite //历史局面的棋子
+}
+
+// 记录刚刚生效的一步棋，before是走棋之前的局面
+func (g *Game) recordMove(st player.Statement, before position.Position) {
+	redIsDown := g.redIsDown()
+	m := position.Move{From: position.ToSquare(st.Source, redIsDown), To: position.ToSquare(st.Target, redIsDown)}
+	notation := before.ChineseMove(m)
+	after := before
+	after.Play(m)
+	g.record = append(g.record, recordEntry{st: st, notation: notation, pos: after})
+}
+
+// 重新摆棋时清空着法记录，从start局面开始记录
+func (g *Game) resetRecord(start position.Position) {
+	g.record = nil
+	g.recordStart = start
+	g.review = nil
+	g.moveListScroll = 0
+}
+
+// 记录mover一方吃掉的棋子。吃子记录保存在界面上，不读取内核协程修改的玩家
+func (g *Game) trackCapture(mover core.ChessmanGroup, code core.ChessmanCode) {
+	if g.captures == nil {
+		g.captures = make(map[core.ChessmanGroup][]core.ChessmanCode, 2)
+	}
+	g.captures[mover] = append(g.captures[mover], code)
+}
+
+// 重新摆棋时清空双方的吃子
+func (g *Game) clearCaptures() {
+	g.captures = nil
+}
+
+// 第index步之后的局面
+func (g *Game) recordPosition(index int) position.Position {
+	if index == 0 {
+		return g.recordStart
+	}
+	return g.record[index-1].pos
+}
+
+// 进入复盘，显示走了index步之后的局面。index等于总步数时回到对局
+func (g *Game) reviewAt(index int) {
+	index = max(0, min(index, len(g.record)))
+	if index == len(g.record) {
+		g.review = nil
+		return
+	}
+	if g.clickedSprite != nil {
+		g.clickedSprite.clicked = false
+		g.clickedSprite = nil
+	}
+	g.drag = nil
+	g.review = &reviewState{index: index, sprites: g.buildSprites(g.recordPosition(index))}
+}
+
+// 着法列表的第一步是否是黑方走的，这时第一个回合的红方着法空着
+func (g *Game) blackStartsRecord() bool {
+	return !g.recordStart.RedToMove
+}
+
+// 着法列表的总行数
+func (g *Game) moveListRowCount() int {
+	n := len(g.record)
+	if g.blackStartsRecord() {
+		n++
+	}
+	return (n + 1) / 2
+}
+
+// 着法列表显示的第一行。默认显示最新的着法，滚动之后向前翻看
+func (g *Game) moveListFirstRow() int {
+	first := max(0, g.moveListRowCount()-moveListRows)
+	return max(0, first-g.moveListScroll)
+}
+
+// 着法列表中(x, y)位置的着法序号，从0开始，没有着法时返回false
+func (g *Game) moveAt(x, y int) (int, bool) {
+	if x < panelX+panelPadding+moveNumberW || y < moveListTop {
+		return 0, false
+	}
+	row := (y - moveListTop) / moveRowHeight
+	col := (x - panelX - panelPadding - moveNumberW) / moveCellWidth
+	if row >= moveListRows || col > 1 {
+		return 0, false
+	}
+	slot := 2*(g.moveListFirstRow()+row) + col
+	if g.blackStartsRecord() {
+		slot--
+	}
+	if slot < 0 || slot >= len(g.record) {
+		return 0, false
+	}
+	return slot, true
+}
+
+// 处理着法列表和复盘的输入，返回true时本帧不再处理棋盘上的操作。
+// 滚轮翻看着法列表，点击着法进入复盘，左右方向键前后翻看，Esc或者点击最后一步回到对局
+func (g *Game) updatePanel() bool {
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		maxScroll := max(0, g.moveListRowCount()-moveListRows)
+		if dy > 0 {
+			g.moveListScroll = min(g.moveListScroll+1, maxScroll)
+		} else {
+			g.moveListScroll = max(g.moveListScroll-1, 0)
+		}
+	}
+
+	current := len(g.record)
+	if g.review != nil {
+		current = g.review.index
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft) && current > 0:
+		g.reviewAt(current - 1)
+		return true
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight) && g.review != nil:
+		g.reviewAt(current + 1)
+		return true
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape) && g.review != nil:
+		g.review = nil
+		return true
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := cursorPosition()
+		if x >= panelX {
+			if i, ok := g.moveAt(x, y); ok {
+				g.reviewAt(i + 1)
+			}
+			return true
+		}
+	}
+	return g.review != nil
+}
+
+// 绘制棋盘右侧的面板：着法列表和双方的吃子
+func (g *Game) drawPanel(screen *ebiten.Image) {
+	fillRect(screen, float32(panelX), 0, float32(ScreenWidth-panelX), float32(ScreenHeight), panelColor, false)
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	title := i18n.T("着法")
+	if g.computerThinking() {
+		title += i18n.T("  电脑思考中…")
+	}
+	if g.review != nil {
+		title = i18n.T("复盘 第%d/%d步", g.review.index, len(g.record))
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(panelX+panelPadding), 10)
+	drawText(screen, title, f, op)
+
+	current := len(g.record)
+	if g.review != nil {
+		current = g.review.index
+	}
+	f.Size = 16
+	first := g.moveListFirstRow()
+	for row := 0; row < moveListRows && first+row < g.moveListRowCount(); row++ {
+		y := moveListTop + row*moveRowHeight
+		op = &text.DrawOptions{}
+		op.GeoM.Translate(float64(panelX+panelPadding), float64(y+2))
+		drawText(screen, fmt.Sprintf("%d.", first+row+1), f, op)
+		for col := 0; col < 2; col++ {
+			i := 2*(first+row) + col
+			if g.blackStartsRecord() {
+				i--
+			}
+			if i < 0 || i >= len(g.record) {
+				continue
+			}
+			x := panelX + panelPadding + moveNumberW + col*moveCellWidth
+			if i+1 == current {
+				fillRect(screen, float32(x-2), float32(y), float32(moveCellWidth-4), float32(moveRowHeight), currentMoveColor, false)
+			}
+			op = &text.DrawOptions{}
+			op.GeoM.Translate(float64(x), float64(y+2))
+			drawText(screen, i18n.Move(g.record[i].notation), f, op)
+		}
+	}
+
+	g.drawLocalClock(screen, moveListTop+moveListRows*moveRowHeight+4)
+
+	red, black := g.player1, g.player2
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	y := g.drawTray(screen, i18n.T("红方吃子"), g.captures[red.GetGroup()], false, trayTop)
+	g.drawTray(screen, i18n.T("黑方吃子"), g.captures[black.GetGroup()], true, y)
+
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(float64(panelX+panelPadding), float64(ScreenHeight-24))
+	if g.review != nil {
+		op.ColorScale.ScaleWithColor(reviewBannerColor)
+		drawText(screen, i18n.T("← → 翻看  Esc 回到对局"), f, op)
+	} else {
+		drawText(screen, g.seatHint(), f, op)
+	}
+}
+
+// 绘制一方吃掉的棋子，capturedRed表示被吃掉的是红棋。返回下一块区域的位置
+func (g *Game) drawTray(screen *ebiten.Image, label string, codes []core.ChessmanCode, capturedRed bool, y int) int {
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      16,
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(panelX+panelPadding), float64(y))
+	drawText(screen, label, f, op)
+	y += moveRowHeight
+
+	for i, code := range codes {
+		x := float64(panelX + panelPadding + (i%trayPerRow)*trayPieceSize)
+		lookOf(code, capturedRed).draw(screen, x, float64(y+(i/trayPerRow)*trayPieceSize), trayPieceScale, 1)
+	}
+	rows := max(1, (len(codes)+trayPerRow-1)/trayPerRow)
+	return y + rows*trayPieceSize + 4
+}
+
+// 复盘时绘制历史局面和走到这里的一步棋
+func (g *Game) drawReview(screen *ebiten.Image) {
+	r := g.review
+	if r.index > 0 {
+		g.drawLastMove(screen, &g.record[r.index-1].st)
+	}
+	for _, s := range r.sprites {
+		s.Draw(screen, 1)
+	}
+}
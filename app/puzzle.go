@@ -0,0 +1,210 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/puzzle"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 做题模式的状态
+type puzzleMode struct {
+	puzzles  []puzzle.Puzzle
+	index    int              //当前题目的序号
+	session  *puzzle.Session  //当前题目的做题过程
+	progress *puzzle.Progress //练习记录
+	message  string           //走棋之后的提示
+}
+
+// NewPuzzleGame 创建做题模式的游戏，做题一方总是位于棋盘下方，对方的应着自动走出。
+// 练习记录保存在用户配置目录，无法保存时只记录在内存中
+func NewPuzzleGame(puzzles []puzzle.Puzzle) (*Game, error) {
+	if len(puzzles) == 0 {
+		return nil, errors.New("no puzzles")
+	}
+	progress := puzzle.NewProgress()
+	if dir, err := os.UserConfigDir(); err == nil {
+		if p, err := puzzle.OpenProgress(filepath.Join(dir, "xiangqi", "puzzles.json")); err == nil {
+			progress = p
+		} else {
+			log.Printf("open puzzle progress: %v", err)
+		}
+	}
+
+	p1 := player.NewPlayer()
+	p1.SetID(localPlayer1ID)
+	p1.SetIsDown(true)
+	p1.SetGroup(core.Group1)
+	p2 := player.NewPlayer()
+	p2.SetID(localPlayer2ID)
+	p2.SetGroup(core.Group2)
+
+	g := newGame(p1, p2)
+	g.puzzle = &puzzleMode{puzzles: puzzles, progress: progress}
+	if err := g.loadPuzzle(progress.Next(puzzles, 0)); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// 开始做第index道题目
+func (g *Game) loadPuzzle(index int) error {
+	pm := g.puzzle
+	session, err := puzzle.NewSession(pm.puzzles[index])
+	if err != nil {
+		return err
+	}
+	pm.index = index
+	pm.session = session
+	pm.message = ""
+
+	//做题一方在棋盘下方
+	g.player1.SetIsFirst(session.RedSolves())
+	g.player2.SetIsFirst(!session.RedSolves())
+	g.placeSprites(session.Position())
+	g.clickedSprite = nil
+	g.gameMsg = nil
+	return nil
+}
+
+// 做题模式的输入：点击或者拖动走棋，H查看提示，R重新开始，N下一题。解完之后点击棋盘进入下一题
+func (g *Game) updatePuzzle() error {
+	pm := g.puzzle
+	if target, ok := g.updateDrag(); ok && !pm.session.Solved() {
+		g.playPuzzleMove(g.selectedStatement(target))
+		return nil
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyH):
+		if m, ok := pm.session.Hint(); ok {
+			pos := pm.session.Position()
+			pm.message = i18n.T("提示：%s", i18n.Move(pos.ChineseMove(m)))
+			//选中提示的棋子
+			if sp := g.spriteAtCoordinate(position.ToCoordinate(m.From, g.redIsDown())); sp != nil {
+				if g.clickedSprite != nil {
+					g.clickedSprite.clicked = false
+				}
+				sp.clicked = true
+				g.clickedSprite = sp
+			}
+		}
+		return nil
+	case inpututil.IsKeyJustPressed(ebiten.KeyR):
+		pm.session.Restart()
+		g.placeSprites(pm.session.Position())
+		g.clickedSprite = nil
+		pm.message = ""
+		return nil
+	case inpututil.IsKeyJustPressed(ebiten.KeyN):
+		return g.loadPuzzle((pm.index + 1) % len(pm.puzzles))
+	}
+
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return nil
+	}
+	if pm.session.Solved() {
+		return g.loadPuzzle(pm.progress.Next(pm.puzzles, pm.index+1))
+	}
+
+	x, y := cursorPosition()
+	if sp := g.spriteAt(x, y); sp != nil && sp.group == g.nextRoundGroup {
+		if g.clickedSprite != nil {
+			g.clickedSprite.clicked = false
+		}
+		sp.clicked = true
+		g.clickedSprite = sp
+		g.startDrag(sp)
+		return nil
+	}
+	if g.clickedSprite == nil || !g.InBoard(x, y) {
+		return nil
+	}
+	tx, ty := g.transformCoordinate(g.revisesCoordinate(x, y))
+	g.playPuzzleMove(g.selectedStatement(core.Coordinate{X: tx, Y: ty}))
+	return nil
+}
+
+// 校验做题一方的着法，正确时移动棋子并自动走出对方的应着
+func (g *Game) playPuzzleMove(st player.Statement) {
+	pm := g.puzzle
+	redIsDown := g.redIsDown()
+	m := position.Move{From: position.ToSquare(st.Source, redIsDown), To: position.ToSquare(st.Target, redIsDown)}
+	fb, err := pm.session.Play(m)
+	g.clickedSprite.clicked = false
+	g.clickedSprite = nil
+	switch {
+	case errors.Is(err, puzzle.ErrIllegal):
+		pm.message = i18n.T("不符合走棋规则")
+		return
+	case err != nil:
+		pm.message = err.Error()
+		return
+	case !fb.Correct:
+		pm.message = i18n.T("不对，再想想")
+		return
+	}
+
+	g.replaySprite(st)
+	if fb.Reply != nil {
+		g.replaySprite(player.Statement{
+			Source: position.ToCoordinate(fb.Reply.From, redIsDown),
+			Target: position.ToCoordinate(fb.Reply.To, redIsDown),
+		})
+	}
+	if !fb.Solved {
+		pm.message = i18n.T("正确，继续")
+		return
+	}
+	if err := pm.progress.Record(pm.session, time.Now()); err != nil {
+		log.Printf("save puzzle progress: %v", err)
+	}
+	if pm.session.Succeeded() {
+		pm.message = i18n.T("解题成功！点击棋盘进入下一题")
+	} else {
+		pm.message = i18n.T("解完了，走错%d次、提示%d次。点击棋盘进入下一题", pm.session.Mistakes(), pm.session.Hints())
+	}
+}
+
+// 题目名称加步数，例如 马后炮（1步杀）
+func puzzleTitle(s *puzzle.Session) string {
+	if s.Mate() {
+		return i18n.T("%s（%d步杀）", s.Puzzle().Name, s.Moves())
+	}
+	return i18n.T("%s（%d步）", s.Puzzle().Name, s.Moves())
+}
+
+// 绘制题目名称、练习记录和提示
+func (g *Game) drawPuzzle(screen *ebiten.Image) {
+	pm := g.puzzle
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	rate, attempts := pm.progress.SuccessRate()
+	rec := pm.progress.Get(pm.session.Puzzle())
+	header := i18n.T("第%d/%d题 %s  本题%d/%d  总成功率%.0f%%（%d次）",
+		pm.index+1, len(pm.puzzles), puzzleTitle(pm.session), rec.Successes, rec.Attempts, 100*rate, attempts)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), 4)
+	drawText(screen, header, f, op)
+
+	footer := pm.message
+	if len(footer) == 0 {
+		footer = i18n.T("H 提示  R 重新开始  N 下一题")
+	}
+	f.Size = 22
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), float64(g.boardLogicZeroPoint.y+9*gridLength+30))
+	drawText(screen, footer, f, op)
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+	"github.com/CXeon/xiangqi/transport"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 积分榜显示的人数
+const leaderboardSize = 5
+
+// 单机对战的两位玩家的id
+const (
+	localPlayer1ID = 1
+	localPlayer2ID = 2
+)
+
+// 单机对战的存储，保存在用户配置目录，无法保存时只记录在内存中
+func newLocalRepository() storage.Repository {
+	var repo storage.Repository = storage.NewMemoryRepository()
+	if dir, err := os.UserConfigDir(); err == nil {
+		if fr, err := storage.OpenFileRepository(filepath.Join(dir, "xiangqi")); err == nil {
+			repo = fr
+		} else {
+			log.Printf("open repository: %v", err)
+		}
+	}
+	repo.EnsurePlayer(localPlayer1ID, "玩家1")
+	repo.EnsurePlayer(localPlayer2ID, "玩家2")
+	return repo
+}
+
+// 单机对战结束后归档对局、记录评分，并显示双方的评分、战绩和积分榜
+func (g *Game) recordLocalResult(msg chessgame.GameMsg) {
+	if g.repo == nil {
+		return
+	}
+	red, black := g.player1, g.player2
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	if _, err := g.repo.SaveGame(storage.NewGameRecord(g.gameCore, red, black, msg, g.startedAt)); err != nil {
+		g.ratingLines = []string{i18n.T("对局保存失败：%s", err)}
+		return
+	}
+	outcome, ok := rating.OutcomeOf(msg, red.GetGroup())
+	if !ok {
+		return
+	}
+	redRating, blackRating, err := g.ratings.Record(red.GetID(), black.GetID(), outcome)
+	if err != nil {
+		g.ratingLines = []string{i18n.T("评分保存失败：%s", err)}
+		return
+	}
+	g.showRatings([]rating.Rating{redRating, blackRating}, g.ratings.Leaderboard(leaderboardSize, rating.ByGlicko))
+
+	//单机对战的双方都是本地玩家，显示双方的历史战绩
+	for _, p := range []player.PlayerInterface{red, black} {
+		g.ratingLines = append(g.ratingLines, g.statsLine(p.GetID()))
+	}
+}
+
+// 玩家历史战绩的显示内容
+func (g *Game) statsLine(playerID int) string {
+	name := i18n.T("玩家%d", playerID)
+	if profile, ok := g.repo.GetPlayer(playerID); ok {
+		name = profile.Name
+	}
+	s := g.repo.GetStats(playerID)
+	return i18n.T("%s 执红%d胜%d和%d负 执黑%d胜%d和%d负 吃子%d",
+		name, s.Red.Wins, s.Red.Draws, s.Red.Losses, s.Black.Wins, s.Black.Draws, s.Black.Losses, s.Captures)
+}
+
+// 网络对战结束后，在后台查询服务器的积分榜，不阻塞界面刷新
+func (g *Game) queryLeaderboard(addr string, result []rating.Rating) {
+	g.showRatings(result, nil)
+	g.leadersCh = make(chan []rating.Rating, 1)
+	go func(ch chan []rating.Rating) {
+		leaders, err := transport.QueryLeaderboard(addr, leaderboardSize)
+		if err != nil {
+			leaders = nil
+		}
+		ch <- leaders
+	}(g.leadersCh)
+}
+
+// 接收后台查询到的积分榜
+func (g *Game) pollLeaderboard() {
+	if g.leadersCh == nil {
+		return
+	}
+	select {
+	case leaders := <-g.leadersCh:
+		g.leadersCh = nil
+		g.showRatings(g.resultRatings, leaders)
+	default:
+	}
+}
+
+// 生成对局双方评分和积分榜的显示内容
+func (g *Game) showRatings(result, leaders []rating.Rating) {
+	g.resultRatings = result
+	lines := make([]string, 0, len(result)+len(leaders)+1)
+	for i, r := range result {
+		side := i18n.T("红方")
+		if i == 1 {
+			side = i18n.T("黑方")
+		}
+		lines = append(lines, i18n.T("%s 玩家%d  Elo %.0f  Glicko %.0f±%.0f", side, r.PlayerID, r.Elo, r.Glicko.Rating, 2*r.Glicko.RD))
+	}
+	if len(leaders) > 0 {
+		lines = append(lines, i18n.T("积分榜"))
+		for i, r := range leaders {
+			lines = append(lines, i18n.T("%d. 玩家%d  %.0f  %d胜%d和%d负", i+1, r.PlayerID, r.Glicko.Rating, r.Wins, r.Draws, r.Losses))
+		}
+	}
+	g.ratingLines = lines
+}
+
+// 在半透明的底板上绘制评分和积分榜
+func (g *Game) drawRatings(screen *ebiten.Image) {
+	if len(g.ratingLines) == 0 {
+		return
+	}
+	const lineHeight = 22
+	x := float32(g.boardLogicZeroPoint.x + g.gridLength/2)
+	y := float32(g.boardLogicZeroPoint.y + g.gridLength/2)
+	width := float32(7 * g.gridLength)
+	height := float32(len(g.ratingLines)*lineHeight + 16)
+	fillRect(screen, x, y, width, height, color.RGBA{A: 0xa0}, false)
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      16,
+	}
+	for i, line := range g.ratingLines {
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(x+8), float64(y+8)+float64(i*lineHeight))
+		op.ColorScale.ScaleWithColor(color.White)
+		drawText(screen, line, f, op)
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// 翻转棋盘和选择座位的输入。F 随时翻转棋盘；单机对战走第一步棋之前，C 交换红黑，S 交换上下
+func (g *Game) updateSeat() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		g.flipBoard()
+	}
+	if !g.canChangeSeat() {
+		return nil
+	}
+	swapColor := inpututil.IsKeyJustPressed(ebiten.KeyC)
+	swapSide := inpututil.IsKeyJustPressed(ebiten.KeyS)
+	if !swapColor && !swapSide {
+		return nil
+	}
+	g.restartLocalGame(func() {
+		for _, p := range []player.PlayerInterface{g.player1, g.player2} {
+			if swapColor {
+				p.SetIsFirst(!p.GetIsFirst())
+			}
+			if swapSide {
+				p.SetIsDown(!p.GetIsDown())
+			}
+		}
+	})
+	return nil
+}
+
+// 单机对战还没有走棋时可以选择座位
+func (g *Game) canChangeSeat() bool {
+	return g.gameCore != nil && len(g.record) == 0 && len(g.winner) == 0
+}
+
+// 重新开始单机对局。原来的内核可能还在等待下一步棋或者电脑正在思考，所以先让它在后台退出，
+// 退出之后由reseat修改座位，再创建新的内核。reseat为nil时座位不变
+func (g *Game) restartLocalGame(reseat func()) {
+	g.restarting = g.stopCore()
+	g.reseat = reseat
+}
+
+// 上一局的内核退出之后开始新的一局。返回true表示还在等待，这时界面不接受操作
+func (g *Game) finishRestart() (bool, error) {
+	if g.restarting == nil {
+		return false, nil
+	}
+	select {
+	case <-g.restarting:
+	default:
+		return true, nil
+	}
+	g.restarting = nil
+	if g.reseat != nil {
+		g.reseat()
+		g.reseat = nil
+	}
+	if err := g.startCore(); err != nil {
+		return false, err
+	}
+	g.initSprites()
+	g.winner = ""
+	g.ratingLines = nil
+	g.gameMsg = nil
+	g.failMsg = ""
+	g.clickedSprite = nil
+	return false, nil
+}
+
+// 翻转棋盘：棋子留在内核棋盘上的原位，界面上下左右颠倒显示
+func (g *Game) flipBoard() {
+	g.finishAnimations()
+	coordinates := make([]core.Coordinate, len(g.sprites))
+	for i, sp := range g.sprites {
+		x, y := g.transformCoordinate(sp.x-g.spriteReparation, sp.y-g.spriteReparation)
+		coordinates[i] = core.Coordinate{X: x, Y: y}
+	}
+	g.flipped = !g.flipped
+	for i, sp := range g.sprites {
+		x, y := g.untransformCoordinate(coordinates[i].X, coordinates[i].Y)
+		sp.MoveTo(x+g.spriteReparation, y+g.spriteReparation)
+	}
+	if g.review != nil {
+		g.reviewAt(g.review.index)
+	}
+}
+
+// 面板底部显示的座位操作提示
+func (g *Game) seatHint() string {
+	if g.canChangeSeat() {
+		return i18n.T("C 换红黑  S 换上下  F 翻转")
+	}
+	return i18n.T("F 翻转棋盘")
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"github.com/CXeon/xiangqi/assets"
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// 音效的采样率
+const soundSampleRate = 44100
+
+// 音效的种类
+type sound int
+
+const (
+	soundMove    sound = iota //走棋
+	soundCapture              //吃子
+	soundCheck                //将军
+	soundIllegal              //走法不合规则
+	soundWin                  //胜利
+	soundLose                 //失败
+)
+
+// 内置的音效文件
+var soundFiles = map[sound][]byte{
+	soundMove:    assets.MoveWav,
+	soundCapture: assets.CaptureWav,
+	soundCheck:   assets.CheckWav,
+	soundIllegal: assets.IllegalWav,
+	soundWin:     assets.WinWav,
+	soundLose:    assets.LoseWav,
+}
+
+// 启动菜单中音效的档位，第0档关闭音效
+var (
+	soundLevelNames = []string{"关闭", "小", "中", "大"}
+	soundLevels     = []float64{0, 0.3, 0.6, 1}
+)
+
+// 调节音量的步长
+const volumeStep = 0.1
+
+var (
+	soundVolume  = 0.6
+	soundMuted   = false
+	audioContext *audio.Context
+	soundPCM     map[sound][]byte //解码之后的音效，为nil时还没有解码
+	soundFailed  bool             //音效解码失败，不再播放
+)
+
+// SetVolume 设置音效的音量，范围0到1
+func SetVolume(volume float64) {
+	soundVolume = max(0, min(volume, 1))
+}
+
+// SetMuted 关闭或者打开音效
+func SetMuted(muted bool) {
+	soundMuted = muted
+}
+
+// 当前音量对应的菜单档位
+func soundLevel() int {
+	if soundMuted || soundVolume == 0 {
+		return 0
+	}
+	level := 1
+	for i, v := range soundLevels {
+		if v <= soundVolume {
+			level = max(level, i)
+		}
+	}
+	return level
+}
+
+// 按照菜单档位设置音量
+func setSoundLevel(level int) {
+	SetMuted(level == 0)
+	if level > 0 {
+		SetVolume(soundLevels[level])
+	}
+}
+
+// 播放音效。第一次播放时创建音频环境并解码所有的音效
+func playSound(s sound) {
+	if soundMuted || soundVolume == 0 || soundFailed {
+		return
+	}
+	if soundPCM == nil {
+		if audioContext == nil {
+			audioContext = audio.NewContext(soundSampleRate)
+		}
+		pcm := make(map[sound][]byte, len(soundFiles))
+		for k, data := range soundFiles {
+			stream, err := wav.DecodeWithSampleRate(soundSampleRate, bytes.NewReader(data))
+			if err == nil {
+				pcm[k], err = io.ReadAll(stream)
+			}
+			if err != nil {
+				log.Printf("decode sound: %v", err)
+				soundFailed = true
+				return
+			}
+		}
+		soundPCM = pcm
+	}
+	p := audioContext.NewPlayerFromBytes(soundPCM[s])
+	p.SetVolume(soundVolume)
+	p.Play()
+}
+
+// 音量的输入，任何模式下都可以调节：V 静音，- 和 = 调节音量
+func updateVolume() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyV):
+		SetMuted(!soundMuted)
+	case inpututil.IsKeyJustPressed(ebiten.KeyMinus):
+		SetVolume(soundVolume - volumeStep)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEqual):
+		SetMuted(false)
+		SetVolume(soundVolume + volumeStep)
+	}
+}
+
+// 一步棋生效之后的音效：将军优先，其次是吃子
+func (g *Game) playMoveSound(msg chessgame.GameMsg) {
+	switch {
+	case msg.Check != nil:
+		playSound(soundCheck)
+	case len(msg.Captured()) > 0:
+		playSound(soundCapture)
+	default:
+		playSound(soundMove)
+	}
+}
+
+// 对局结束的音效。本地玩家获胜，或者双方都由本地玩家操作、观战时播放胜利音效
+func (g *Game) playResultSound(winner core.ChessmanGroup) {
+	loser := g.player1.GetGroup()
+	if loser == winner {
+		loser = g.player2.GetGroup()
+	}
+	if !g.isLocalGroup(winner) && g.isLocalGroup(loser) {
+		playSound(soundLose)
+		return
+	}
+	playSound(soundWin)
+}
@@ -3,25 +3,35 @@ package app
 import (
 	"github.com/CXeon/xiangqi/core"
 	"github.com/hajimehoshi/ebiten/v2"
-	"image"
 	"image/color"
 )
 
 // Sprite 代表棋子的图片.
 type Sprite struct {
-	image      *ebiten.Image
-	alphaImage *image.Alpha
-	x          int                //在棋盘的坐标
-	y          int                //在棋盘的坐标
-	clicked    bool               //被点击选中
-	group      core.ChessmanGroup //棋子所属阵营
-	code       core.ChessmanCode  //棋子代号
+	x       int                //在棋盘的坐标
+	y       int                //在棋盘的坐标
+	clicked bool               //被点击选中
+	group   core.ChessmanGroup //棋子所属阵营
+	code    core.ChessmanCode  //棋子代号
+	red     bool               //红棋还是黑棋，按照当前主题绘制
 
+	//绘制位置和逻辑位置不同时，棋子跟随鼠标或者逐帧移动到逻辑位置
+	anim     *spriteAnimation
+	dragging bool
+	dragX    int
+	dragY    int
+}
+
+// 棋子从起点逐帧移动到逻辑位置的动画
+type spriteAnimation struct {
+	fromX, fromY float64
+	frame        int
+	frames       int
 }
 
 // In returns true if (x, y) is in the sprite, and false otherwise.
 func (s *Sprite) In(x, y int) bool {
-	return s.alphaImage.At(x-s.x, y-s.y).(color.Alpha).A > 0
+	return lookOf(s.code, s.red).alpha.At(x-s.x, y-s.y).(color.Alpha).A > 0
 }
 
 // MoveTo moves the sprite to the position (x, y).
@@ -44,10 +54,57 @@ func (s *Sprite) MoveTo(x, y int) {
 	}
 }
 
+// AnimateTo 将棋子的逻辑位置移动到(x, y)，绘制位置在frames帧之内从当前的绘制位置平滑移动过去
+func (s *Sprite) AnimateTo(x, y, frames int) {
+	fromX, fromY := s.drawPosition()
+	s.dragging = false
+	s.MoveTo(x, y)
+	s.anim = nil
+	if frames > 0 && (fromX != float64(s.x) || fromY != float64(s.y)) {
+		s.anim = &spriteAnimation{fromX: fromX, fromY: fromY, frames: frames}
+	}
+}
+
+// DragTo 拖动棋子时绘制在(x, y)，逻辑位置不变
+func (s *Sprite) DragTo(x, y int) {
+	s.anim = nil
+	s.dragging = true
+	s.dragX = x
+	s.dragY = y
+}
+
+// Update 推进一帧动画
+func (s *Sprite) Update() {
+	if s.anim == nil {
+		return
+	}
+	s.anim.frame++
+	if s.anim.frame >= s.anim.frames {
+		s.anim = nil
+	}
+}
+
+// StopAnimation 结束动画，直接绘制在逻辑位置
+func (s *Sprite) StopAnimation() {
+	s.anim = nil
+	s.dragging = false
+}
+
+// 当前的绘制位置，动画先快后慢
+func (s *Sprite) drawPosition() (float64, float64) {
+	switch {
+	case s.dragging:
+		return float64(s.dragX), float64(s.dragY)
+	case s.anim != nil:
+		t := float64(s.anim.frame) / float64(s.anim.frames)
+		t = 1 - (1-t)*(1-t)*(1-t)
+		return s.anim.fromX + (float64(s.x)-s.anim.fromX)*t, s.anim.fromY + (float64(s.y)-s.anim.fromY)*t
+	}
+	return float64(s.x), float64(s.y)
+}
+
 // Draw draws the sprite.
 func (s *Sprite) Draw(screen *ebiten.Image, alpha float32) {
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(s.x), float64(s.y))
-	op.ColorScale.ScaleAlpha(alpha)
-	screen.DrawImage(s.image, op)
+	x, y := s.drawPosition()
+	lookOf(s.code, s.red).draw(screen, x, y, 1, alpha)
 }
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/study"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 排局欣赏模式的状态
+type studyMode struct {
+	studies []*study.Study
+	index   int           //当前排局的序号
+	cursor  *study.Cursor //当前排局中的位置
+	choice  int           //下一步选择的变着，0是主变
+}
+
+// NewStudyGame 创建排局欣赏模式的游戏，红方总是位于棋盘下方。
+// 右方向键前进、左方向键后退，上下方向键选择变着，Home回到开始，End走完主变，N下一个排局
+func NewStudyGame(studies []*study.Study) (*Game, error) {
+	if len(studies) == 0 {
+		return nil, errors.New("no studies")
+	}
+	p1 := player.NewPlayer()
+	p1.SetID(localPlayer1ID)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p1.SetGroup(core.Group1)
+	p2 := player.NewPlayer()
+	p2.SetID(localPlayer2ID)
+	p2.SetGroup(core.Group2)
+
+	g := newGame(p1, p2)
+	g.study = &studyMode{studies: studies}
+	if err := g.loadStudy(0); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// 打开第index个排局
+func (g *Game) loadStudy(index int) error {
+	sm := g.study
+	cursor, err := study.NewCursor(sm.studies[index])
+	if err != nil {
+		return err
+	}
+	sm.index = index
+	sm.cursor = cursor
+	g.showStudyPosition()
+	return nil
+}
+
+// 按当前位置重新摆棋，并高亮显示走到这里的一步棋
+func (g *Game) showStudyPosition() {
+	sm := g.study
+	sm.choice = 0
+	g.placeSprites(sm.cursor.Position())
+	g.clickedSprite = nil
+	g.gameMsg = nil
+	if m, _, ok := sm.cursor.LastMove(); ok {
+		g.lastMove = &player.Statement{Source: position.ToCoordinate(m.From, true), Target: position.ToCoordinate(m.To, true)}
+	}
+}
+
+// 排局欣赏模式的输入
+func (g *Game) updateStudy() error {
+	sm := g.study
+	c := sm.cursor
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		if c.Forward(sm.choice) {
+			g.showStudyPosition()
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		if c.Back() {
+			g.showStudyPosition()
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		if n := len(c.Variations()); n > 0 {
+			sm.choice = (sm.choice + 1) % n
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		if n := len(c.Variations()); n > 0 {
+			sm.choice = (sm.choice + n - 1) % n
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		c.Home()
+		g.showStudyPosition()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		c.End()
+		g.showStudyPosition()
+	case inpututil.IsKeyJustPressed(ebiten.KeyN), inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		return g.loadStudy((sm.index + 1) % len(sm.studies))
+	case inpututil.IsKeyJustPressed(ebiten.KeyP), inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		return g.loadStudy((sm.index + len(sm.studies) - 1) % len(sm.studies))
+	}
+	return nil
+}
+
+// 绘制排局名称、当前着法、注释和可选的变着
+func (g *Game) drawStudy(screen *ebiten.Image) {
+	sm := g.study
+	c := sm.cursor
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      18,
+	}
+	header := i18n.T("第%d/%d局 %s", sm.index+1, len(sm.studies), c.Study().Title)
+	if m, before, ok := c.LastMove(); ok {
+		header += i18n.T("  第%d步 %s", c.Node().Ply(), i18n.Move(before.ChineseMove(m)))
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), 4)
+	drawText(screen, header, f, op)
+
+	//变着列表，当前选择的变着加上括号
+	variations := c.Variations()
+	pos := c.Position()
+	names := make([]string, 0, len(variations))
+	for i, v := range variations {
+		name := i18n.Move(pos.ChineseMove(v.Move))
+		if i == sm.choice {
+			name = "【" + name + "】"
+		}
+		names = append(names, name)
+	}
+	footer := i18n.T("→ 前进  ← 后退  ↑↓ 选择变着  Home/End 开始/结束  N/P 换局")
+	if len(variations) > 1 {
+		footer = i18n.T("变着：%s", strings.Join(names, " "))
+	} else if len(variations) == 0 {
+		footer = i18n.T("结束") + "  " + footer
+	}
+
+	//棋盘下方最多显示两行注释和一行提示
+	y := float64(g.boardLogicZeroPoint.y + 9*gridLength + 4)
+	f.Size = 16
+	for _, line := range wrapText(c.Comment(), 32, 2) {
+		op = &text.DrawOptions{}
+		op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), y)
+		drawText(screen, line, f, op)
+		y += 20
+	}
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(float64(g.boardLogicZeroPoint.x), y)
+	drawText(screen, footer, f, op)
+}
+
+// 按字数折行，注释中的汉字宽度基本相同。超过maxLines行时省略后面的内容
+func wrapText(s string, width, maxLines int) []string {
+	runes := []rune(s)
+	lines := make([]string, 0, maxLines)
+	for len(runes) > width {
+		if len(lines) == maxLines-1 {
+			return append(lines, string(runes[:width-1])+"…")
+		}
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	if len(runes) > 0 {
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
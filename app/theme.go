@@ -0,0 +1,225 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/theme"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 矢量绘制棋盘和棋子的颜色
+var (
+	vectorBoardColor = color.RGBA{R: 0xe8, G: 0xc8, B: 0x8c, A: 0xff}
+	vectorLineColor  = color.RGBA{R: 0x80, G: 0x50, B: 0x30, A: 0xff}
+	vectorFaceColor  = color.RGBA{R: 0xf5, G: 0xe6, B: 0xc8, A: 0xff}
+	vectorRedColor   = color.RGBA{R: 0xc0, G: 0x20, B: 0x20, A: 0xff}
+	vectorBlackColor = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+)
+
+// 一种棋子在主题中的外观
+type pieceLook struct {
+	image *ebiten.Image //主题中的图片，为nil时用矢量图形绘制
+	alpha *image.Alpha  //点击判断的遮罩
+	glyph string        //矢量绘制时棋子上的字
+	red   bool
+}
+
+// 转换成ebiten图片的主题，切换回来时不用重新转换
+type themeLook struct {
+	pack   *theme.Pack
+	board  *ebiten.Image //为nil时用矢量图形绘制
+	pieces map[theme.Piece]*pieceLook
+}
+
+var (
+	themes       []*theme.Pack                  //可以选择的主题，第一个是内置的默认主题
+	themeLooks   = map[*theme.Pack]*themeLook{} //已经转换过的主题
+	currentTheme *themeLook
+)
+
+// LoadThemes 读取目录中的主题，加在内置主题的后面。每个子目录或者zip文件是一套主题
+func LoadThemes(dir string) error {
+	packs, err := theme.Discover(dir)
+	if err != nil {
+		return err
+	}
+	themes = append(themes, packs...)
+	return nil
+}
+
+// SetTheme 按照名称选择主题
+func SetTheme(name string) error {
+	for _, p := range themes {
+		if p.Name == name {
+			applyTheme(p)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown theme %q", name)
+}
+
+// 切换到下一个主题
+func nextTheme() {
+	for i, p := range themes {
+		if p == currentTheme.pack {
+			applyTheme(themes[(i+1)%len(themes)])
+			return
+		}
+	}
+}
+
+// 菜单中显示的当前主题
+func themeLabel() string {
+	for i, p := range themes {
+		if p == currentTheme.pack {
+			return i18n.T("%s（%d/%d）", i18n.T(p.Name), i+1, len(themes))
+		}
+	}
+	return i18n.T(currentTheme.pack.Name)
+}
+
+// 使用主题绘制棋盘和棋子
+func applyTheme(p *theme.Pack) {
+	if look, ok := themeLooks[p]; ok {
+		currentTheme = look
+		return
+	}
+	look := &themeLook{pack: p, pieces: make(map[theme.Piece]*pieceLook)}
+	if p.Board != nil {
+		look.board = ebiten.NewImageFromImage(p.Board)
+	}
+	for _, piece := range theme.Pieces {
+		pl := &pieceLook{glyph: p.Glyph(piece), red: piece.Red}
+		if img := p.Pieces[piece]; img != nil {
+			pl.image = ebiten.NewImageFromImage(img)
+			pl.alpha = alphaMask(img)
+		} else {
+			pl.alpha = discMask(theme.PieceSize)
+		}
+		look.pieces[piece] = pl
+	}
+	themeLooks[p] = look
+	currentTheme = look
+}
+
+// 图片的透明度，用于判断点击位置
+func alphaMask(img image.Image) *image.Alpha {
+	b := img.Bounds()
+	mask := image.NewAlpha(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for j := b.Min.Y; j < b.Max.Y; j++ {
+		for i := b.Min.X; i < b.Max.X; i++ {
+			mask.Set(i-b.Min.X, j-b.Min.Y, img.At(i, j))
+		}
+	}
+	return mask
+}
+
+// 矢量绘制的棋子是圆形
+func discMask(size int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, size, size))
+	r := float64(size) / 2
+	for j := 0; j < size; j++ {
+		for i := 0; i < size; i++ {
+			dx, dy := float64(i)+0.5-r, float64(j)+0.5-r
+			if dx*dx+dy*dy <= r*r {
+				mask.SetAlpha(i, j, color.Alpha{A: 0xff})
+			}
+		}
+	}
+	return mask
+}
+
+// 当前主题中棋子的外观
+func lookOf(code core.ChessmanCode, red bool) *pieceLook {
+	return currentTheme.pieces[theme.Piece{Code: code, Red: red}]
+}
+
+// 以(x, y)为左上角绘制棋子，scale是相对于原图的缩放比例
+func (l *pieceLook) draw(screen *ebiten.Image, x, y, scale float64, alpha float32) {
+	if l.image != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(x, y)
+		op.ColorScale.ScaleAlpha(alpha)
+		drawImage(screen, l.image, op)
+		return
+	}
+
+	r := float64(theme.PieceSize) / 2 * scale
+	cx, cy := float32(x+r), float32(y+r)
+	ink := vectorBlackColor
+	if l.red {
+		ink = vectorRedColor
+	}
+	fillCircle(screen, cx, cy, float32(r-1), fade(vectorFaceColor, alpha), true)
+	strokeCircle(screen, cx, cy, float32(r-2), float32(2*scale), fade(ink, alpha), true)
+	strokeCircle(screen, cx, cy, float32(r-6*scale), float32(scale), fade(ink, alpha), true)
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      30 * scale,
+	}
+	width, height := text.Measure(l.glyph, f, 0)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(cx)-width/2, float64(cy)-height/2)
+	op.ColorScale.ScaleWithColor(ink)
+	op.ColorScale.ScaleAlpha(alpha)
+	drawText(screen, l.glyph, f, op)
+}
+
+// 按照透明度淡化颜色
+func fade(c color.RGBA, alpha float32) color.RGBA {
+	scale := func(v uint8) uint8 { return uint8(float32(v) * alpha) }
+	return color.RGBA{R: scale(c.R), G: scale(c.G), B: scale(c.B), A: scale(c.A)}
+}
+
+// 绘制当前主题的棋盘
+func drawBoard(screen *ebiten.Image) {
+	if currentTheme.board != nil {
+		drawImage(screen, currentTheme.board, &ebiten.DrawImageOptions{})
+		return
+	}
+
+	fillRect(screen, 0, 0, float32(BoardWidth), float32(ScreenHeight), vectorBoardColor, false)
+	x0, y0, g := float32(boardLogicZeroX), float32(boardLogicZeroY), float32(gridLength)
+	strokeRect(screen, x0-6, y0-6, 8*g+12, 9*g+12, 4, vectorLineColor, false)
+	for rank := 0; rank <= 9; rank++ {
+		y := y0 + float32(rank)*g
+		strokeLine(screen, x0, y, x0+8*g, y, 1, vectorLineColor, false)
+	}
+	for file := 0; file <= 8; file++ {
+		x := x0 + float32(file)*g
+		if file == 0 || file == 8 {
+			strokeLine(screen, x, y0, x, y0+9*g, 1, vectorLineColor, false)
+			continue
+		}
+		//竖线在河界断开
+		strokeLine(screen, x, y0, x, y0+4*g, 1, vectorLineColor, false)
+		strokeLine(screen, x, y0+5*g, x, y0+9*g, 1, vectorLineColor, false)
+	}
+	//九宫的斜线
+	for _, top := range []float32{y0, y0 + 7*g} {
+		strokeLine(screen, x0+3*g, top, x0+5*g, top+2*g, 1, vectorLineColor, true)
+		strokeLine(screen, x0+5*g, top, x0+3*g, top+2*g, 1, vectorLineColor, true)
+	}
+
+	f := &text.GoTextFace{
+		Source:    hanziFaceSource,
+		Direction: text.DirectionLeftToRight,
+		Size:      36,
+	}
+	river := float64(y0 + 4.5*g)
+	for i, s := range []string{"楚河", "漢界"} {
+		width, height := text.Measure(s, f, 0)
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(x0+float32(2+4*i)*g)-width/2, river-height/2)
+		op.ColorScale.ScaleWithColor(vectorLineColor)
+		drawText(screen, s, f, op)
+	}
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// 界面按照固定的逻辑坐标布局，棋盘、面板和菜单的位置都在ScreenWidth×ScreenHeight之内计算。
+// Layout根据窗口大小和屏幕的DPI算出缩放比例，绘制时把逻辑坐标放大到实际像素，文字按照放大之后的字号渲染，
+// 所以放大窗口或者在高DPI屏幕上同样清晰。鼠标位置先换算回逻辑坐标，
+// 棋子的点击判断（Sprite.In的透明度遮罩）和transformCoordinate等坐标转换都与缩放无关
+
+// 实际像素和逻辑坐标的比例，每一帧由Layout更新
+var viewScale = 1.0
+
+// 按照窗口大小计算缩放比例，返回实际绘制的屏幕大小。
+// 宽高比和逻辑大小相同，窗口比例不同时ebiten在两边留黑边。窗口大小未知时不缩放
+func scaledLayout(width, height, outsideWidth, outsideHeight int) (int, int) {
+	if outsideWidth <= 0 || outsideHeight <= 0 {
+		viewScale = 1
+		return width, height
+	}
+	dpi := ebiten.Monitor().DeviceScaleFactor()
+	viewScale = math.Min(float64(outsideWidth)*dpi/float64(width), float64(outsideHeight)*dpi/float64(height))
+	return int(math.Round(float64(width) * viewScale)), int(math.Round(float64(height) * viewScale))
+}
+
+// 鼠标在逻辑坐标中的位置
+func cursorPosition() (int, int) {
+	x, y := ebiten.CursorPosition()
+	return int(float64(x) / viewScale), int(float64(y) / viewScale)
+}
+
+// 绘制图片，op中的变换使用逻辑坐标
+func drawImage(screen, img *ebiten.Image, op *ebiten.DrawImageOptions) {
+	op.GeoM.Scale(viewScale, viewScale)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(img, op)
+}
+
+// 绘制文字，op中只能有平移，位置使用逻辑坐标。文字按照放大之后的字号渲染，而不是把渲染好的文字放大
+func drawText(screen *ebiten.Image, s string, f *text.GoTextFace, op *text.DrawOptions) {
+	face := &text.GoTextFace{
+		Source:    f.Source,
+		Direction: f.Direction,
+		Size:      f.Size * viewScale,
+		Language:  f.Language,
+	}
+	x, y := op.GeoM.Element(0, 2), op.GeoM.Element(1, 2)
+	op.GeoM.Reset()
+	op.GeoM.Translate(x*viewScale, y*viewScale)
+	text.Draw(screen, s, face, op)
+}
+
+// 下面的图形绘制函数和vector包的同名函数一样，坐标、大小和线宽都使用逻辑坐标
+
+func fillRect(screen *ebiten.Image, x, y, width, height float32, clr color.Color, antialias bool) {
+	s := float32(viewScale)
+	vector.DrawFilledRect(screen, x*s, y*s, width*s, height*s, clr, antialias)
+}
+
+func strokeRect(screen *ebiten.Image, x, y, width, height, strokeWidth float32, clr color.Color, antialias bool) {
+	s := float32(viewScale)
+	vector.StrokeRect(screen, x*s, y*s, width*s, height*s, strokeWidth*s, clr, antialias)
+}
+
+func strokeLine(screen *ebiten.Image, x0, y0, x1, y1, strokeWidth float32, clr color.Color, antialias bool) {
+	s := float32(viewScale)
+	vector.StrokeLine(screen, x0*s, y0*s, x1*s, y1*s, strokeWidth*s, clr, antialias)
+}
+
+func fillCircle(screen *ebiten.Image, cx, cy, r float32, clr color.Color, antialias bool) {
+	s := float32(viewScale)
+	vector.DrawFilledCircle(screen, cx*s, cy*s, r*s, clr, antialias)
+}
+
+func strokeCircle(screen *ebiten.Image, cx, cy, r, strokeWidth float32, clr color.Color, antialias bool) {
+	s := float32(viewScale)
+	vector.StrokeCircle(screen, cx*s, cy*s, r*s, strokeWidth*s, clr, antialias)
+}
@@ -59,4 +59,22 @@ var (
 
 	//go:embed fzfs_gbk.ttf
 	HanziTTF []byte //中文字体
+
+	//go:embed move.wav
+	MoveWav []byte //走棋音效
+
+	//go:embed capture.wav
+	CaptureWav []byte //吃子音效
+
+	//go:embed check.wav
+	CheckWav []byte //将军音效
+
+	//go:embed illegal.wav
+	IllegalWav []byte //走法不合规则的音效
+
+	//go:embed win.wav
+	WinWav []byte //胜利音效
+
+	//go:embed lose.wav
+	LoseWav []byte //失败音效
 )
@@ -0,0 +1,154 @@
+package book
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// 二进制文件格式：
+// 文件头为4字节的magic、2字节的版本号和4字节的记录数，之后是按哈希值排序的定长记录。
+// 每条记录26字节：8字节局面哈希、1字节起点、1字节终点（行*9+列），以及4个4字节的权重、胜、和、负。
+// 所有整数都是大端字节序
+const (
+	magic      = "XQBK"
+	version    = 1
+	recordSize = 26
+)
+
+type record struct {
+	Hash   uint64
+	From   uint8
+	To     uint8
+	Weight uint32
+	Wins   uint32
+	Draws  uint32
+	Losses uint32
+}
+
+func squareIndex(sq position.Square) uint8 {
+	return uint8(sq.Rank*position.Files + sq.File)
+}
+
+func squareOf(i uint8) position.Square {
+	return position.Square{File: int(i) % position.Files, Rank: int(i) / position.Files}
+}
+
+// WriteTo 以二进制格式写出开局库
+func (b *Book) WriteTo(w io.Writer) (int64, error) {
+	b.mu.RLock()
+	records := make([]record, 0, len(b.entries))
+	for key, entries := range b.entries {
+		for _, e := range entries {
+			records = append(records, record{
+				Hash:   key,
+				From:   squareIndex(e.Move.From),
+				To:     squareIndex(e.Move.To),
+				Weight: e.Weight,
+				Wins:   e.Wins,
+				Draws:  e.Draws,
+				Losses: e.Losses,
+			})
+		}
+	}
+	b.mu.RUnlock()
+
+	//按哈希值排序，相同局面按权重从高到低，保证同一个开局库写出的文件相同
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Hash != records[j].Hash {
+			return records[i].Hash < records[j].Hash
+		}
+		if records[i].Weight != records[j].Weight {
+			return records[i].Weight > records[j].Weight
+		}
+		if records[i].From != records[j].From {
+			return records[i].From < records[j].From
+		}
+		return records[i].To < records[j].To
+	})
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	binary.Write(bw, binary.BigEndian, uint16(version))
+	binary.Write(bw, binary.BigEndian, uint32(len(records)))
+	for _, r := range records {
+		if err := binary.Write(bw, binary.BigEndian, r); err != nil {
+			return 0, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return int64(len(magic) + 6 + len(records)*recordSize), nil
+}
+
+// Read 读取二进制格式的开局库
+func Read(r io.Reader) (*Book, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+6)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("not an opening book file")
+	}
+	if v := binary.BigEndian.Uint16(header[len(magic):]); v != version {
+		return nil, fmt.Errorf("unsupported opening book version %d", v)
+	}
+	n := binary.BigEndian.Uint32(header[len(magic)+2:])
+
+	b := New()
+	for i := uint32(0); i < n; i++ {
+		var rec record
+		if err := binary.Read(br, binary.BigEndian, &rec); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		from, to := squareOf(rec.From), squareOf(rec.To)
+		if !from.Valid() || !to.Valid() {
+			return nil, fmt.Errorf("record %d: invalid move", i)
+		}
+		b.entries[rec.Hash] = append(b.entries[rec.Hash], Entry{
+			Move:   position.Move{From: from, To: to},
+			Weight: rec.Weight,
+			Wins:   rec.Wins,
+			Draws:  rec.Draws,
+			Losses: rec.Losses,
+		})
+	}
+	return b, nil
+}
+
+// Save 保存到文件，先写临时文件再替换，避免写到一半时文件损坏
+func (b *Book) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = b.WriteTo(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load 从文件读取开局库
+func Load(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,185 @@
+package book
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// Entry 开局库中一个局面下的一步棋
+type Entry struct {
+	Move   position.Move
+	Weight uint32 //权重，从对局记录生成时为这步棋出现的次数
+	Wins   uint32 //走这步棋的一方赢的局数
+	Draws  uint32
+	Losses uint32
+}
+
+// Games 有结果的局数
+func (e Entry) Games() uint32 {
+	return e.Wins + e.Draws + e.Losses
+}
+
+// Score 走这步棋的一方的平均得分，没有结果时为0.5
+func (e Entry) Score() float64 {
+	if e.Games() == 0 {
+		return 0.5
+	}
+	return (float64(e.Wins) + float64(e.Draws)/2) / float64(e.Games())
+}
+
+// BuildOptions 从对局记录生成开局库的设置
+type BuildOptions struct {
+	MaxPlies  int    //每局只收录前多少步，小于等于0时使用DefaultMaxPlies
+	MinWeight uint32 //权重小于MinWeight的着法不收录
+}
+
+// DefaultMaxPlies 生成开局库时每局默认收录的步数
+const DefaultMaxPlies = 20
+
+// Book 开局库，用局面的Zobrist哈希值查找着法
+type Book struct {
+	mu      sync.RWMutex
+	entries map[uint64][]Entry
+}
+
+// New 创建空的开局库
+func New() *Book {
+	return &Book{entries: make(map[uint64][]Entry)}
+}
+
+// Build 从对局记录生成开局库
+func Build(records []storage.GameRecord, opts BuildOptions) (*Book, error) {
+	b := New()
+	for _, rec := range records {
+		if err := b.AddGame(rec, opts.MaxPlies); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MinWeight > 1 {
+		b.Prune(opts.MinWeight)
+	}
+	return b, nil
+}
+
+// Add 在局面下增加一步棋的权重，用于手工编辑开局库
+func (b *Book) Add(pos position.Position, m position.Move, weight uint32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entry(pos, m).Weight += weight
+}
+
+// Record 收录一局对局中的一步棋，权重加1，并按走这步棋的一方统计对局结果
+func (b *Book) Record(pos position.Position, m position.Move, outcome rating.Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entry(pos, m)
+	e.Weight++
+	switch {
+	case outcome == rating.Draw:
+		e.Draws++
+	case (outcome == rating.RedWin) == pos.RedToMove:
+		e.Wins++
+	default:
+		e.Losses++
+	}
+}
+
+// 获取局面下一步棋的记录，没有时新建，调用时必须持有锁
+func (b *Book) entry(pos position.Position, m position.Move) *Entry {
+	key := pos.Hash()
+	entries := b.entries[key]
+	for i := range entries {
+		if entries[i].Move == m {
+			return &entries[i]
+		}
+	}
+	b.entries[key] = append(entries, Entry{Move: m})
+	return &b.entries[key][len(entries)]
+}
+
+// AddGame 收录一局对局记录的前maxPlies步
+func (b *Book) AddGame(rec storage.GameRecord, maxPlies int) error {
+	if maxPlies <= 0 {
+		maxPlies = DefaultMaxPlies
+	}
+	pos, err := rec.StartPosition()
+	if err != nil {
+		return err
+	}
+	for i, m := range rec.PositionMoves() {
+		if i >= maxPlies {
+			break
+		}
+		if len(pos.At(m.From).Code) == 0 {
+			return errors.New("invalid game record: no chessman at " + m.From.String())
+		}
+		b.Record(pos, m, rec.Result)
+		pos.Play(m)
+	}
+	return nil
+}
+
+// Prune 删除权重小于minWeight的着法
+func (b *Book) Prune(minWeight uint32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, entries := range b.entries {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Weight >= minWeight {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			delete(b.entries, key)
+		} else {
+			b.entries[key] = kept
+		}
+	}
+}
+
+// Lookup 查询局面下的所有着法，按权重从高到低排列
+func (b *Book) Lookup(pos position.Position) []Entry {
+	b.mu.RLock()
+	entries := append([]Entry(nil), b.entries[pos.Hash()]...)
+	b.mu.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Weight > entries[j].Weight
+	})
+	return entries
+}
+
+// Pick 按权重随机选择局面下的一步棋，开局库中没有这个局面时返回false
+func (b *Book) Pick(pos position.Position, rnd *rand.Rand) (Entry, bool) {
+	entries := b.Lookup(pos)
+	total := uint64(0)
+	for _, e := range entries {
+		total += uint64(e.Weight)
+	}
+	if total == 0 {
+		return Entry{}, false
+	}
+	n := uint64(rnd.Int63n(int64(total)))
+	for _, e := range entries {
+		if n < uint64(e.Weight) {
+			return e, true
+		}
+		n -= uint64(e.Weight)
+	}
+	return entries[len(entries)-1], true
+}
+
+// Len 开局库中的局面数
+func (b *Book) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries)
+}
@@ -0,0 +1,145 @@
+package book
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// 用ICCS着法生成红方位于棋盘下方的对局记录
+func newRecord(t *testing.T, result rating.Outcome, moves ...string) storage.GameRecord {
+	rec := storage.GameRecord{Result: result, RedIsDown: true}
+	for _, s := range moves {
+		m, err := position.ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.Moves = append(rec.Moves, player.Statement{
+			Source: position.ToCoordinate(m.From, true),
+			Target: position.ToCoordinate(m.To, true),
+		})
+	}
+	return rec
+}
+
+func TestBuild(t *testing.T) {
+	records := []storage.GameRecord{
+		newRecord(t, rating.RedWin, "h2e2", "h9g7", "h0g2"),
+		newRecord(t, rating.BlackWin, "h2e2", "b9c7"),
+		newRecord(t, rating.Draw, "b0c2", "h9g7"),
+	}
+	b, err := Build(records, BuildOptions{MaxPlies: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//开局局面、炮二平五之后、马八进七之后
+	if b.Len() != 3 {
+		t.Fatalf("expect 3 positions, got %d", b.Len())
+	}
+
+	entries := b.Lookup(position.Initial())
+	if len(entries) != 2 || entries[0].Move.String() != "h2e2" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if e := entries[0]; e.Weight != 2 || e.Wins != 1 || e.Losses != 1 || e.Score() != 0.5 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	//黑方走的着法以黑方的结果统计
+	pos := position.Initial()
+	m, _ := position.ParseMove("h2e2")
+	pos.Play(m)
+	entries = b.Lookup(pos)
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Move.String() == "b9c7" && e.Wins != 1 {
+			t.Fatalf("black should win with b9c7, got %+v", e)
+		}
+	}
+
+	b.Prune(2)
+	if b.Len() != 1 {
+		t.Fatalf("expect 1 position after prune, got %d", b.Len())
+	}
+}
+
+func TestBinary(t *testing.T) {
+	records := []storage.GameRecord{
+		newRecord(t, rating.RedWin, "h2e2", "h9g7"),
+		newRecord(t, rating.RedWin, "h2e2", "b9c7"),
+		newRecord(t, rating.BlackWin, "c3c4"),
+	}
+	b, err := Build(records, BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	n, err := b.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) || buf.Len() != 10+4*recordSize {
+		t.Fatalf("unexpected size %d", buf.Len())
+	}
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err = b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != b.Len() {
+		t.Fatalf("expect %d positions, got %d", b.Len(), loaded.Len())
+	}
+	got, want := loaded.Lookup(position.Initial()), b.Lookup(position.Initial())
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("expect %+v, got %+v", want, got)
+	}
+
+	if _, err = Read(bytes.NewReader([]byte("XQBX\x00\x01\x00\x00\x00\x00"))); err == nil {
+		t.Fatal("invalid magic should fail")
+	}
+	if _, err = Read(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("truncated file should fail")
+	}
+}
+
+func TestPick(t *testing.T) {
+	b := New()
+	pos := position.Initial()
+	h2e2, _ := position.ParseMove("h2e2")
+	c3c4, _ := position.ParseMove("c3c4")
+	b.Add(pos, h2e2, 9)
+	b.Add(pos, c3c4, 1)
+
+	rnd := rand.New(rand.NewSource(1))
+	count := 0
+	for i := 0; i < 1000; i++ {
+		e, ok := b.Pick(pos, rnd)
+		if !ok {
+			t.Fatal("position should be in book")
+		}
+		if e.Move == h2e2 {
+			count++
+		}
+	}
+	if count < 850 || count > 950 {
+		t.Fatalf("h2e2 should be picked about 900 times, got %d", count)
+	}
+
+	//走棋方不同的局面哈希值不同
+	pos.RedToMove = false
+	if _, ok := b.Pick(pos, rnd); ok {
+		t.Fatal("position with black to move should not be in book")
+	}
+}
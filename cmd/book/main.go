@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/CXeon/xiangqi/book"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+func main() {
+	dataDir := flag.String("data", "data", "对局记录所在的目录")
+	out := flag.String("out", "book.bin", "开局库文件")
+	maxPlies := flag.Int("maxplies", book.DefaultMaxPlies, "每局收录的步数")
+	minWeight := flag.Uint("min", 1, "出现次数少于min的着法不收录")
+	probe := flag.String("probe", "", "不生成开局库，查询开局库中FEN局面的着法")
+	flag.Parse()
+
+	if len(*probe) > 0 {
+		probeBook(*out, *probe)
+		return
+	}
+
+	repo, err := storage.OpenFileRepository(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repo.Close()
+	games := repo.ListGames(0)
+	b, err := book.Build(games, book.BuildOptions{MaxPlies: *maxPlies, MinWeight: uint32(*minWeight)})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = b.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d games, %d positions written to %s\n", len(games), b.Len(), *out)
+}
+
+// 打印开局库中局面的所有着法
+func probeBook(path, fen string) {
+	b, err := book.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pos, err := position.ParseFEN(fen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries := b.Lookup(pos)
+	if len(entries) == 0 {
+		fmt.Println("position not in book")
+		return
+	}
+	for _, e := range entries {
+		fmt.Printf("%s %s  weight %d  +%d =%d -%d  score %.1f%%\n",
+			e.Move, pos.ChineseMove(e.Move), e.Weight, e.Wins, e.Draws, e.Losses, 100*e.Score())
+	}
+}
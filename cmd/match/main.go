@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/book"
+	"github.com/CXeon/xiangqi/match"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+	"github.com/CXeon/xiangqi/tablebase"
+)
+
+func main() {
+	engine1 := flag.String("engine1", "greedy", "第一个引擎：random、greedy，或者 ucci:引擎路径 参数...")
+	engine2 := flag.String("engine2", "random", "第二个引擎，格式同engine1")
+	games := flag.Int("games", 100, "对局数，双方交替先手")
+	concurrency := flag.Int("concurrency", 1, "同时进行的对局数")
+	maxPlies := flag.Int("maxplies", ai.DefaultMaxPlies, "每局的步数限制，超过后判和")
+	openingsFile := flag.String("openings", "", "开局文件，每行一个FEN或者ICCS着法序列，每个开局双方交换先后手各下一局")
+	depth := flag.Int("depth", 0, "UCCI引擎的搜索深度")
+	moveTime := flag.Duration("movetime", 0, "UCCI引擎每步的思考时间，设置了depth时忽略")
+	sprt := flag.String("sprt", "", "SPRT检验的Elo区间，例如 0,10，为空时不检验")
+	alpha := flag.Float64("alpha", 0.05, "SPRT的第一类错误概率")
+	beta := flag.Float64("beta", 0.05, "SPRT的第二类错误概率")
+	bookFile := flag.String("book", "", "开局库文件，双方在开局库的范围内按开局库走棋")
+	tbDir := flag.String("tablebase", "", "残局库目录，双方在残局库的范围内按残局库走棋")
+	dataDir := flag.String("data", "", "保存对局记录的目录，为空时不保存")
+	flag.Parse()
+
+	cfg := match.Config{Games: *games, Concurrency: *concurrency, MaxPlies: *maxPlies}
+	if len(*openingsFile) > 0 {
+		f, err := os.Open(*openingsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Openings, err = ai.LoadOpenings(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *openingsFile, err)
+		}
+	}
+	if len(*sprt) > 0 {
+		var elo0, elo1 float64
+		if _, err := fmt.Sscanf(*sprt, "%g,%g", &elo0, &elo1); err != nil {
+			log.Fatalf("invalid sprt bounds %q", *sprt)
+		}
+		cfg.SPRT = &match.SPRTConfig{Elo0: elo0, Elo1: elo1, Alpha: *alpha, Beta: *beta}
+	}
+
+	var openingBook *book.Book
+	if len(*bookFile) > 0 {
+		var err error
+		if openingBook, err = book.Load(*bookFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var tb *tablebase.Tablebase
+	if len(*tbDir) > 0 {
+		var err error
+		if tb, err = tablebase.Load(*tbDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts := ai.UCCIOptions{Depth: *depth, MoveTime: *moveTime}
+	m, err := match.New(cfg,
+		match.Side{ID: 1, New: engineFactory(*engine1, 1, opts, openingBook, tb)},
+		match.Side{ID: 2, New: engineFactory(*engine2, 2, opts, openingBook, tb)})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(*dataDir) > 0 {
+		repo, err := storage.OpenFileRepository(*dataDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer repo.Close()
+		m.UseRepository(repo)
+	}
+
+	m.OnGame(func(g match.GameSummary) {
+		red, black := g.Engine1Name, g.Engine2Name
+		if !g.Engine1Red {
+			red, black = black, red
+		}
+		line := fmt.Sprintf("game %d: %s vs %s %s (%s, %d plies)", g.Index+1, red, black, outcomeText(g.Outcome), g.Reason, g.Plies)
+		if len(g.Opening) > 0 {
+			line += " opening " + g.Opening
+		}
+		s := g.StatsSoFar
+		line += fmt.Sprintf("  score %d-%d-%d", s.Wins, s.Losses, s.Draws)
+		if cfg.SPRT != nil {
+			line += fmt.Sprintf("  llr %.2f", g.LLRSoFar)
+		}
+		fmt.Println(line)
+	})
+
+	start := time.Now()
+	stats, err := m.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	report(stats, cfg.SPRT, time.Since(start))
+}
+
+// 按名称创建引擎的工厂，内置引擎每次使用不同的随机种子。
+// 开局库不为nil时先查开局库，残局库不为nil时在残局阶段查残局库
+func engineFactory(spec string, id int64, opts ai.UCCIOptions, openingBook *book.Book, tb *tablebase.Tablebase) match.EngineFactory {
+	var seed atomic.Int64
+	seed.Store(time.Now().UnixNano() + id<<32)
+	return func() (ai.Engine, error) {
+		engine, err := newEngine(spec, seed.Add(1), opts)
+		if err != nil {
+			return nil, err
+		}
+		if tb != nil {
+			engine = ai.NewTablebaseEngine(tb, engine)
+		}
+		if openingBook != nil {
+			engine = ai.NewBookEngine(openingBook, engine, seed.Add(1))
+		}
+		return engine, nil
+	}
+}
+
+func newEngine(spec string, seed int64, opts ai.UCCIOptions) (ai.Engine, error) {
+	if path, ok := strings.CutPrefix(spec, "ucci:"); ok {
+		fields := strings.Fields(path)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid engine %q", spec)
+		}
+		return ai.NewUCCI(fields[0], fields[1:], opts)
+	}
+	return ai.NewEngine(spec, seed)
+}
+
+func outcomeText(o rating.Outcome) string {
+	switch o {
+	case rating.RedWin:
+		return "1-0"
+	case rating.BlackWin:
+		return "0-1"
+	}
+	return "1/2-1/2"
+}
+
+// 输出对战的统计结果，胜负以第一个引擎为准
+func report(stats match.Stats, sprt *match.SPRTConfig, elapsed time.Duration) {
+	diff, margin := stats.EloDiff()
+	fmt.Printf("games %d, wins %d, draws %d, losses %d, score %.1f%% in %s\n",
+		stats.Games(), stats.Wins, stats.Draws, stats.Losses, 100*stats.Score(), elapsed.Round(time.Second))
+	fmt.Printf("elo difference %.1f +/- %.1f, los %.1f%%\n", diff, margin, 100*stats.LOS())
+	if sprt != nil {
+		lower, upper := sprt.Bounds()
+		fmt.Printf("sprt [%g, %g] llr %.2f (%.2f, %.2f): %s\n",
+			sprt.Elo0, sprt.Elo1, stats.LLR(*sprt), lower, upper, stats.SPRT(*sprt))
+	}
+}
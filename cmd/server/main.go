@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/CXeon/xiangqi/matchmaking"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+	"github.com/CXeon/xiangqi/transport"
+)
+
+func main() {
+	cfg := transport.DefaultConfig()
+	addr := flag.String("addr", ":7788", "监听地址")
+	flag.DurationVar(&cfg.TimeControl, "time", cfg.TimeControl, "每一方的对局时间")
+	flag.DurationVar(&cfg.GracePeriod, "grace", cfg.GracePeriod, "玩家断线后保留座位的时间")
+	flag.DurationVar(&cfg.WatchDelay, "watch-delay", cfg.WatchDelay, "观战消息的推送延迟")
+	dataDir := flag.String("data", "data", "保存玩家资料、对局记录和评分的目录，为空时不保存")
+	matchInterval := flag.Duration("match-interval", 2*time.Second, "自动匹配每一轮的间隔，为0时不接受排队")
+	flag.Parse()
+
+	server := transport.NewServer(cfg)
+	if len(*dataDir) > 0 {
+		repo, err := storage.OpenFileRepository(*dataDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer repo.Close()
+		server.UseRepository(repo)
+		server.UseRatings(rating.NewService(rating.DefaultConfig(), repo.Ratings()))
+	}
+	if *matchInterval > 0 {
+		m := matchmaking.NewMatchmaker(matchmaking.DefaultConfig(), matchmaking.NewMemoryStore())
+		server.UseMatchmaker(m, *matchInterval, make(chan struct{}))
+	}
+	log.Printf("xiangqi server listening on %s", *addr)
+	if err := server.ListenAndServe(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/tablebase"
+)
+
+func main() {
+	dir := flag.String("dir", "tablebase", "残局库目录")
+	materials := flag.String("material", "KR-K", "要生成的子力组成，多个用逗号分隔，例如 KRP-KAABB,KN-KA")
+	probe := flag.String("probe", "", "不生成残局表，查询残局库中FEN局面的结果和每个着法的结果")
+	flag.Parse()
+
+	if len(*probe) > 0 {
+		probeTablebase(*dir, *probe)
+		return
+	}
+
+	tb, err := tablebase.Load(*dir)
+	if err != nil {
+		//目录不存在时从头生成
+		tb = tablebase.New()
+	}
+	for _, s := range strings.Split(*materials, ",") {
+		m, err := tablebase.ParseMaterial(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start := time.Now()
+		t, err := tb.Generate(m)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %d positions in %s\n", t.Material(), t.Len(), time.Since(start).Round(time.Millisecond))
+	}
+	if err := tb.Save(*dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// 打印局面的结果，以及每个着法走完之后对方的结果
+func probeTablebase(dir, fen string) {
+	tb, err := tablebase.Load(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pos, err := position.ParseFEN(fen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r, ok := tb.Probe(pos)
+	if !ok {
+		fmt.Println("position not in tablebase")
+		return
+	}
+	fmt.Println(r)
+	moves, _ := tb.ProbeMoves(pos)
+	for _, m := range moves {
+		fmt.Printf("%s %s  %s\n", m.Move, pos.ChineseMove(m.Move), m.Result)
+	}
+}
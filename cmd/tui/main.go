@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/CXeon/xiangqi/tui"
+)
+
+func main() {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	color := flag.Bool("color", !noColor, "使用ANSI颜色绘制棋盘")
+	flag.Parse()
+
+	game, err := tui.NewGame(os.Stdin, os.Stdout, *color)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer game.Close()
+	if err = game.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
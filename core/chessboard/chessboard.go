@@ -7,6 +7,9 @@ import (
 	"github.com/CXeon/xiangqi/core/chessman"
 )
 
+// ErrNoChessman 起点没有要移动的棋子
+var ErrNoChessman = errors.New("the chessman is not exist")
+
 const (
 	rows = 10 //棋盘行数
 	cols = 9  //棋盘列数
@@ -102,10 +105,10 @@ func (board *Chessboard) getChessman(group core.ChessmanGroup, code core.Chessma
 	x, y := location.X, location.Y
 	cm := board.matrix[y][x]
 	if cm == nil {
-		return nil, errors.New(fmt.Sprintf("the chessman %s is not exist", code))
+		return nil, fmt.Errorf("%w: %s", ErrNoChessman, code)
 	}
 	if cm.GetChessmanCode() != code || cm.GetChessmanGroup() != group {
-		return nil, errors.New(fmt.Sprintf("the location [%d,%d] is other chess group %d, code %s", location.X, location.Y, cm.GetChessmanGroup(), cm.GetChessmanCode()))
+		return nil, fmt.Errorf("%w: the location [%d,%d] is other chess group %d, code %s", ErrNoChessman, location.X, location.Y, cm.GetChessmanGroup(), cm.GetChessmanCode())
 	}
 	return cm, nil
 }
@@ -27,4 +27,13 @@ type ChessboardInterface interface {
 
 	//清空棋盘的棋子
 	ClearChessmen()
+
+	//返回某个坐标上的棋子按照走法规则可以到达的所有坐标
+	LegalTargets(source core.Coordinate) []core.Coordinate
+
+	//返回阵营所有可以走的移动，不包括走完之后将帅见面的移动
+	LegalMoves(group core.ChessmanGroup) []Move
+
+	//阵营的将帅是否被将军
+	IsInCheck(group core.ChessmanGroup) bool
 }
@@ -0,0 +1,140 @@
+package chessboard
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessman"
+)
+
+// Move 棋盘上的一步移动
+type Move struct {
+	Group  core.ChessmanGroup
+	Code   core.ChessmanCode
+	Source core.Coordinate
+	Target core.Coordinate
+	Won    core.ChessmanCode //目标坐标上会被吃掉的棋子，不吃子时为空
+}
+
+// LegalTargets 返回source坐标上的棋子按照走法规则可以到达的所有坐标
+func (board *Chessboard) LegalTargets(source core.Coordinate) []core.Coordinate {
+	targets := make([]core.Coordinate, 0)
+	cm := board.matrix[source.Y][source.X]
+	if cm == nil {
+		return targets
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			target := core.Coordinate{X: x, Y: y}
+			if target == source {
+				continue
+			}
+			if ok, err := cm.CheckMove(board.matrix, board.rowGroup, source, target); ok && err == nil {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// LegalMoves 返回阵营所有可以走的移动。走完之后将帅见面会直接判负，这样的移动不包括在内
+func (board *Chessboard) LegalMoves(group core.ChessmanGroup) []Move {
+	moves := make([]Move, 0)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			cm := board.matrix[y][x]
+			if cm == nil || cm.GetChessmanGroup() != group {
+				continue
+			}
+			source := core.Coordinate{X: x, Y: y}
+			for _, target := range board.LegalTargets(source) {
+				move := Move{
+					Group:  group,
+					Code:   cm.GetChessmanCode(),
+					Source: source,
+					Target: target,
+				}
+				if won := board.matrix[target.Y][target.X]; won != nil {
+					move.Won = won.GetChessmanCode()
+				}
+				//吃掉对方将帅直接获胜，不需要再检查见面
+				if move.Won != core.JiangShuai && JiangShuaiFace2Face(board.afterMove(source, target)) {
+					continue
+				}
+				moves = append(moves, move)
+			}
+		}
+	}
+	return moves
+}
+
+// IsInCheck 阵营的将帅是否正被对方的棋子攻击，即对方下一步可以按照走法规则吃掉将帅
+func (board *Chessboard) IsInCheck(group core.ChessmanGroup) bool {
+	king, ok := board.findChessman(group, core.JiangShuai)
+	if !ok {
+		return false
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			cm := board.matrix[y][x]
+			if cm == nil || cm.GetChessmanGroup() == group {
+				continue
+			}
+			if ok, err := cm.CheckMove(board.matrix, board.rowGroup, core.Coordinate{X: x, Y: y}, king); ok && err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 查找阵营的某一种棋子的坐标，用于查找将帅
+func (board *Chessboard) findChessman(group core.ChessmanGroup, code core.ChessmanCode) (core.Coordinate, bool) {
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			cm := board.matrix[y][x]
+			if cm != nil && cm.GetChessmanGroup() == group && cm.GetChessmanCode() == code {
+				return core.Coordinate{X: x, Y: y}, true
+			}
+		}
+	}
+	return core.Coordinate{}, false
+}
+
+// 返回移动之后的棋盘矩阵，不修改原来的棋盘
+func (board *Chessboard) afterMove(source, target core.Coordinate) [][]chessman.ChessmanInterface {
+	matrix := make([][]chessman.ChessmanInterface, len(board.matrix))
+	for i, row := range board.matrix {
+		matrix[i] = make([]chessman.ChessmanInterface, len(row))
+		copy(matrix[i], row)
+	}
+	matrix[target.Y][target.X] = matrix[source.Y][source.X]
+	matrix[source.Y][source.X] = nil
+	return matrix
+}
+
+// JiangShuaiFace2Face 检查棋盘矩阵上两个阵营的将帅是否见面，即在同一列而且中间没有棋子
+func JiangShuaiFace2Face(matrix [][]chessman.ChessmanInterface) bool {
+	//下方的将帅在0到2行，上方的将帅在7到9行
+	down, downOK := findJiangShuai(matrix, 0, 2)
+	up, upOK := findJiangShuai(matrix, 7, 9)
+	if !downOK || !upOK || down.X != up.X {
+		return false
+	}
+	for y := down.Y + 1; y < up.Y; y++ {
+		if matrix[y][down.X] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// 在fromRow到toRow行之间查找将帅
+func findJiangShuai(matrix [][]chessman.ChessmanInterface, fromRow, toRow int) (core.Coordinate, bool) {
+	for y := fromRow; y <= toRow; y++ {
+		for x, cm := range matrix[y] {
+			if cm != nil && cm.GetChessmanCode() == core.JiangShuai {
+				return core.Coordinate{X: x, Y: y}, true
+			}
+		}
+	}
+	return core.Coordinate{}, false
+}
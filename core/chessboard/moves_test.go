@@ -0,0 +1,57 @@
+package chessboard
+
+import (
+	"testing"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessman"
+)
+
+// 只有两个将帅和一个挡在中间的车的棋盘
+func newBoard(t *testing.T) *Chessboard {
+	t.Helper()
+	board := NewChessboard()
+	board.DivideGroup(core.Group1, []int{0, 1, 2, 3, 4})
+	board.DivideGroup(core.Group2, []int{5, 6, 7, 8, 9})
+	jiang1 := chessman.NewChessman(core.JiangShuai, "", core.Group1, core.Coordinate{X: 4, Y: 0})
+	jiang1.BindRule(chessman.RuleJiangShuai)
+	ju := chessman.NewChessman(core.Ju, "", core.Group1, core.Coordinate{X: 4, Y: 3})
+	ju.BindRule(chessman.RuleJu)
+	jiang2 := chessman.NewChessman(core.JiangShuai, "", core.Group2, core.Coordinate{X: 4, Y: 9})
+	jiang2.BindRule(chessman.RuleJiangShuai)
+	chessmen := []chessman.ChessmanInterface{jiang1, ju, jiang2}
+	if err := board.PutChessmenOnBoard(chessmen); err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+func TestJiangShuaiFace2Face(t *testing.T) {
+	board := newBoard(t)
+	if JiangShuaiFace2Face(board.GetMatrix()) {
+		t.Fatalf("the rook is between the kings")
+	}
+	if !JiangShuaiFace2Face(board.afterMove(core.Coordinate{X: 4, Y: 3}, core.Coordinate{X: 3, Y: 3})) {
+		t.Fatalf("kings should face each other once the rook leaves the file")
+	}
+}
+
+// 车离开中路会让将帅见面，这样的移动不能出现在LegalMoves里
+func TestLegalMovesSkipFace2Face(t *testing.T) {
+	board := newBoard(t)
+	for _, m := range board.LegalMoves(core.Group1) {
+		if m.Code == core.Ju && m.Target.X != 4 {
+			t.Errorf("rook move %v -> %v leaves the kings facing", m.Source, m.Target)
+		}
+	}
+	//将可以横走离开中路
+	found := false
+	for _, m := range board.LegalMoves(core.Group1) {
+		if m.Code == core.JiangShuai && m.Target == (core.Coordinate{X: 3, Y: 0}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the king should be able to step sideways")
+	}
+}
@@ -0,0 +1,86 @@
+package chessclock
+
+import (
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// State 棋钟在某一时刻的状态，可以用来同步给客户端
+type State struct {
+	Remaining map[core.ChessmanGroup]time.Duration //双方剩余时间
+	Running   core.ChessmanGroup                   //正在计时的阵营，GroupNone表示棋钟已停止
+}
+
+// Clock 对局棋钟，同一时刻只有一方在计时
+type Clock struct {
+	remaining map[core.ChessmanGroup]time.Duration
+	running   core.ChessmanGroup
+	since     time.Time //当前一方开始计时的时间
+
+	now func() time.Time
+}
+
+// NewClock 创建棋钟，双方的初始时间都是initial
+func NewClock(initial time.Duration) *Clock {
+	return &Clock{
+		remaining: map[core.ChessmanGroup]time.Duration{
+			core.Group1: initial,
+			core.Group2: initial,
+		},
+		running: core.GroupNone,
+		now:     time.Now,
+	}
+}
+
+// Start 开始为阵营计时，另一方的计时同时停止
+func (c *Clock) Start(group core.ChessmanGroup) {
+	c.Stop()
+	c.running = group
+	c.since = c.now()
+}
+
+// Stop 停止计时
+func (c *Clock) Stop() {
+	if c.running == core.GroupNone {
+		return
+	}
+	c.remaining[c.running] -= c.now().Sub(c.since)
+	c.running = core.GroupNone
+}
+
+// Remaining 获取阵营的剩余时间，超时后为负数
+func (c *Clock) Remaining(group core.ChessmanGroup) time.Duration {
+	r := c.remaining[group]
+	if group == c.running {
+		r -= c.now().Sub(c.since)
+	}
+	return r
+}
+
+// Running 获取正在计时的阵营
+func (c *Clock) Running() core.ChessmanGroup {
+	return c.running
+}
+
+// Expired 查询正在计时的一方是否已经超时
+func (c *Clock) Expired() (core.ChessmanGroup, bool) {
+	if c.running == core.GroupNone {
+		return core.GroupNone, false
+	}
+	if c.Remaining(c.running) > 0 {
+		return core.GroupNone, false
+	}
+	return c.running, true
+}
+
+// GetState 获取棋钟当前的状态
+func (c *Clock) GetState() State {
+	return State{
+		Remaining: map[core.ChessmanGroup]time.Duration{
+			core.Group1: c.Remaining(core.Group1),
+			core.Group2: c.Remaining(core.Group2),
+		},
+		Running: c.running,
+	}
+}
@@ -0,0 +1,159 @@
+package chessclock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 测试用的时间，只有测试修改t时才前进
+type fakeTime struct {
+	t time.Time
+}
+
+func (f *fakeTime) now() time.Time {
+	return f.t
+}
+
+// 棋钟的一个操作：先让时间前进advance，然后开始为start计时，stop为true时改为停止棋钟
+type step struct {
+	advance time.Duration
+	start   core.ChessmanGroup
+	stop    bool
+}
+
+func TestClock(t *testing.T) {
+	const g1, g2 = core.Group1, core.Group2
+	cases := []struct {
+		name      string
+		initial   time.Duration
+		steps     []step
+		advance   time.Duration //所有操作之后时间再前进多少
+		remaining [2]time.Duration
+		running   core.ChessmanGroup
+		expired   bool
+	}{
+		{
+			name:      "没有开始计时",
+			initial:   time.Minute,
+			advance:   time.Hour,
+			remaining: [2]time.Duration{time.Minute, time.Minute},
+			running:   core.GroupNone,
+		},
+		{
+			name:      "只扣正在计时的一方",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}},
+			advance:   10 * time.Second,
+			remaining: [2]time.Duration{50 * time.Second, time.Minute},
+			running:   g1,
+		},
+		{
+			name:      "切换计时的一方",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: 10 * time.Second, start: g2}},
+			advance:   5 * time.Second,
+			remaining: [2]time.Duration{50 * time.Second, 55 * time.Second},
+			running:   g2,
+		},
+		{
+			name:      "停止之后不再扣时",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: 10 * time.Second, stop: true}},
+			advance:   time.Hour,
+			remaining: [2]time.Duration{50 * time.Second, time.Minute},
+			running:   core.GroupNone,
+		},
+		{
+			name:      "来回切换，各自累计用时",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: 10 * time.Second, start: g2}, {advance: 20 * time.Second, start: g1}},
+			remaining: [2]time.Duration{50 * time.Second, 40 * time.Second},
+			running:   g1,
+		},
+		{
+			name:      "重复为同一方计时",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: 10 * time.Second, start: g1}},
+			remaining: [2]time.Duration{50 * time.Second, time.Minute},
+			running:   g1,
+		},
+		{
+			name:      "剩余时间正好为0时超时",
+			initial:   time.Minute,
+			steps:     []step{{start: g2}},
+			advance:   time.Minute,
+			remaining: [2]time.Duration{time.Minute, 0},
+			running:   g2,
+			expired:   true,
+		},
+		{
+			name:      "超时后剩余时间为负数",
+			initial:   time.Minute,
+			steps:     []step{{start: g2}},
+			advance:   70 * time.Second,
+			remaining: [2]time.Duration{time.Minute, -10 * time.Second},
+			running:   g2,
+			expired:   true,
+		},
+		{
+			name:      "还差一点没有超时",
+			initial:   time.Minute,
+			steps:     []step{{start: g2}},
+			advance:   time.Minute - time.Millisecond,
+			remaining: [2]time.Duration{time.Minute, time.Millisecond},
+			running:   g2,
+		},
+		{
+			name:      "超时之后停止，不再报告超时",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: 2 * time.Minute, stop: true}},
+			remaining: [2]time.Duration{-time.Minute, time.Minute},
+			running:   core.GroupNone,
+		},
+		{
+			name:      "在0之前停止",
+			initial:   time.Minute,
+			steps:     []step{{start: g1}, {advance: time.Minute - time.Second, stop: true}},
+			advance:   time.Hour,
+			remaining: [2]time.Duration{time.Second, time.Minute},
+			running:   core.GroupNone,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ft := &fakeTime{t: time.Unix(0, 0)}
+			clock := NewClock(c.initial)
+			clock.now = ft.now
+			for _, s := range c.steps {
+				ft.t = ft.t.Add(s.advance)
+				if s.stop {
+					clock.Stop()
+				} else {
+					clock.Start(s.start)
+				}
+			}
+			ft.t = ft.t.Add(c.advance)
+
+			if r := clock.Remaining(g1); r != c.remaining[0] {
+				t.Errorf("group1 remaining %v, expect %v", r, c.remaining[0])
+			}
+			if r := clock.Remaining(g2); r != c.remaining[1] {
+				t.Errorf("group2 remaining %v, expect %v", r, c.remaining[1])
+			}
+			if clock.Running() != c.running {
+				t.Errorf("running %v, expect %v", clock.Running(), c.running)
+			}
+			group, expired := clock.Expired()
+			if expired != c.expired || expired && group != c.running {
+				t.Errorf("expired %v %v, expect %v", group, expired, c.expired)
+			}
+			state := clock.GetState()
+			if state.Running != c.running || state.Remaining[g1] != c.remaining[0] || state.Remaining[g2] != c.remaining[1] {
+				t.Errorf("unexpected state %+v", state)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package chessgame
 
 import (
+	"errors"
 	"fmt"
 	"github.com/CXeon/xiangqi/core"
 	"github.com/CXeon/xiangqi/core/chessboard"
 	"github.com/CXeon/xiangqi/core/chessman"
 	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"sync"
 )
 
 type ChessGame struct {
@@ -13,13 +16,27 @@ type ChessGame struct {
 	playerUp       player.PlayerInterface         //玩家2号
 	board          chessboard.ChessboardInterface //棋盘
 	nextRoundGroup core.ChessmanGroup             //下一回合应该哪个阵营下棋
+	history        []player.Statement             //已经生效的下棋记录
 
 	quit chan struct{} //退出通道，用于随时终止棋局
+
+	//观战相关
+	spectatorMu     sync.Mutex
+	spectators      map[*Spectator]struct{}
+	records         []SpectatorMsg    //棋局产生的所有消息，用于新加入的观战者
+	initialPosition position.Position //棋局开始时的局面
+	spectatorsEnded bool              //棋局已经关闭
 }
 
 // 初始化棋局
 func (game *ChessGame) InitialGame(player1, player2 player.PlayerInterface) error {
 
+	//双方都没有设置坐在下方时，player1坐在下方。座位要记在玩家身上，
+	//否则ResetGame按照玩家的座位重新划分棋盘时会和这里不一致，再来一局时棋盘会翻转
+	if !player1.GetIsDown() && !player2.GetIsDown() {
+		player1.SetIsDown(true)
+	}
+
 	//引入玩家
 	if player1.GetIsDown() {
 		game.playerDown = player1
@@ -58,6 +75,7 @@ func (game *ChessGame) InitialGame(player1, player2 player.PlayerInterface) erro
 		game.nextRoundGroup = game.playerUp.GetGroup()
 	}
 
+	game.history = make([]player.Statement, 0)
 	game.quit = make(chan struct{}, 1) //初始化终止通道
 
 	//记录玩家初始拥有的棋子
@@ -73,6 +91,8 @@ func (game *ChessGame) InitialGame(player1, player2 player.PlayerInterface) erro
 	}
 	game.playerUp.AddOwnChessmen(codes2)
 
+	game.resetSpectatorRecords()
+
 	return nil
 }
 
@@ -122,6 +142,9 @@ func (game *ChessGame) ResetGame() error {
 	}
 	game.playerUp.AddOwnChessmen(codes2)
 
+	//清空下棋记录
+	game.history = make([]player.Statement, 0)
+
 	//重置回合标记
 	if game.playerDown.GetIsFirst() {
 		game.nextRoundGroup = game.playerDown.GetGroup()
@@ -129,11 +152,15 @@ func (game *ChessGame) ResetGame() error {
 		game.nextRoundGroup = game.playerUp.GetGroup()
 	}
 
+	game.resetSpectatorRecords()
+
 	return nil
 }
 
 // 关闭棋局
 func (game *ChessGame) Close() error {
+	game.endSpectators()
+
 	game.playerDown.ClearOwnChessman()
 	game.playerDown.ClearLostChessman()
 	game.playerDown.ClearWonChessman()
@@ -157,152 +184,216 @@ func (game *ChessGame) Run(downPlayerCh, upPlayerCh chan player.Statement) (msgC
 	go func() {
 		defer close(msgChan)
 		for {
-			switch game.nextRoundGroup {
-			case core.Group1:
-				var pl player.PlayerInterface
-				if game.playerDown.GetGroup() == core.Group1 {
-					pl = game.playerDown
-				} else {
-					pl = game.playerUp
-				}
-				//棋局开始或者后手已经下完棋，先手下棋
-				st, err := pl.ReceiveStatement(downPlayerCh, game.quit)
-				if err != nil {
-
-					msgChan <- GameMsg{
-						Event:           Err,
-						WonChessmanCode: "",
-						WonGroup:        core.GroupNone,
-						Msg:             err.Error(),
-					}
-					return
-				}
-				//移动棋子
-				wonCode, err := game.board.MoveChessman(st.Group, st.Code, st.Source, st.Target)
-				if err != nil {
-
-					msgChan <- GameMsg{
-						Event:           Err,
-						WonChessmanCode: "",
-						WonGroup:        core.GroupNone,
-						Msg:             err.Error(),
-					}
-					continue
-				}
-
-				game.nextRoundGroup = core.Group2 //修改下一回合下棋阵营
-
-				//判定吃的棋子是否将军，是的话就赢了
-				if wonCode == core.JiangShuai {
-					msgChan <- GameMsg{
-						Event:           Fin,
-						WonChessmanCode: wonCode,
-						WonGroup:        game.playerDown.GetGroup(),
-						Msg:             "Win",
-					}
-					return
-				}
-
-				//检查两个阵营的将帅是否见面了，见面了判移动的这一方输
-				if game.JiangShuaiFace2Face() {
-					msgChan <- GameMsg{
-						Event:           Fin,
-						WonChessmanCode: wonCode,
-						WonGroup:        game.playerUp.GetGroup(),
-						Msg:             "Win",
-					}
-					return
-				}
-
-				//还没觉出胜负移动完毕，向外部发送消息
-				if len(wonCode) > 0 {
-					game.playerDown.AddWonChessman(wonCode)
-					game.playerUp.DelOwnChessman(wonCode)
-					game.playerUp.AddLostChessman(wonCode)
-				}
-
-				msgChan <- GameMsg{
-					Event:           Done,
-					WonChessmanCode: wonCode,
-					WonGroup:        core.GroupNone,
-					Msg:             "moveDone",
-				}
-			case core.Group2:
-				var pl player.PlayerInterface
-				if game.playerDown.GetGroup() == core.Group2 {
-					pl = game.playerDown
-				} else {
-					pl = game.playerUp
-				}
-				st, err := pl.ReceiveStatement(upPlayerCh, game.quit)
-				if err != nil {
-					gameMsg := GameMsg{
-						Event:           Err,
-						WonChessmanCode: "",
-						WonGroup:        core.GroupNone,
-						Msg:             err.Error(),
-					}
-					msgChan <- gameMsg
-					return
-				}
-				//移动棋子
-				wonCode, err := game.board.MoveChessman(st.Group, st.Code, st.Source, st.Target)
-				if err != nil {
-					msgChan <- GameMsg{
-						Event:           Err,
-						WonChessmanCode: "",
-						WonGroup:        core.GroupNone,
-						Msg:             err.Error(),
-					}
-
-					continue
-				}
-
-				game.nextRoundGroup = core.Group1 //棋子移动完毕先手已经执行
-
-				//判定吃的棋子是否将军，是的话就赢了
-				if wonCode == core.JiangShuai {
-					msgChan <- GameMsg{
-						Event:           Fin,
-						WonChessmanCode: wonCode,
-						WonGroup:        game.playerUp.GetGroup(),
-						Msg:             "Win",
-					}
-					return
-				}
-
-				//检查两个阵营的将帅是否见面了，见面了判移动的这一方输
-				if game.JiangShuaiFace2Face() {
-					msgChan <- GameMsg{
-						Event:           Fin,
-						WonChessmanCode: wonCode,
-						WonGroup:        game.playerDown.GetGroup(),
-						Msg:             "Win",
-					}
-					return
-				}
-
-				//还没觉出胜负移动完毕，向外部发送消息
-				if len(wonCode) > 0 {
-					game.playerUp.AddWonChessman(wonCode)
-					game.playerDown.DelOwnChessman(wonCode)
-					game.playerDown.AddLostChessman(wonCode)
-				}
-
-				msgChan <- GameMsg{
-					Event:           Done,
-					WonChessmanCode: wonCode,
-					WonGroup:        core.GroupNone,
-					Msg:             "moveDone",
-				}
+			//阵营1的意图从第一个通道读取，阵营2的意图从第二个通道读取
+			ch := downPlayerCh
+			if game.nextRoundGroup == core.Group2 {
+				ch = upPlayerCh
+			}
+			if !game.playRound(ch, msgChan) {
+				return
 			}
-
 		}
 
 	}()
 	return msgChan
 }
 
+// 进行一个回合：接收当前阵营玩家的意图，移动棋子并判定胜负。返回false表示棋局已经结束
+func (game *ChessGame) playRound(ch chan player.Statement, msgChan chan GameMsg) bool {
+	mover, opponent := game.playerDown, game.playerUp
+	if mover.GetGroup() != game.nextRoundGroup {
+		mover, opponent = opponent, mover
+	}
+
+	st, err := mover.ReceiveStatement(ch, game.quit)
+	if err != nil {
+		reason := RejectNoStatement
+		if errors.Is(err, player.ErrQuit) || errors.Is(err, player.ErrClosed) {
+			reason = RejectClosed
+		}
+		game.emit(msgChan, NewRejected(reason, err.Error()), st)
+		return false
+	}
+	if st.Undo {
+		game.undo(msgChan)
+		return true
+	}
+	//移动棋子
+	wonCode, err := game.board.MoveChessman(st.Group, st.Code, st.Source, st.Target)
+	if err != nil {
+		game.emit(msgChan, NewRejected(rejectReasonOf(err), err.Error()), st)
+		return true
+	}
+
+	game.nextRoundGroup = opponent.GetGroup() //修改下一回合下棋阵营
+	game.history = append(game.history, st)
+	move := &MoveApplied{
+		Group:    st.Group,
+		Piece:    st.Code,
+		From:     st.Source,
+		To:       st.Target,
+		Captured: wonCode,
+	}
+
+	//判定吃的棋子是否将军，是的话就赢了
+	if wonCode == core.JiangShuai {
+		msg := NewGameOver(mover.GetGroup(), TerminationCapture)
+		msg.Move = move
+		game.emit(msgChan, msg, st)
+		return false
+	}
+
+	//检查两个阵营的将帅是否见面了，见面了判移动的这一方输
+	if game.JiangShuaiFace2Face() {
+		msg := NewGameOver(opponent.GetGroup(), TerminationFaceToFace)
+		msg.Move = move
+		game.emit(msgChan, msg, st)
+		return false
+	}
+
+	//还没决出胜负移动完毕，向外部发送消息
+	if len(wonCode) > 0 {
+		mover.AddWonChessman(wonCode)
+		opponent.DelOwnChessman(wonCode)
+		opponent.AddLostChessman(wonCode)
+	}
+
+	msg := GameMsg{Event: Done, Move: move}
+	if game.board.IsInCheck(opponent.GetGroup()) {
+		msg.Check = &Check{Group: opponent.GetGroup()}
+	}
+	game.emit(msgChan, msg, st)
+	return true
+}
+
+// 棋盘拒绝移动棋子的原因
+func rejectReasonOf(err error) RejectReason {
+	switch {
+	case errors.Is(err, chessman.ErrOwnPiece):
+		return RejectOwnPiece
+	case errors.Is(err, chessboard.ErrNoChessman):
+		return RejectNoChessman
+	}
+	return RejectInvalidMove
+}
+
+// 向外部发送消息，同时推送给观战者
+func (game *ChessGame) emit(msgChan chan GameMsg, msg GameMsg, st player.Statement) {
+	game.publish(msg, st)
+	msgChan <- msg
+}
+
+// 获取下一回合应该下棋的阵营
+func (game *ChessGame) GetNextRoundGroup() core.ChessmanGroup {
+	return game.nextRoundGroup
+}
+
+// 获取已经生效的下棋记录
+func (game *ChessGame) GetHistory() []player.Statement {
+	history := make([]player.Statement, len(game.history))
+	copy(history, game.history)
+	return history
+}
+
+// GetLegalStatements 获取阵营当前所有可以走的下棋意图，由各个棋子的走法规则计算，不包括走完之后将帅见面的移动
+func (game *ChessGame) GetLegalStatements(group core.ChessmanGroup) []player.Statement {
+	moves := game.board.LegalMoves(group)
+	statements := make([]player.Statement, len(moves))
+	for i, m := range moves {
+		statements[i] = player.Statement{
+			Group:  m.Group,
+			Code:   m.Code,
+			Source: m.Source,
+			Target: m.Target,
+		}
+	}
+	return statements
+}
+
+// GetLegalTargets 获取source坐标上的棋子当前可以到达的所有坐标，不包括走完之后将帅见面的目标
+func (game *ChessGame) GetLegalTargets(source core.Coordinate) []core.Coordinate {
+	targets := make([]core.Coordinate, 0)
+	cm := game.board.GetMatrix()[source.Y][source.X]
+	if cm == nil {
+		return targets
+	}
+	for _, m := range game.board.LegalMoves(cm.GetChessmanGroup()) {
+		if m.Source == source {
+			targets = append(targets, m.Target)
+		}
+	}
+	return targets
+}
+
+// IsInCheck 阵营的将帅是否被对方将军
+func (game *ChessGame) IsInCheck(group core.ChessmanGroup) bool {
+	return game.board.IsInCheck(group)
+}
+
+// 获取红方视角的当前局面，先手方执红棋
+func (game *ChessGame) GetPosition() position.Position {
+	red := game.playerDown
+	if !red.GetIsFirst() {
+		red = game.playerUp
+	}
+	return position.FromMatrix(game.board.GetMatrix(), red.GetGroup(), game.GetRedIsDown(), game.nextRoundGroup == red.GetGroup())
+}
+
+// GetInitialPosition 获取棋局开始时红方视角的局面，SetPosition之后为设置的局面
+func (game *ChessGame) GetInitialPosition() position.Position {
+	game.spectatorMu.Lock()
+	defer game.spectatorMu.Unlock()
+	return game.initialPosition
+}
+
+// ParseMove 将红方视角的着法转换成当前局面下的下棋意图，起始位置必须有棋子
+func (game *ChessGame) ParseMove(m position.Move) (player.Statement, error) {
+	redIsDown := game.GetRedIsDown()
+	source := position.ToCoordinate(m.From, redIsDown)
+	cm := game.board.GetMatrix()[source.Y][source.X]
+	if cm == nil {
+		return player.Statement{}, fmt.Errorf("no chessman at %s", m.From)
+	}
+	return player.Statement{
+		Group:  cm.GetChessmanGroup(),
+		Code:   cm.GetChessmanCode(),
+		Source: source,
+		Target: position.ToCoordinate(m.To, redIsDown),
+	}, nil
+}
+
+// FormatMove 将下棋意图转换成红方视角的着法
+func (game *ChessGame) FormatMove(st player.Statement) position.Move {
+	redIsDown := game.GetRedIsDown()
+	return position.Move{
+		From: position.ToSquare(st.Source, redIsDown),
+		To:   position.ToSquare(st.Target, redIsDown),
+	}
+}
+
+// FormatChineseMove 将下棋意图转换成中文记谱，例如炮二平五，必须在下棋意图生效之前调用
+func (game *ChessGame) FormatChineseMove(st player.Statement) string {
+	pos := game.GetPosition()
+	return pos.ChineseMove(game.FormatMove(st))
+}
+
+// ParseChineseMove 将中文记谱解析成下一回合阵营的下棋意图，接受繁体字以及中文数字和阿拉伯数字混用的写法
+func (game *ChessGame) ParseChineseMove(s string) (player.Statement, error) {
+	want := position.NormalizeChinese(s)
+	pos := game.GetPosition()
+	for _, st := range game.GetLegalStatements(game.nextRoundGroup) {
+		if position.NormalizeChinese(pos.ChineseMove(game.FormatMove(st))) == want {
+			return st, nil
+		}
+	}
+	return player.Statement{}, fmt.Errorf("invalid move %q", s)
+}
+
+// GetRedIsDown 获取红方（先手方）是否位于棋盘下方，用于核心层坐标和红方视角位置的转换
+func (game *ChessGame) GetRedIsDown() bool {
+	return game.playerDown.GetIsFirst()
+}
+
 func (game *ChessGame) Show() {
 	matrix := game.board.GetMatrix()
 
@@ -326,114 +417,117 @@ func (game *ChessGame) Show() {
 
 func (game *ChessGame) newChessmen(downGroup, upGroup core.ChessmanGroup) (chessmenOfPlayerDown, chessmenOfPlayerUp []chessman.ChessmanInterface) {
 
+	//下方的玩家先手时执红
+	downRed := game.playerDown.GetIsFirst()
+
 	chessmenOfPlayerDown = make([]chessman.ChessmanInterface, 16)
 
-	ju1 := chessman.NewChessman(core.Ju, "车", downGroup, core.Coordinate{
+	ju1 := chessman.NewChessman(core.Ju, chessmanName(core.Ju, downRed), downGroup, core.Coordinate{
 		X: 0,
 		Y: 0,
 	})
 	ju1.BindRule(chessman.RuleJu)
 	chessmenOfPlayerDown[0] = ju1
 
-	ju2 := chessman.NewChessman(core.Ju, "车", downGroup, core.Coordinate{
+	ju2 := chessman.NewChessman(core.Ju, chessmanName(core.Ju, downRed), downGroup, core.Coordinate{
 		X: 8,
 		Y: 0,
 	})
 	ju2.BindRule(chessman.RuleJu)
 	chessmenOfPlayerDown[1] = ju2
 
-	ma1 := chessman.NewChessman(core.Ma, "马", downGroup, core.Coordinate{
+	ma1 := chessman.NewChessman(core.Ma, chessmanName(core.Ma, downRed), downGroup, core.Coordinate{
 		X: 1,
 		Y: 0,
 	})
 	ma1.BindRule(chessman.RuleMa)
 	chessmenOfPlayerDown[2] = ma1
 
-	ma2 := chessman.NewChessman(core.Ma, "马", downGroup, core.Coordinate{
+	ma2 := chessman.NewChessman(core.Ma, chessmanName(core.Ma, downRed), downGroup, core.Coordinate{
 		X: 7,
 		Y: 0,
 	})
 	ma2.BindRule(chessman.RuleMa)
 	chessmenOfPlayerDown[3] = ma2
 
-	xiang1 := chessman.NewChessman(core.Xiang, "象", downGroup, core.Coordinate{
+	xiang1 := chessman.NewChessman(core.Xiang, chessmanName(core.Xiang, downRed), downGroup, core.Coordinate{
 		X: 2,
 		Y: 0,
 	})
 	xiang1.BindRule(chessman.RuleXiang)
 	chessmenOfPlayerDown[4] = xiang1
 
-	xiang2 := chessman.NewChessman(core.Xiang, "象", downGroup, core.Coordinate{
+	xiang2 := chessman.NewChessman(core.Xiang, chessmanName(core.Xiang, downRed), downGroup, core.Coordinate{
 		X: 6,
 		Y: 0,
 	})
 	xiang2.BindRule(chessman.RuleXiang)
 	chessmenOfPlayerDown[5] = xiang2
 
-	shi1 := chessman.NewChessman(core.Shi, "士", downGroup, core.Coordinate{
+	shi1 := chessman.NewChessman(core.Shi, chessmanName(core.Shi, downRed), downGroup, core.Coordinate{
 		X: 3,
 		Y: 0,
 	})
 	shi1.BindRule(chessman.RuleShi)
 	chessmenOfPlayerDown[6] = shi1
 
-	shi2 := chessman.NewChessman(core.Shi, "士", downGroup, core.Coordinate{
+	shi2 := chessman.NewChessman(core.Shi, chessmanName(core.Shi, downRed), downGroup, core.Coordinate{
 		X: 5,
 		Y: 0,
 	})
 	shi2.BindRule(chessman.RuleShi)
 	chessmenOfPlayerDown[7] = shi2
 
-	jiangShuai1 := chessman.NewChessman(core.JiangShuai, "帅", downGroup, core.Coordinate{
+	jiangShuai1 := chessman.NewChessman(core.JiangShuai, chessmanName(core.JiangShuai, downRed), downGroup, core.Coordinate{
 		X: 4,
 		Y: 0,
 	})
 	jiangShuai1.BindRule(chessman.RuleJiangShuai)
 	chessmenOfPlayerDown[8] = jiangShuai1
 
-	pao1 := chessman.NewChessman(core.Pao, "炮", downGroup, core.Coordinate{
+	pao1 := chessman.NewChessman(core.Pao, chessmanName(core.Pao, downRed), downGroup, core.Coordinate{
 		X: 1,
 		Y: 2,
 	})
 	pao1.BindRule(chessman.RulePao)
 	chessmenOfPlayerDown[9] = pao1
 
-	pao2 := chessman.NewChessman(core.Pao, "炮", downGroup, core.Coordinate{
+	pao2 := chessman.NewChessman(core.Pao, chessmanName(core.Pao, downRed), downGroup, core.Coordinate{
 		X: 7,
 		Y: 2,
 	})
 	pao2.BindRule(chessman.RulePao)
 	chessmenOfPlayerDown[10] = pao2
 
-	bingzu1 := chessman.NewChessman(core.BingZu, "兵", downGroup, core.Coordinate{
+	bingzu1 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, downRed), downGroup, core.Coordinate{
 		X: 0,
 		Y: 3,
 	})
 	bingzu1.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerDown[11] = bingzu1
 
-	bingzu2 := chessman.NewChessman(core.BingZu, "兵", downGroup, core.Coordinate{
+	bingzu2 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, downRed), downGroup, core.Coordinate{
 		X: 2,
 		Y: 3,
 	})
 	bingzu2.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerDown[12] = bingzu2
 
-	bingzu3 := chessman.NewChessman(core.BingZu, "兵", downGroup, core.Coordinate{
+	bingzu3 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, downRed), downGroup, core.Coordinate{
 		X: 4,
 		Y: 3,
 	})
 	bingzu3.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerDown[13] = bingzu3
 
-	bingzu4 := chessman.NewChessman(core.BingZu, "兵", downGroup, core.Coordinate{
+	bingzu4 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, downRed), downGroup, core.Coordinate{
 		X: 6,
 		Y: 3,
 	})
 	bingzu4.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerDown[14] = bingzu4
 
-	bingzu5 := chessman.NewChessman(core.BingZu, "兵", downGroup, core.Coordinate{
+	bingzu5 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, downRed), downGroup, core.Coordinate{
 		X: 8,
 		Y: 3,
 	})
@@ -442,112 +536,112 @@ func (game *ChessGame) newChessmen(downGroup, upGroup core.ChessmanGroup) (chess
 
 	chessmenOfPlayerUp = make([]chessman.ChessmanInterface, 16)
 
-	ju3 := chessman.NewChessman(core.Ju, "车", upGroup, core.Coordinate{
+	ju3 := chessman.NewChessman(core.Ju, chessmanName(core.Ju, !downRed), upGroup, core.Coordinate{
 		X: 0,
 		Y: 9,
 	})
 	ju3.BindRule(chessman.RuleJu)
 	chessmenOfPlayerUp[0] = ju3
 
-	ju4 := chessman.NewChessman(core.Ju, "车", upGroup, core.Coordinate{
+	ju4 := chessman.NewChessman(core.Ju, chessmanName(core.Ju, !downRed), upGroup, core.Coordinate{
 		X: 8,
 		Y: 9,
 	})
 	ju4.BindRule(chessman.RuleJu)
 	chessmenOfPlayerUp[1] = ju4
 
-	ma3 := chessman.NewChessman(core.Ma, "马", upGroup, core.Coordinate{
+	ma3 := chessman.NewChessman(core.Ma, chessmanName(core.Ma, !downRed), upGroup, core.Coordinate{
 		X: 1,
 		Y: 9,
 	})
 	ma3.BindRule(chessman.RuleMa)
 	chessmenOfPlayerUp[2] = ma3
 
-	ma4 := chessman.NewChessman(core.Ma, "马", upGroup, core.Coordinate{
+	ma4 := chessman.NewChessman(core.Ma, chessmanName(core.Ma, !downRed), upGroup, core.Coordinate{
 		X: 7,
 		Y: 9,
 	})
 	ma4.BindRule(chessman.RuleMa)
 	chessmenOfPlayerUp[3] = ma4
 
-	xiang3 := chessman.NewChessman(core.Xiang, "象", upGroup, core.Coordinate{
+	xiang3 := chessman.NewChessman(core.Xiang, chessmanName(core.Xiang, !downRed), upGroup, core.Coordinate{
 		X: 2,
 		Y: 9,
 	})
 	xiang3.BindRule(chessman.RuleXiang)
 	chessmenOfPlayerUp[4] = xiang3
 
-	xiang4 := chessman.NewChessman(core.Xiang, "象", upGroup, core.Coordinate{
+	xiang4 := chessman.NewChessman(core.Xiang, chessmanName(core.Xiang, !downRed), upGroup, core.Coordinate{
 		X: 6,
 		Y: 9,
 	})
 	xiang4.BindRule(chessman.RuleXiang)
 	chessmenOfPlayerUp[5] = xiang4
 
-	shi3 := chessman.NewChessman(core.Shi, "士", upGroup, core.Coordinate{
+	shi3 := chessman.NewChessman(core.Shi, chessmanName(core.Shi, !downRed), upGroup, core.Coordinate{
 		X: 3,
 		Y: 9,
 	})
 	shi3.BindRule(chessman.RuleShi)
 	chessmenOfPlayerUp[6] = shi3
 
-	shi4 := chessman.NewChessman(core.Shi, "士", upGroup, core.Coordinate{
+	shi4 := chessman.NewChessman(core.Shi, chessmanName(core.Shi, !downRed), upGroup, core.Coordinate{
 		X: 5,
 		Y: 9,
 	})
 	shi4.BindRule(chessman.RuleShi)
 	chessmenOfPlayerUp[7] = shi4
 
-	jiangShuai2 := chessman.NewChessman(core.JiangShuai, "将", upGroup, core.Coordinate{
+	jiangShuai2 := chessman.NewChessman(core.JiangShuai, chessmanName(core.JiangShuai, !downRed), upGroup, core.Coordinate{
 		X: 4,
 		Y: 9,
 	})
 	jiangShuai2.BindRule(chessman.RuleJiangShuai)
 	chessmenOfPlayerUp[8] = jiangShuai2
 
-	pao3 := chessman.NewChessman(core.Pao, "炮", upGroup, core.Coordinate{
+	pao3 := chessman.NewChessman(core.Pao, chessmanName(core.Pao, !downRed), upGroup, core.Coordinate{
 		X: 1,
 		Y: 7,
 	})
 	pao3.BindRule(chessman.RulePao)
 	chessmenOfPlayerUp[9] = pao3
 
-	pao4 := chessman.NewChessman(core.Pao, "炮", upGroup, core.Coordinate{
+	pao4 := chessman.NewChessman(core.Pao, chessmanName(core.Pao, !downRed), upGroup, core.Coordinate{
 		X: 7,
 		Y: 7,
 	})
 	pao4.BindRule(chessman.RulePao)
 	chessmenOfPlayerUp[10] = pao4
 
-	bingzu6 := chessman.NewChessman(core.BingZu, "卒", upGroup, core.Coordinate{
+	bingzu6 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, !downRed), upGroup, core.Coordinate{
 		X: 0,
 		Y: 6,
 	})
 	bingzu6.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerUp[11] = bingzu6
 
-	bingzu7 := chessman.NewChessman(core.BingZu, "卒", upGroup, core.Coordinate{
+	bingzu7 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, !downRed), upGroup, core.Coordinate{
 		X: 2,
 		Y: 6,
 	})
 	bingzu7.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerUp[12] = bingzu7
 
-	bingzu8 := chessman.NewChessman(core.BingZu, "卒", upGroup, core.Coordinate{
+	bingzu8 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, !downRed), upGroup, core.Coordinate{
 		X: 4,
 		Y: 6,
 	})
 	bingzu8.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerUp[13] = bingzu8
 
-	bingzu9 := chessman.NewChessman(core.BingZu, "卒", upGroup, core.Coordinate{
+	bingzu9 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, !downRed), upGroup, core.Coordinate{
 		X: 6,
 		Y: 6,
 	})
 	bingzu9.BindRule(chessman.RuleBingZu)
 	chessmenOfPlayerUp[14] = bingzu9
 
-	bingzu10 := chessman.NewChessman(core.BingZu, "卒", upGroup, core.Coordinate{
+	bingzu10 := chessman.NewChessman(core.BingZu, chessmanName(core.BingZu, !downRed), upGroup, core.Coordinate{
 		X: 8,
 		Y: 6,
 	})
@@ -557,48 +651,7 @@ func (game *ChessGame) newChessmen(downGroup, upGroup core.ChessmanGroup) (chess
 	return chessmenOfPlayerDown, chessmenOfPlayerUp
 }
 
+// 检查两个阵营的将帅是否见面
 func (game *ChessGame) JiangShuaiFace2Face() bool {
-	//定位先手方“将/帅”当前位置
-	matrix := game.board.GetMatrix()
-	downJiangShuaiCoordinate := core.Coordinate{}
-	for i := 0; i < 3; i++ {
-		row := matrix[i]
-		for j, r := range row {
-			if r == nil {
-				continue
-			}
-			if r.GetChessmanCode() == core.JiangShuai {
-				downJiangShuaiCoordinate.X = j
-				downJiangShuaiCoordinate.Y = i
-			}
-		}
-	}
-
-	//定位后手方“将/帅”当前位置
-	upJiangShuaiCoordinate := core.Coordinate{}
-	for i := 7; i < 10; i++ {
-		row := matrix[i]
-		for j, r := range row {
-			if r == nil {
-				continue
-			}
-			if r.GetChessmanCode() == core.JiangShuai {
-				upJiangShuaiCoordinate.X = j
-				upJiangShuaiCoordinate.Y = i
-			}
-		}
-	}
-
-	//判定两个棋子横坐标是否相同
-	if downJiangShuaiCoordinate.X != upJiangShuaiCoordinate.X {
-		return false
-	}
-	//横坐标相同判定棋子中间是否已经没有棋子
-	for i := downJiangShuaiCoordinate.Y + 1; i < upJiangShuaiCoordinate.Y; i++ {
-		if matrix[i][downJiangShuaiCoordinate.X] != nil {
-			return false
-		}
-	}
-
-	return true
+	return chessboard.JiangShuaiFace2Face(game.board.GetMatrix())
 }
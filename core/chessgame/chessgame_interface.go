@@ -1,6 +1,12 @@
 package chessgame
 
-import "github.com/CXeon/xiangqi/core/player"
+import (
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
 
 type ChessGameInterface interface {
 
@@ -14,9 +20,54 @@ type ChessGameInterface interface {
 	//关闭棋局
 	Close() error
 
-	//运行棋局
+	//运行棋局，玩家发送悔棋意图时撤销最近一步棋
 	Run(downPlayerCh, upPlayerCh chan player.Statement) (msgChan chan GameMsg)
 
+	//获取下一回合应该下棋的阵营
+	GetNextRoundGroup() core.ChessmanGroup
+
+	//获取已经生效的下棋记录
+	GetHistory() []player.Statement
+
+	//获取阵营当前所有可以走的下棋意图
+	GetLegalStatements(group core.ChessmanGroup) []player.Statement
+
+	//获取某个坐标上的棋子当前可以到达的所有坐标
+	GetLegalTargets(source core.Coordinate) []core.Coordinate
+
+	//获取阵营的将帅是否被将军
+	IsInCheck(group core.ChessmanGroup) bool
+
+	//获取红方视角的当前局面
+	GetPosition() position.Position
+
+	//获取棋局开始时红方视角的局面
+	GetInitialPosition() position.Position
+
+	//获取红方是否位于棋盘下方
+	GetRedIsDown() bool
+
+	//将棋局设置为指定的局面，必须在运行之前调用
+	SetPosition(pos position.Position) error
+
+	//将红方视角的着法转换成下棋意图
+	ParseMove(m position.Move) (player.Statement, error)
+
+	//将下棋意图转换成红方视角的着法
+	FormatMove(st player.Statement) position.Move
+
+	//将下棋意图转换成中文记谱
+	FormatChineseMove(st player.Statement) string
+
+	//将中文记谱解析成下一回合阵营的下棋意图
+	ParseChineseMove(s string) (player.Statement, error)
+
+	//加入观战，消息延迟delay之后推送给观战者
+	Spectate(delay time.Duration) *Spectator
+
+	//把内核之外产生的消息（例如超时、弃局）推送给观战者
+	Publish(msg GameMsg)
+
 	//打印棋局
 	Show()
 }
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/CXeon/xiangqi/core"
 	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
 	"testing"
+	"time"
 )
 
 func TestChessGame(t *testing.T) {
@@ -99,3 +101,462 @@ func TestChessGame(t *testing.T) {
 
 	return
 }
+
+// 双方都没有设置座位时player1坐在下方，再来一局棋盘不会翻转
+func TestDefaultSeat(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	if !p1.GetIsDown() || !game.GetRedIsDown() {
+		t.Fatal("player1 should sit down")
+	}
+	if err := game.ResetGame(); err != nil {
+		t.Fatal(err)
+	}
+	if !game.GetRedIsDown() || game.GetPosition().FEN() != position.InitialFEN {
+		t.Fatalf("board should not flip after reset, got %s", game.GetPosition().FEN())
+	}
+}
+
+func TestSpectate(t *testing.T) {
+	var p1, p2 player.PlayerInterface
+	p1 = player.NewPlayer()
+	p2 = player.NewPlayer()
+
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p2.SetGroup(core.Group2)
+
+	var chP1 = make(chan player.Statement, 1)
+	var chP2 = make(chan player.Statement, 1)
+	chessGame := new(ChessGame)
+	err := chessGame.InitialGame(p1, p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgChan := chessGame.Run(chP1, chP2)
+
+	//没有延迟的观战者
+	spectator := chessGame.Spectate(0)
+
+	//被拒绝的下棋意图不推送给观战者
+	chP1 <- player.Statement{
+		Group:  core.Group1,
+		Code:   core.Ju,
+		Source: core.Coordinate{X: 0, Y: 0},
+		Target: core.Coordinate{X: 1, Y: 1},
+	}
+	if msg := <-msgChan; msg.Event != Err {
+		t.Fatalf("expect err, got %+v", msg)
+	}
+
+	//先手“炮二平五”
+	st := player.Statement{
+		Group:  core.Group1,
+		Code:   core.Pao,
+		Source: core.Coordinate{X: 1, Y: 2},
+		Target: core.Coordinate{X: 4, Y: 2},
+	}
+	chP1 <- st
+	<-msgChan
+
+	msg := <-spectator.Messages()
+	if msg.GameMsg.Event != Done || msg.Statement != st {
+		t.Fatalf("unexpected spectator msg %+v", msg)
+	}
+	if msg.Position.RedToMove {
+		t.Fatal("black should move next")
+	}
+
+	//有延迟的观战者加入时只能看到延迟之前的局面
+	delayed := chessGame.Spectate(time.Hour)
+	if len(delayed.History) != 0 || delayed.Position.FEN() != position.InitialFEN {
+		t.Fatalf("delayed spectator should not see the last move, got %s", delayed.Position.FEN())
+	}
+	delayed.Close()
+
+	late := chessGame.Spectate(0)
+	if len(late.History) != 1 {
+		t.Fatalf("expect 1 move in history, got %d", len(late.History))
+	}
+
+	//棋局关闭后观战通道关闭
+	chessGame.Close()
+	for range late.Messages() {
+	}
+}
+
+func TestGetLegalStatements(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+
+	//开局时双方各有44种走法
+	if n := len(game.GetLegalStatements(core.Group1)); n != 44 {
+		t.Fatalf("expect 44 moves for group1, got %d", n)
+	}
+	if n := len(game.GetLegalStatements(core.Group2)); n != 44 {
+		t.Fatalf("expect 44 moves for group2, got %d", n)
+	}
+	for _, st := range game.GetLegalStatements(core.Group1) {
+		if st.Group != core.Group1 {
+			t.Fatalf("unexpected statement %+v", st)
+		}
+	}
+}
+
+func TestSetPosition(t *testing.T) {
+	//先手方位于棋盘上方
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p2.SetGroup(core.Group2)
+	p2.SetIsDown(true)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.SetPosition(position.Position{}); err == nil {
+		t.Fatal("position without jiangshuai should be rejected")
+	}
+
+	fen := "3k5/9/9/9/9/9/9/9/4R4/4K4 b"
+	pos, _ := position.ParseFEN(fen)
+	if err := game.SetPosition(pos); err != nil {
+		t.Fatal(err)
+	}
+	if got := game.GetPosition().FEN(); got != fen {
+		t.Fatalf("expect %s, got %s", fen, got)
+	}
+	if game.GetNextRoundGroup() != core.Group2 {
+		t.Fatal("black should move first")
+	}
+
+	m, _ := position.ParseMove("e1e9")
+	st, err := game.ParseMove(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Group != core.Group1 || st.Code != core.Ju || game.FormatMove(st) != m {
+		t.Fatalf("unexpected statement %+v", st)
+	}
+	if _, err = game.ParseMove(position.Move{From: position.Square{File: 0, Rank: 0}}); err == nil {
+		t.Fatal("parse move from an empty square should fail")
+	}
+
+	//将在九宫的角上只有两种走法
+	moves := game.GetLegalStatements(core.Group2)
+	if len(moves) != 2 {
+		t.Fatalf("expect 2 moves, got %d", len(moves))
+	}
+}
+
+func TestChineseMove(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	st, err := game.ParseChineseMove("炮二平五")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := game.FormatMove(st).String(); got != "h2e2" {
+		t.Fatalf("expect h2e2, got %s", got)
+	}
+	if got := game.FormatChineseMove(st); got != "炮二平五" {
+		t.Fatalf("expect 炮二平五, got %s", got)
+	}
+	//繁体字和阿拉伯数字按照当前走棋方解析
+	if st, err = game.ParseChineseMove("馬8進7"); err != nil || game.FormatMove(st).String() != "b0c2" {
+		t.Fatalf("expect b0c2, got %v %v", game.FormatMove(st), err)
+	}
+	if _, err = game.ParseChineseMove("炮二进九"); err == nil {
+		t.Fatal("invalid move should be rejected")
+	}
+
+	//黑方用阿拉伯数字，纵线从黑方的右手边开始
+	pos, _ := position.ParseFEN("rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C2C4/9/RNBAKABNR b")
+	if err = game.SetPosition(pos); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := position.ParseMove("h9g7")
+	if st, _ = game.ParseMove(m); game.FormatChineseMove(st) != "马8进7" {
+		t.Fatalf("expect 马8进7, got %s", game.FormatChineseMove(st))
+	}
+
+	//同一纵线上有两个同类棋子时用前后区分
+	pos, _ = position.ParseFEN("3k5/9/9/9/9/4R4/9/9/4R4/5K3 w")
+	if err = game.SetPosition(pos); err != nil {
+		t.Fatal(err)
+	}
+	st, err = game.ParseChineseMove("后车平4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := game.FormatMove(st).String(); got != "e1f1" {
+		t.Fatalf("expect e1f1, got %s", got)
+	}
+	m, _ = position.ParseMove("e4e8")
+	if st, _ = game.ParseMove(m); game.FormatChineseMove(st) != "前车进四" {
+		t.Fatalf("expect 前车进四, got %s", game.FormatChineseMove(st))
+	}
+}
+
+func TestUndo(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	ch1 := make(chan player.Statement, 1)
+	ch2 := make(chan player.Statement, 1)
+	msgChan := game.Run(ch1, ch2)
+
+	//没有下过棋时不能悔棋
+	ch1 <- player.Statement{Undo: true}
+	if msg := <-msgChan; msg.Event != Err || msg.Rejected.Reason != RejectNothingToUndo {
+		t.Fatalf("expect error, got %+v", msg)
+	}
+
+	//炮二进七吃马，然后悔棋
+	m, _ := position.ParseMove("h2h9")
+	st, _ := game.ParseMove(m)
+	ch1 <- st
+	if msg := <-msgChan; msg.Event != Done || msg.Captured() != core.Ma {
+		t.Fatalf("unexpected msg %+v", msg)
+	}
+	if won, _ := p1.GetWonChessmen(); len(won) != 1 {
+		t.Fatalf("expect 1 won chessman, got %v", won)
+	}
+	ch2 <- player.Statement{Undo: true}
+	if msg := <-msgChan; msg.Event != Undone {
+		t.Fatalf("expect undone, got %+v", msg)
+	}
+	if got := game.GetPosition().FEN(); got != position.InitialFEN {
+		t.Fatalf("expect initial position, got %s", got)
+	}
+	if game.GetNextRoundGroup() != core.Group1 || len(game.GetHistory()) != 0 {
+		t.Fatal("red should move again after undo")
+	}
+	if won, _ := p1.GetWonChessmen(); len(won) != 0 {
+		t.Fatalf("won chessmen should be restored, got %v", won)
+	}
+	if lost, _ := p2.GetLostChessmen(); len(lost) != 0 {
+		t.Fatalf("lost chessmen should be restored, got %v", lost)
+	}
+	game.Close()
+}
+
+// 重新走一遍下棋记录失败时拒绝悔棋，棋局保持悔棋之前的状态
+func TestUndoReplayFailure(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	msgChan := make(chan GameMsg, 1)
+	//炮二进七吃马，车9进2，然后让第一步记录变成起点没有棋子的着法
+	for _, s := range []string{"h2h9", "i9i7"} {
+		m, _ := position.ParseMove(s)
+		st, err := game.ParseMove(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = game.board.MoveChessman(st.Group, st.Code, st.Source, st.Target); err != nil {
+			t.Fatal(err)
+		}
+		game.history = append(game.history, st)
+	}
+	p1.AddWonChessman(core.Ma)
+	p2.DelOwnChessman(core.Ma)
+	p2.AddLostChessman(core.Ma)
+	game.nextRoundGroup = core.Group1
+	game.history[0].Source = core.Coordinate{X: 4, Y: 4}
+	before := game.GetPosition().FEN()
+
+	game.undo(msgChan)
+	msg := <-msgChan
+	if msg.Event != Err || msg.Rejected.Reason != RejectUndoFailed || msg.Rejected.Detail == "" {
+		t.Fatalf("expect rejected undo, got %+v", msg)
+	}
+	if got := game.GetPosition().FEN(); got != before {
+		t.Fatalf("position should be restored to %s, got %s", before, got)
+	}
+	if game.GetNextRoundGroup() != core.Group1 || len(game.GetHistory()) != 2 {
+		t.Fatalf("turn and history should be kept, got %v %d", game.GetNextRoundGroup(), len(game.GetHistory()))
+	}
+	if won, _ := p1.GetWonChessmen(); len(won) != 1 || won[0] != core.Ma {
+		t.Fatalf("won chessmen should be kept, got %v", won)
+	}
+	if lost, _ := p2.GetLostChessmen(); len(lost) != 1 || lost[0] != core.Ma {
+		t.Fatalf("lost chessmen should be kept, got %v", lost)
+	}
+	if own, _ := p2.GetOwnChessmen(); len(own) != 15 {
+		t.Fatalf("expect 15 own chessmen, got %d", len(own))
+	}
+}
+
+func TestGameEvents(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	pos, _ := position.ParseFEN("3k5/9/9/9/9/9/9/9/4R4/4K4 w")
+	if err := game.SetPosition(pos); err != nil {
+		t.Fatal(err)
+	}
+	ch1 := make(chan player.Statement, 1)
+	ch2 := make(chan player.Statement, 1)
+	msgChan := game.Run(ch1, ch2)
+	play := func(ch chan player.Statement, move string) GameMsg {
+		m, _ := position.ParseMove(move)
+		st, err := game.ParseMove(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch <- st
+		return <-msgChan
+	}
+
+	//被拒绝的着法带有原因
+	if msg := play(ch1, "e1d2"); msg.Event != Err || msg.Rejected.Reason != RejectInvalidMove {
+		t.Fatalf("expect invalid move, got %+v", msg)
+	}
+	if msg := play(ch1, "e1e0"); msg.Event != Err || msg.Rejected.Reason != RejectOwnPiece {
+		t.Fatalf("expect own piece, got %+v", msg)
+	}
+
+	//车平四将军
+	msg := play(ch1, "e1d1")
+	if msg.Event != Done || msg.Check == nil || msg.Check.Group != core.Group2 {
+		t.Fatalf("expect check, got %+v", msg)
+	}
+	if mv := msg.Move; mv == nil || mv.Piece != core.Ju || mv.Group != core.Group1 || len(mv.Captured) > 0 {
+		t.Fatalf("unexpected move %+v", msg.Move)
+	}
+
+	//将没有应将，车吃将分出胜负
+	if msg = play(ch2, "d9d8"); msg.Event != Done {
+		t.Fatalf("expect done, got %+v", msg)
+	}
+	msg = play(ch1, "d1d8")
+	if msg.Event != Fin || msg.Captured() != core.JiangShuai || msg.Winner() != core.Group1 || msg.GameOver.Termination != TerminationCapture {
+		t.Fatalf("expect capture, got %+v", msg)
+	}
+}
+
+// 棋子的名称固定使用简体中文的记谱名称，和界面语言无关
+func TestChessmanNames(t *testing.T) {
+	p1 := player.NewPlayer()
+	p2 := player.NewPlayer()
+	p1.SetGroup(core.Group1)
+	p1.SetIsFirst(true)
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	matrix := game.board.GetMatrix()
+	cases := []struct {
+		co   core.Coordinate
+		name string
+	}{
+		{core.Coordinate{X: 4, Y: 0}, "帅"},
+		{core.Coordinate{X: 4, Y: 9}, "将"},
+		{core.Coordinate{X: 2, Y: 0}, "相"},
+		{core.Coordinate{X: 2, Y: 9}, "象"},
+		{core.Coordinate{X: 0, Y: 3}, "兵"},
+		{core.Coordinate{X: 0, Y: 6}, "卒"},
+		{core.Coordinate{X: 0, Y: 0}, "车"},
+	}
+	for _, c := range cases {
+		if name := matrix[c.co.Y][c.co.X].GetChessmanName(); name != c.name {
+			t.Errorf("%v: expect %s, got %s", c.co, c.name, name)
+		}
+	}
+}
+
+func TestRefereeTargetsAndCheck(t *testing.T) {
+	referee, err := NewReferee()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//开局时二路马可以跳到三路和一路
+	sq, _ := position.ParseSquare("h0")
+	targets, err := referee.LegalTargets(position.Initial(), sq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, target := range targets {
+		got[target.String()] = true
+	}
+	if len(got) != 2 || !got["g2"] || !got["i2"] {
+		t.Fatalf("unexpected targets %v", targets)
+	}
+	if check, _ := referee.InCheck(position.Initial()); check {
+		t.Fatal("initial position is not check")
+	}
+
+	//红车照将，黑将被将军；帅离开中路之后不能走到将帅见面的位置
+	pos, _ := position.ParseFEN("4k4/9/9/9/9/9/9/9/4R4/3K5 b")
+	if check, _ := referee.InCheck(pos); !check {
+		t.Fatal("rook should give check")
+	}
+	pos, _ = position.ParseFEN("4k4/9/9/9/9/9/9/9/9/3K5 w")
+	sq, _ = position.ParseSquare("d0")
+	targets, _ = referee.LegalTargets(pos, sq)
+	for _, target := range targets {
+		if target.String() == "e0" {
+			t.Fatal("kings must not face each other")
+		}
+	}
+	if len(targets) != 1 {
+		t.Fatalf("unexpected targets %v", targets)
+	}
+}
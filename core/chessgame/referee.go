@@ -0,0 +1,78 @@
+package chessgame
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Referee 裁判，用内核的规则判断任意局面下的着法是否合法。
+// 裁判内部的棋局不会运行，每次判断之前按局面重新摆棋，不能在多个协程中同时使用
+type Referee struct {
+	game *ChessGame
+}
+
+// NewReferee 创建裁判
+func NewReferee() (*Referee, error) {
+	p1 := player.NewPlayer()
+	p1.SetIsFirst(true)
+	p1.SetIsDown(true)
+	p1.SetGroup(core.Group1)
+	p2 := player.NewPlayer()
+	p2.SetGroup(core.Group2)
+
+	game := new(ChessGame)
+	if err := game.InitialGame(p1, p2); err != nil {
+		return nil, err
+	}
+	return &Referee{game: game}, nil
+}
+
+// LegalMoves 局面下走棋方所有合法的着法
+func (r *Referee) LegalMoves(pos position.Position) ([]position.Move, error) {
+	if err := r.game.SetPosition(pos); err != nil {
+		return nil, err
+	}
+	statements := r.game.GetLegalStatements(r.game.GetNextRoundGroup())
+	moves := make([]position.Move, 0, len(statements))
+	for _, st := range statements {
+		moves = append(moves, r.game.FormatMove(st))
+	}
+	return moves, nil
+}
+
+// IsLegal 着法在局面下是否合法
+func (r *Referee) IsLegal(pos position.Position, m position.Move) (bool, error) {
+	moves, err := r.LegalMoves(pos)
+	if err != nil {
+		return false, err
+	}
+	for _, legal := range moves {
+		if legal == m {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LegalTargets 局面下from位置的棋子可以到达的所有位置
+func (r *Referee) LegalTargets(pos position.Position, from position.Square) ([]position.Square, error) {
+	if err := r.game.SetPosition(pos); err != nil {
+		return nil, err
+	}
+	redIsDown := r.game.GetRedIsDown()
+	targets := r.game.GetLegalTargets(position.ToCoordinate(from, redIsDown))
+	squares := make([]position.Square, len(targets))
+	for i, co := range targets {
+		squares[i] = position.ToSquare(co, redIsDown)
+	}
+	return squares, nil
+}
+
+// InCheck 局面下走棋方是否被将军
+func (r *Referee) InCheck(pos position.Position) (bool, error) {
+	if err := r.game.SetPosition(pos); err != nil {
+		return false, err
+	}
+	return r.game.IsInCheck(r.game.GetNextRoundGroup()), nil
+}
@@ -0,0 +1,172 @@
+package chessgame
+
+import (
+	"errors"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessman"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// 棋子的名称，固定使用记谱中的简体中文名称，和界面语言无关，界面显示时按照棋子代码翻译
+func chessmanName(code core.ChessmanCode, red bool) string {
+	return position.PieceName(position.Piece{Code: code, Red: red})
+}
+
+// SetPosition 将棋局设置为指定的局面，例如残局或者开局库中的局面，必须在InitialGame之后、Run之前调用。
+// 局面中的红方棋子属于先手玩家，RedToMove决定下一回合哪个阵营下棋
+func (game *ChessGame) SetPosition(pos position.Position) error {
+	if err := validatePosition(pos); err != nil {
+		return err
+	}
+	if err := game.placePosition(pos); err != nil {
+		return err
+	}
+	game.history = make([]player.Statement, 0)
+	game.resetSpectatorRecords()
+
+	return nil
+}
+
+// 按照局面重新摆放棋子，重置玩家的棋子记录和下一回合的阵营
+func (game *ChessGame) placePosition(pos position.Position) error {
+	red, black := game.playerDown, game.playerUp
+	if !red.GetIsFirst() {
+		red, black = black, red
+	}
+	redIsDown := game.GetRedIsDown()
+
+	chessmen := make([]chessman.ChessmanInterface, 0, 32)
+	redCodes := make([]core.ChessmanCode, 0, 16)
+	blackCodes := make([]core.ChessmanCode, 0, 16)
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := pos.Squares[rank][file]
+			if len(piece.Code) == 0 {
+				continue
+			}
+			group := black.GetGroup()
+			if piece.Red {
+				group = red.GetGroup()
+				redCodes = append(redCodes, piece.Code)
+			} else {
+				blackCodes = append(blackCodes, piece.Code)
+			}
+			co := position.ToCoordinate(position.Square{File: file, Rank: rank}, redIsDown)
+			cm := chessman.NewChessman(piece.Code, chessmanName(piece.Code, piece.Red), group, co)
+			cm.BindRule(chessman.Rules[piece.Code])
+			chessmen = append(chessmen, cm)
+		}
+	}
+
+	game.board.ClearChessmen()
+	if err := game.board.PutChessmenOnBoard(chessmen); err != nil {
+		return err
+	}
+
+	for _, p := range []player.PlayerInterface{red, black} {
+		p.ClearOwnChessman()
+		p.ClearLostChessman()
+		p.ClearWonChessman()
+	}
+	red.AddOwnChessmen(redCodes)
+	black.AddOwnChessmen(blackCodes)
+
+	if pos.RedToMove {
+		game.nextRoundGroup = red.GetGroup()
+	} else {
+		game.nextRoundGroup = black.GetGroup()
+	}
+	return nil
+}
+
+// 悔棋：从棋局开始时的局面重新走一遍最后一步之前的下棋记录，然后通知外部撤销的是哪一步
+func (game *ChessGame) undo(msgChan chan GameMsg) {
+	if len(game.history) == 0 {
+		game.emit(msgChan, NewRejected(RejectNothingToUndo, ""), player.Statement{Undo: true})
+		return
+	}
+
+	last := game.history[len(game.history)-1]
+	replay := game.history[:len(game.history)-1]
+	restore := game.snapshot()
+	if err := game.replay(game.GetInitialPosition(), replay); err != nil {
+		//重新走一遍失败时恢复悔棋之前的局面，棋局继续进行
+		restore()
+		game.emit(msgChan, NewRejected(RejectUndoFailed, err.Error()), player.Statement{Undo: true})
+		return
+	}
+	game.history = append(make([]player.Statement, 0, len(replay)), replay...)
+	game.nextRoundGroup = last.Group
+
+	game.emit(msgChan, GameMsg{Event: Undone}, last)
+}
+
+// 在start局面上依次走history中的下棋记录，同时更新玩家吃子和失子的记录
+func (game *ChessGame) replay(start position.Position, history []player.Statement) error {
+	if err := game.placePosition(start); err != nil {
+		return err
+	}
+	for _, st := range history {
+		wonCode, err := game.board.MoveChessman(st.Group, st.Code, st.Source, st.Target)
+		if err != nil {
+			return err
+		}
+		if len(wonCode) > 0 {
+			mover, opponent := game.playerDown, game.playerUp
+			if mover.GetGroup() != st.Group {
+				mover, opponent = opponent, mover
+			}
+			mover.AddWonChessman(wonCode)
+			opponent.DelOwnChessman(wonCode)
+			opponent.AddLostChessman(wonCode)
+		}
+	}
+	return nil
+}
+
+// 记录当前的局面（包括下一回合的阵营）和玩家吃子、失子的记录，返回恢复这些状态的函数
+func (game *ChessGame) snapshot() (restore func()) {
+	pos := game.GetPosition()
+	players := []player.PlayerInterface{game.playerDown, game.playerUp}
+	won := make([][]core.ChessmanCode, len(players))
+	lost := make([][]core.ChessmanCode, len(players))
+	for i, p := range players {
+		codes, _ := p.GetWonChessmen()
+		won[i] = append([]core.ChessmanCode(nil), codes...)
+		codes, _ = p.GetLostChessmen()
+		lost[i] = append([]core.ChessmanCode(nil), codes...)
+	}
+
+	return func() {
+		//局面是从当前棋盘生成的，重新摆放不会出错
+		_ = game.placePosition(pos)
+		for i, p := range players {
+			p.AddWonChessmen(won[i])
+			p.AddLostChessmen(lost[i])
+		}
+	}
+}
+
+// 检查局面是否可以开始对局：双方各有一个将帅，并且将帅在九宫之内
+func validatePosition(pos position.Position) error {
+	kings := map[bool]int{}
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := pos.Squares[rank][file]
+			if piece.Code != core.JiangShuai {
+				continue
+			}
+			kings[piece.Red]++
+			inPalace := file >= 3 && file <= 5 && (piece.Red && rank <= 2 || !piece.Red && rank >= 7)
+			if !inPalace {
+				return errors.New("jiangshuai is out of the palace")
+			}
+		}
+	}
+	if kings[true] != 1 || kings[false] != 1 {
+		return errors.New("each side must have exactly one jiangshuai")
+	}
+	return nil
+}
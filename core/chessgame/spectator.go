@@ -0,0 +1,204 @@
+package chessgame
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// SpectatorMsg 推送给观战者的消息
+type SpectatorMsg struct {
+	GameMsg   GameMsg           //内核产生的消息
+	Statement player.Statement  //产生消息的下棋意图
+	Position  position.Position //消息产生之后的局面
+	At        time.Time         //消息产生的时间
+}
+
+// Spectator 观战者，只能接收消息，不能下棋。
+// 消息会在产生之后延迟一段时间才推送，防止观战者向对局双方透露棋步
+type Spectator struct {
+	Position position.Position  //加入观战时可以公开的局面
+	History  []player.Statement //加入观战时可以公开的下棋记录
+
+	game  *ChessGame
+	delay time.Duration
+	out   chan SpectatorMsg
+
+	mu     sync.Mutex
+	queue  []SpectatorMsg //等待推送的消息
+	ended  bool           //棋局已经关闭，不会再有新的消息
+	notify chan struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Spectate 加入观战，delay为消息推送的延迟。
+// 返回的观战者带有加入时可以公开的局面，之后的消息从Messages通道接收，棋局关闭后通道关闭
+func (game *ChessGame) Spectate(delay time.Duration) *Spectator {
+	game.spectatorMu.Lock()
+	defer game.spectatorMu.Unlock()
+
+	s := &Spectator{
+		Position: game.initialPosition,
+		History:  make([]player.Statement, 0),
+		game:     game,
+		delay:    delay,
+		out:      make(chan SpectatorMsg, 16),
+		queue:    make([]SpectatorMsg, 0),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+
+	//延迟时间之前的消息直接作为加入时的局面，之后的消息排队推送
+	cutoff := time.Now().Add(-delay)
+	for _, msg := range game.records {
+		if msg.At.After(cutoff) {
+			s.queue = append(s.queue, msg)
+			continue
+		}
+		s.Position = msg.Position
+		switch msg.GameMsg.Event {
+		case Undone:
+			s.History = s.History[:len(s.History)-1]
+		default:
+			//超时、弃局等内核之外的结果没有生效的着法
+			if msg.GameMsg.Move != nil {
+				s.History = append(s.History, msg.Statement)
+			}
+		}
+	}
+	s.ended = game.spectatorsEnded
+
+	game.spectators[s] = struct{}{}
+	go s.run()
+
+	return s
+}
+
+// Messages 返回接收消息的通道
+func (s *Spectator) Messages() <-chan SpectatorMsg {
+	return s.out
+}
+
+// Close 退出观战
+func (s *Spectator) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.game.spectatorMu.Lock()
+	delete(s.game.spectators, s)
+	s.game.spectatorMu.Unlock()
+}
+
+// 按照延迟推送排队的消息
+func (s *Spectator) run() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.ended {
+			s.mu.Unlock()
+			select {
+			case <-s.notify:
+			case <-s.stop:
+				return
+			}
+			s.mu.Lock()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		msg := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if wait := time.Until(msg.At.Add(s.delay)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-s.stop:
+				return
+			}
+		}
+		select {
+		case s.out <- msg:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Spectator) push(msg SpectatorMsg) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *Spectator) end() {
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *Spectator) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// 记录消息并推送给所有观战者。被拒绝的下棋意图只属于下棋的一方，不推送给观战者
+func (game *ChessGame) publish(msg GameMsg, st player.Statement) {
+	if msg.Event == Err {
+		return
+	}
+	game.spectatorMu.Lock()
+	defer game.spectatorMu.Unlock()
+
+	record := SpectatorMsg{
+		GameMsg:   msg,
+		Statement: st,
+		At:        time.Now(),
+	}
+	if game.board != nil {
+		record.Position = game.GetPosition()
+	}
+	game.records = append(game.records, record)
+	for s := range game.spectators {
+		s.push(record)
+	}
+}
+
+// Publish 把内核之外产生的消息推送给观战者，例如服务器判定的超时或者弃局。
+// 必须在Close之前调用，否则观战者的通道已经关闭，收不到这条消息
+func (game *ChessGame) Publish(msg GameMsg) {
+	game.publish(msg, player.Statement{})
+}
+
+// 重新开始记录观战消息
+func (game *ChessGame) resetSpectatorRecords() {
+	game.spectatorMu.Lock()
+	defer game.spectatorMu.Unlock()
+
+	game.initialPosition = game.GetPosition()
+	game.records = make([]SpectatorMsg, 0)
+	game.spectatorsEnded = false
+	if game.spectators == nil {
+		game.spectators = make(map[*Spectator]struct{})
+	}
+}
+
+// 棋局关闭，观战者收完剩余的消息后通道关闭
+func (game *ChessGame) endSpectators() {
+	game.spectatorMu.Lock()
+	defer game.spectatorMu.Unlock()
+
+	game.spectatorsEnded = true
+	for s := range game.spectators {
+		s.end()
+	}
+}
@@ -1,18 +1,115 @@
 package chessgame
 
-import "github.com/CXeon/xiangqi/core"
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+)
 
+// GameMsg 内核产生的事件。Event表示事件的类型，其余字段按照类型填写：
+// Done带有Move和可能的Check，Err带有Rejected，Fin带有GameOver，由走棋分出胜负时还带有Move
 type GameMsg struct {
-	Event           MoveEvent          //事件类型
-	WonChessmanCode core.ChessmanCode  //发生吃棋后赢得的棋子code
-	WonGroup        core.ChessmanGroup //分出胜负后，赢家的阵营
-	Msg             string             //消息
+	Event    MoveEvent     `json:"event"`              //事件类型
+	Move     *MoveApplied  `json:"move,omitempty"`     //生效的着法
+	Rejected *MoveRejected `json:"rejected,omitempty"` //被拒绝的下棋意图
+	Check    *Check        `json:"check,omitempty"`    //着法生效后形成将军
+	GameOver *GameOver     `json:"gameOver,omitempty"` //对局结束
 }
 
 type MoveEvent string
 
 const (
-	Done MoveEvent = "DONE" //表示移动有效，棋子正常移动
-	Err  MoveEvent = "ERR"  //表示移动报错，棋子不能移动
-	Fin  MoveEvent = "FIN"  //表示胜负已分，本局对局结束
+	Done   MoveEvent = "DONE"   //表示移动有效，棋子正常移动
+	Err    MoveEvent = "ERR"    //表示移动报错，棋子不能移动
+	Fin    MoveEvent = "FIN"    //表示胜负已分，本局对局结束
+	Undone MoveEvent = "UNDONE" //表示悔棋有效，最近一步棋已经撤销
+)
+
+// MoveApplied 生效的着法，坐标和下棋意图使用同一个视角
+type MoveApplied struct {
+	Group    core.ChessmanGroup `json:"group"`              //走棋的阵营
+	Piece    core.ChessmanCode  `json:"piece"`              //移动的棋子
+	From     core.Coordinate    `json:"from"`               //起点
+	To       core.Coordinate    `json:"to"`                 //终点
+	Captured core.ChessmanCode  `json:"captured,omitempty"` //被吃掉的棋子，没有吃子时为空
+}
+
+// Statement 着法对应的下棋意图
+func (m MoveApplied) Statement() player.Statement {
+	return player.Statement{Group: m.Group, Code: m.Piece, Source: m.From, Target: m.To}
+}
+
+// RejectReason 下棋意图被拒绝的原因
+type RejectReason string
+
+const (
+	RejectInvalidMove   RejectReason = "invalidMove"   //走法不符合规则
+	RejectOwnPiece      RejectReason = "ownPiece"      //目标位置是自己的棋子
+	RejectNoChessman    RejectReason = "noChessman"    //起点没有意图中的棋子
+	RejectNothingToUndo RejectReason = "nothingToUndo" //没有可以悔的棋
+	RejectUndoFailed    RejectReason = "undoFailed"    //重新走一遍下棋记录失败，没能悔棋
+	RejectClosed        RejectReason = "closed"        //棋局已经关闭
+	RejectNoStatement   RejectReason = "noStatement"   //玩家没能给出下棋意图，例如引擎出错
 )
+
+// MoveRejected 被拒绝的下棋意图
+type MoveRejected struct {
+	Reason RejectReason `json:"reason"`           //拒绝的原因
+	Detail string       `json:"detail,omitempty"` //原始的错误信息，只用于排查问题
+}
+
+// Check 将军
+type Check struct {
+	Group core.ChessmanGroup `json:"group"` //被将军的阵营
+}
+
+// Termination 对局结束的原因
+type Termination string
+
+const (
+	TerminationCapture     Termination = "capture"     //将帅被吃
+	TerminationFaceToFace  Termination = "faceToFace"  //走成将帅见面，走棋的一方负
+	TerminationTimeout     Termination = "timeout"     //超时判负
+	TerminationAbandoned   Termination = "abandoned"   //断线后没有重连，判负
+	TerminationMaxPlies    Termination = "maxPlies"    //超过步数限制，判和
+	TerminationNoMoves     Termination = "noMoves"     //没有可以走的棋，判负
+	TerminationIllegalMove Termination = "illegalMove" //引擎走了不符合规则的棋，判负
+	TerminationEngineError Termination = "engineError" //引擎出错，判负
+)
+
+// GameOver 对局结束
+type GameOver struct {
+	Winner      core.ChessmanGroup `json:"winner"`      //赢家的阵营，GroupNone表示和棋
+	Termination Termination        `json:"termination"` //结束的原因
+}
+
+// NewGameOver 生成对局结束的事件，超时、弃局等内核之外判定的结果也用它生成
+func NewGameOver(winner core.ChessmanGroup, termination Termination) GameMsg {
+	return GameMsg{
+		Event:    Fin,
+		GameOver: &GameOver{Winner: winner, Termination: termination},
+	}
+}
+
+// NewRejected 生成下棋意图被拒绝的事件
+func NewRejected(reason RejectReason, detail string) GameMsg {
+	return GameMsg{
+		Event:    Err,
+		Rejected: &MoveRejected{Reason: reason, Detail: detail},
+	}
+}
+
+// Winner 赢家的阵营，对局没有结束或者和棋时返回GroupNone
+func (msg GameMsg) Winner() core.ChessmanGroup {
+	if msg.GameOver == nil {
+		return core.GroupNone
+	}
+	return msg.GameOver.Winner
+}
+
+// Captured 着法吃掉的棋子，没有吃子时为空
+func (msg GameMsg) Captured() core.ChessmanCode {
+	if msg.Move == nil {
+		return ""
+	}
+	return msg.Move.Captured
+}
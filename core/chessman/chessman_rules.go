@@ -6,16 +6,33 @@ import (
 	"math"
 )
 
+// Rules 各类棋子的走法规则
+var Rules = map[core.ChessmanCode]ChessRUle{
+	core.Ju:         RuleJu,
+	core.Ma:         RuleMa,
+	core.Xiang:      RuleXiang,
+	core.Shi:        RuleShi,
+	core.JiangShuai: RuleJiangShuai,
+	core.Pao:        RulePao,
+	core.BingZu:     RuleBingZu,
+}
+
+// 走法校验失败的错误
+var (
+	ErrInvalidMove = errors.New("invalid move")                            //走法不符合规则
+	ErrOwnPiece    = errors.New("target has a chessman of the same group") //目标位置是自己的棋子
+)
+
 // “马”的移动规则校验
 // 马走日
 func RuleMa(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 
 	if verifyCoordinateOnTheBoard(source) == false || verifyCoordinateOnTheBoard(target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 	//“马”是否走了日
 	if math.Abs(float64(target.X-source.X))*math.Abs(float64(target.Y-source.Y)) != 2.0 {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//确认“马”走了个什么日
@@ -57,27 +74,27 @@ func RuleMa(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, s
 	case "UP2LEFT1", "UP2RIGHT1":
 		//确认是否是“撇脚马”
 		if matrix[source.Y+1][source.X] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "DOWN2LEFT1", "DOWN2RIGHT1":
 		if matrix[source.Y-1][source.X] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "UP1LEFT2", "DOWN1LEFT2":
 		if matrix[source.Y][source.X+1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "UP1RIGHT2", "DOWN1RIGHT2":
 		if matrix[source.Y][source.X-1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	default:
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//目的坐标是否已经被同阵营的棋子占据
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 
 	return true, nil
@@ -88,17 +105,17 @@ func RuleMa(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, s
 func RuleXiang(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//检查象棋的移动是否在棋盘内
 	if verifyCoordinateOnTheBoard(source) == false || verifyCoordinateOnTheBoard(target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//限制象只能在一个阵营区域移动
 	if rowGroup[source.Y] != rowGroup[target.Y] {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//象是否飞了田
 	if math.Abs(float64(target.X-source.X)) != 2.0 || math.Abs(float64(target.Y-source.Y)) != 2.0 {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//象飞了个什么田
@@ -126,27 +143,27 @@ func RuleXiang(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup
 	switch vh {
 	case "UP2LEFT2":
 		if matrix[source.Y+1][source.X+1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "DOWN2LEFT2":
 		if matrix[source.Y-1][source.X+1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "UP2RIGHT2":
 		if matrix[source.Y+1][source.X-1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "DOWN2RIGHT2":
 		if matrix[source.Y-1][source.X-1] != nil {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	default:
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//目标位置是否存在己方棋子
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 
 	return true, nil
@@ -156,13 +173,18 @@ func RuleXiang(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup
 func RuleBingZu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//检查象棋的移动是否在棋盘内
 	if verifyCoordinateOnTheBoard(source) == false || verifyCoordinateOnTheBoard(target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//检查象棋进行了哪种移动
 	moveY := target.Y - source.Y
 	moveX := target.X - source.X
 
+	//兵卒每次只能走一步
+	if math.Abs(float64(moveX))+math.Abs(float64(moveY)) != 1 {
+		return false, ErrInvalidMove
+	}
+
 	vertical := ""
 	horizontal := ""
 
@@ -183,23 +205,23 @@ func RuleBingZu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGrou
 	switch vh {
 	case "UP1":
 		if matrix[source.Y][source.X].GetChessmanGroup() != rowGroup[4] {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "DOWN1":
 		if matrix[source.Y][source.X].GetChessmanGroup() != rowGroup[5] {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	case "LEFT1", "RIGHT1":
 		if matrix[source.Y][source.X].GetChessmanGroup() == rowGroup[source.Y] {
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	default:
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//目标位置是否存在己方棋子
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 
 	return true, nil
@@ -210,7 +232,7 @@ func RuleBingZu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGrou
 func RuleJu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//检查象棋的移动是否在棋盘内
 	if verifyCoordinateOnTheBoard(source) == false || verifyCoordinateOnTheBoard(target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//确定移动方向
@@ -236,14 +258,14 @@ func RuleJu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, s
 		//向上移动，中间不能有其他棋子存在
 		for i := source.Y + 1; i < target.Y; i++ {
 			if matrix[i][source.X] != nil {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		}
 	case "DOWN":
 		//向下移动，中间不能有其他棋子存在
 		for i := source.Y - 1; i > target.Y; i-- {
 			if matrix[i][source.X] != nil {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		}
 
@@ -251,21 +273,21 @@ func RuleJu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, s
 		//向左移动，中间不能有其他棋子存在
 		for i := source.X + 1; i < target.X; i++ {
 			if matrix[source.Y][i] != nil {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		}
 	case "RIGHT":
 		for i := source.X - 1; i > target.X; i-- {
 			if matrix[source.Y][i] != nil {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		}
 	default:
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 	return true, nil
 
@@ -276,7 +298,7 @@ func RuleJu(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, s
 func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//检查象棋的移动是否在棋盘内
 	if verifyCoordinateOnTheBoard(source) == false || verifyCoordinateOnTheBoard(target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//确定移动方向
@@ -298,7 +320,7 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 	}
 	//判定是否发生吃棋
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 	if chessman := matrix[target.Y][target.X]; chessman != nil {
 		//发生吃棋，那目标坐标和起始坐标之间必须要存在1颗棋子
@@ -312,7 +334,7 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 				}
 			}
 			if count != 1 {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		case "DOWN":
 			count := 0
@@ -323,7 +345,7 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 				}
 			}
 			if count != 1 {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		case "LEFT":
 			count := 0
@@ -334,7 +356,7 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 				}
 			}
 			if count != 1 {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		case "RIGHT":
 			count := 0
@@ -344,10 +366,10 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 				}
 			}
 			if count != 1 {
-				return false, errors.New("invalid move")
+				return false, ErrInvalidMove
 			}
 		default:
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	}
 	if chessman := matrix[target.Y][target.X]; chessman == nil {
@@ -357,14 +379,14 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 			//向上移动，中间不能有其他棋子存在
 			for i := source.Y + 1; i < target.Y; i++ {
 				if matrix[i][source.X] != nil {
-					return false, errors.New("invalid move")
+					return false, ErrInvalidMove
 				}
 			}
 		case "DOWN":
 			//向下移动，中间不能有其他棋子存在
 			for i := source.Y - 1; i > target.Y; i-- {
 				if matrix[i][source.X] != nil {
-					return false, errors.New("invalid move")
+					return false, ErrInvalidMove
 				}
 			}
 
@@ -372,17 +394,17 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 			//向左移动，中间不能有其他棋子存在
 			for i := source.X + 1; i < target.X; i++ {
 				if matrix[source.Y][i] != nil {
-					return false, errors.New("invalid move")
+					return false, ErrInvalidMove
 				}
 			}
 		case "RIGHT":
 			for i := source.X - 1; i > target.X; i-- {
 				if matrix[source.Y][i] != nil {
-					return false, errors.New("invalid move")
+					return false, ErrInvalidMove
 				}
 			}
 		default:
-			return false, errors.New("invalid move")
+			return false, ErrInvalidMove
 		}
 	}
 
@@ -393,12 +415,12 @@ func RulePao(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 func RuleShi(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//首先验证棋子是否在规定范围内活动
 	if verifyJiangShuaiOrShiInZone(matrix, rowGroup, source, target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//验证目标坐标是否存在同阵营棋子
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 
 	//确认移动方式
@@ -427,25 +449,30 @@ func RuleShi(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup,
 	case "UP1LEFT1", "UP1RIGHT1", "DOWN1LEFT1", "DOWN1RIGHT1":
 		return true, nil
 	}
-	return false, errors.New("invalid move")
+	return false, ErrInvalidMove
 }
 
 // “将/帅”的移动规则校验
 func RuleJiangShuai(matrix [][]ChessmanInterface, rowGroup map[int]core.ChessmanGroup, source, target core.Coordinate) (bool, error) {
 	//首先验证棋子是否在规定范围内活动
 	if verifyJiangShuaiOrShiInZone(matrix, rowGroup, source, target) == false {
-		return false, errors.New("invalid move")
+		return false, ErrInvalidMove
 	}
 
 	//验证目标坐标是否存在同阵营棋子
 	if targetCoordinateHaveSameGroupChess(matrix, source, target) {
-		return false, errors.New("target has a chessman of the same group")
+		return false, ErrOwnPiece
 	}
 
 	//确认移动方式
 	moveY := target.Y - source.Y
 	moveX := target.X - source.X
 
+	//将帅每次只能横向或者纵向走一步
+	if math.Abs(float64(moveX))+math.Abs(float64(moveY)) != 1 {
+		return false, ErrInvalidMove
+	}
+
 	vertical := ""
 	horizontal := ""
 
@@ -456,10 +483,10 @@ func RuleJiangShuai(matrix [][]ChessmanInterface, rowGroup map[int]core.Chessman
 		vertical = "DOWN1"
 	}
 	if moveX == 1 {
-		vertical = "LEFT1"
+		horizontal = "LEFT1"
 	}
 	if moveX == -1 {
-		vertical = "RIGHT1"
+		horizontal = "RIGHT1"
 	}
 
 	vh := vertical + horizontal
@@ -468,7 +495,7 @@ func RuleJiangShuai(matrix [][]ChessmanInterface, rowGroup map[int]core.Chessman
 	case "UP1", "RIGHT1", "DOWN1", "LEFT1":
 		return true, nil
 	}
-	return false, errors.New("invalid move")
+	return false, ErrInvalidMove
 }
 
 // 目的坐标是否已经被同阵营的棋子占据
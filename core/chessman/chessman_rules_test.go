@@ -0,0 +1,95 @@
+package chessman
+
+import (
+	"testing"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 空棋盘，0到4行属于Group1，5到9行属于Group2
+func emptyBoard() ([][]ChessmanInterface, map[int]core.ChessmanGroup) {
+	matrix := make([][]ChessmanInterface, 10)
+	for y := range matrix {
+		matrix[y] = make([]ChessmanInterface, 9)
+	}
+	rowGroup := make(map[int]core.ChessmanGroup, 10)
+	for y := 0; y < 10; y++ {
+		if y < 5 {
+			rowGroup[y] = core.Group1
+		} else {
+			rowGroup[y] = core.Group2
+		}
+	}
+	return matrix, rowGroup
+}
+
+func put(matrix [][]ChessmanInterface, code core.ChessmanCode, group core.ChessmanGroup, co core.Coordinate) {
+	matrix[co.Y][co.X] = NewChessman(code, "", group, co)
+}
+
+func TestRuleBingZu(t *testing.T) {
+	matrix, rowGroup := emptyBoard()
+	//一个没过河的兵，一个已经过河的兵
+	home := core.Coordinate{X: 0, Y: 3}
+	crossed := core.Coordinate{X: 4, Y: 6}
+	put(matrix, core.BingZu, core.Group1, home)
+	put(matrix, core.BingZu, core.Group1, crossed)
+
+	cases := []struct {
+		name   string
+		source core.Coordinate
+		target core.Coordinate
+		ok     bool
+	}{
+		{"forward one", home, core.Coordinate{X: 0, Y: 4}, true},
+		{"forward two", home, core.Coordinate{X: 0, Y: 5}, false},
+		{"sideways before river", home, core.Coordinate{X: 1, Y: 3}, false},
+		{"backward", home, core.Coordinate{X: 0, Y: 2}, false},
+		{"crossed forward one", crossed, core.Coordinate{X: 4, Y: 7}, true},
+		{"crossed forward two", crossed, core.Coordinate{X: 4, Y: 8}, false},
+		{"crossed sideways one", crossed, core.Coordinate{X: 3, Y: 6}, true},
+		{"crossed sideways two", crossed, core.Coordinate{X: 6, Y: 6}, false},
+	}
+	for _, c := range cases {
+		ok, _ := RuleBingZu(matrix, rowGroup, c.source, c.target)
+		if ok != c.ok {
+			t.Errorf("%s: got %v, expect %v", c.name, ok, c.ok)
+		}
+	}
+}
+
+func TestRuleJiangShuai(t *testing.T) {
+	matrix, rowGroup := emptyBoard()
+	down := core.Coordinate{X: 4, Y: 1}
+	up := core.Coordinate{X: 3, Y: 9}
+	put(matrix, core.JiangShuai, core.Group1, down)
+	put(matrix, core.JiangShuai, core.Group2, up)
+
+	cases := []struct {
+		name   string
+		source core.Coordinate
+		target core.Coordinate
+		ok     bool
+	}{
+		{"up one", down, core.Coordinate{X: 4, Y: 2}, true},
+		{"down one", down, core.Coordinate{X: 4, Y: 0}, true},
+		{"left one", down, core.Coordinate{X: 3, Y: 1}, true},
+		{"right one", down, core.Coordinate{X: 5, Y: 1}, true},
+		{"two squares", down, core.Coordinate{X: 4, Y: 3}, false},
+		{"diagonal", down, core.Coordinate{X: 5, Y: 2}, false},
+		{"out of palace", core.Coordinate{X: 4, Y: 2}, core.Coordinate{X: 4, Y: 3}, false},
+		{"top sideways", up, core.Coordinate{X: 4, Y: 9}, true},
+		{"top two squares", up, core.Coordinate{X: 5, Y: 9}, false},
+		{"top out of palace", up, core.Coordinate{X: 2, Y: 9}, false},
+	}
+	for _, c := range cases {
+		//出宫的用例把将帅先放到起点
+		if matrix[c.source.Y][c.source.X] == nil {
+			put(matrix, core.JiangShuai, core.Group1, c.source)
+		}
+		ok, _ := RuleJiangShuai(matrix, rowGroup, c.source, c.target)
+		if ok != c.ok {
+			t.Errorf("%s: got %v, expect %v", c.name, ok, c.ok)
+		}
+	}
+}
@@ -5,6 +5,12 @@ import (
 	"github.com/CXeon/xiangqi/core"
 )
 
+// 接收下棋意图时棋局已经关闭的错误
+var (
+	ErrQuit   = errors.New("receive quit signal") //收到退出信号
+	ErrClosed = errors.New("channel is closed")   //意图通道已经关闭
+)
+
 type Player struct {
 	ID           int                 //玩家id
 	group        core.ChessmanGroup  //阵营
@@ -28,16 +34,26 @@ func (p *Player) ReceiveStatement(ch chan Statement, quit chan struct{}) (Statem
 
 	select {
 	case <-quit: // 优先响应退出信号
-		return Statement{}, errors.New("receive quit signal")
+		return Statement{}, ErrQuit
 	case sta, ok := <-ch: // 同时监听数据通道
 		if !ok {
-			return Statement{}, errors.New("channel is closed")
+			return Statement{}, ErrClosed
 		}
 		return sta, nil
 	}
 
 }
 
+// SetID 设置玩家id
+func (p *Player) SetID(id int) {
+	p.ID = id
+}
+
+// GetID 获取玩家id
+func (p *Player) GetID() int {
+	return p.ID
+}
+
 // SetGroup 分配阵营
 func (p *Player) SetGroup(group core.ChessmanGroup) {
 	p.group = group
@@ -6,6 +6,8 @@ import (
 
 type PlayerInterface interface {
 	ReceiveStatement(ch chan Statement, quit chan struct{}) (Statement, error) //player接收意图,quit用来终止读取防止阻塞
+	SetID(id int)                                                              //设置玩家id
+	GetID() int                                                                //获取玩家id
 	SetGroup(group core.ChessmanGroup)                                         //为玩家分配阵营
 	GetGroup() core.ChessmanGroup                                              //获取玩家所属阵营
 	SetIsFirst(isFirst bool)                                                   //设置玩家是否是先手
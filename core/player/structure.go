@@ -7,4 +7,5 @@ type Statement struct {
 	Code   core.ChessmanCode  //棋子code
 	Source core.Coordinate    //起始坐标
 	Target core.Coordinate    //目的坐标
+	Undo   bool               //悔棋，撤销最近一步棋，为true时其他字段被忽略
 }
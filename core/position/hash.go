@@ -0,0 +1,84 @@
+package position
+
+import "github.com/CXeon/xiangqi/core"
+
+// 各种棋子在Zobrist表中的序号
+var pieceIndex = map[core.ChessmanCode]int{
+	core.JiangShuai: 0,
+	core.Shi:        1,
+	core.Xiang:      2,
+	core.Ma:         3,
+	core.Ju:         4,
+	core.Pao:        5,
+	core.BingZu:     6,
+}
+
+// Zobrist随机数表，按红黑、棋子、行、列排列。使用固定的种子生成，保证不同程序计算的哈希值相同
+var (
+	zobristPieces [2][7][Ranks][Files]uint64
+	zobristBlack  uint64 //黑方走棋时异或
+)
+
+func init() {
+	seed := uint64(0x58494e4751493031)
+	next := func() uint64 {
+		//splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for color := range zobristPieces {
+		for piece := range zobristPieces[color] {
+			for rank := 0; rank < Ranks; rank++ {
+				for file := 0; file < Files; file++ {
+					zobristPieces[color][piece][rank][file] = next()
+				}
+			}
+		}
+	}
+	zobristBlack = next()
+}
+
+// Hash 局面的Zobrist哈希值，包括棋子的位置和走棋方，用作开局库等的键
+func (pos *Position) Hash() uint64 {
+	var h uint64
+	for rank := 0; rank < Ranks; rank++ {
+		for file := 0; file < Files; file++ {
+			p := pos.Squares[rank][file]
+			if len(p.Code) == 0 {
+				continue
+			}
+			color := 0
+			if !p.Red {
+				color = 1
+			}
+			h ^= zobristPieces[color][pieceIndex[p.Code]][rank][file]
+		}
+	}
+	if !pos.RedToMove {
+		h ^= zobristBlack
+	}
+	return h
+}
+
+// Play 在局面上走一步棋并交换走棋方，返回被吃掉的棋子。不检查着法是否符合规则
+func (pos *Position) Play(m Move) Piece {
+	captured := pos.At(m.To)
+	pos.Squares[m.To.Rank][m.To.File] = pos.At(m.From)
+	pos.Squares[m.From.Rank][m.From.File] = Piece{}
+	pos.RedToMove = !pos.RedToMove
+	return captured
+}
+
+// Mirror 左右对称的局面
+func (pos Position) Mirror() Position {
+	mirror := Position{RedToMove: pos.RedToMove}
+	for rank := 0; rank < Ranks; rank++ {
+		for file := 0; file < Files; file++ {
+			mirror.Squares[rank][Files-1-file] = pos.Squares[rank][file]
+		}
+	}
+	return mirror
+}
@@ -0,0 +1,69 @@
+package position
+
+import "testing"
+
+// 棋子在位置上的Zobrist随机数
+func zobristOf(p Piece, sq Square) uint64 {
+	color := 0
+	if !p.Red {
+		color = 1
+	}
+	return zobristPieces[color][pieceIndex[p.Code]][sq.Rank][sq.File]
+}
+
+// 走棋之后按照Zobrist的规则增量更新的哈希值应该和重新计算的相同
+func TestHashIncremental(t *testing.T) {
+	pos := Initial()
+	h := pos.Hash()
+	//包括炮八进七吃马这样的吃子
+	for _, s := range []string{"h2e2", "h9g7", "b2b9", "a9a7", "b9a9", "i9h9"} {
+		m := mustParseMove(t, s)
+		moving := pos.At(m.From)
+		h ^= zobristOf(moving, m.From) ^ zobristOf(moving, m.To) ^ zobristBlack
+		if captured := pos.Play(m); len(captured.Code) != 0 {
+			h ^= zobristOf(captured, m.To)
+		}
+		if full := pos.Hash(); h != full {
+			t.Fatalf("after %s: incremental %016x, full %016x", s, h, full)
+		}
+		if parsed := mustParseFEN(t, pos.FEN()); parsed.Hash() != h {
+			t.Fatalf("after %s: hash of %q differs", s, pos.FEN())
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	//不同的着法顺序到达同一个局面，哈希值相同
+	a, b := Initial(), Initial()
+	for _, s := range []string{"h2e2", "h9g7", "b0c2", "b9c7"} {
+		a.Play(mustParseMove(t, s))
+	}
+	for _, s := range []string{"b0c2", "b9c7", "h2e2", "h9g7"} {
+		b.Play(mustParseMove(t, s))
+	}
+	if a.Hash() != b.Hash() {
+		t.Fatalf("transposition should have the same hash")
+	}
+
+	//走棋方不同，哈希值不同
+	red := Initial()
+	black := Initial()
+	black.RedToMove = false
+	if red.Hash() == black.Hash() {
+		t.Fatalf("side to move should change the hash")
+	}
+
+	//固定的种子，哈希值不会因为程序不同而变化，保存的开局库才能继续使用
+	if h := red.Hash(); h != 0xa50acdaefbb9347e {
+		t.Fatalf("hash of the initial position changed: %#016x", h)
+	}
+
+	mirror := red.Mirror()
+	if mirror.FEN() != InitialFEN {
+		t.Fatalf("mirror of initial position: %q", mirror.FEN())
+	}
+	pos := mustParseFEN(t, "3k5/9/9/9/9/9/9/9/4R4/R3K4 w")
+	if got := pos.Mirror().FEN(); got != "5k3/9/9/9/9/9/9/9/4R4/4K3R w" {
+		t.Fatalf("mirror: %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package position
+
+import "fmt"
+
+// String 返回ICCS坐标，列用a到i表示（红方从左到右），行用0到9表示（红方从下到上），例如h2
+func (sq Square) String() string {
+	return fmt.Sprintf("%c%d", 'a'+sq.File, sq.Rank)
+}
+
+// Valid 位置是否在棋盘内
+func (sq Square) Valid() bool {
+	return sq.File >= 0 && sq.File < Files && sq.Rank >= 0 && sq.Rank < Ranks
+}
+
+// ParseSquare 解析ICCS坐标，大小写都可以
+func ParseSquare(s string) (Square, error) {
+	if len(s) != 2 {
+		return Square{}, fmt.Errorf("invalid square %q", s)
+	}
+	file := s[0]
+	if file >= 'A' && file <= 'Z' {
+		file += 'a' - 'A'
+	}
+	sq := Square{File: int(file) - 'a', Rank: int(s[1]) - '0'}
+	if !sq.Valid() {
+		return Square{}, fmt.Errorf("invalid square %q", s)
+	}
+	return sq, nil
+}
+
+// Move 红方视角的一步棋
+type Move struct {
+	From Square
+	To   Square
+}
+
+// String 返回ICCS着法，例如h2e2
+func (m Move) String() string {
+	return m.From.String() + m.To.String()
+}
+
+// ParseMove 解析ICCS着法，也接受h2-e2的写法
+func ParseMove(s string) (Move, error) {
+	if len(s) == 5 && s[2] == '-' {
+		s = s[:2] + s[3:]
+	}
+	if len(s) != 4 {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	from, err := ParseSquare(s[:2])
+	if err != nil {
+		return Move{}, err
+	}
+	to, err := ParseSquare(s[2:])
+	if err != nil {
+		return Move{}, err
+	}
+	return Move{From: from, To: to}, nil
+}
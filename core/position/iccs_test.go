@@ -0,0 +1,66 @@
+package position
+
+import "testing"
+
+func mustParseMove(t *testing.T, s string) Move {
+	t.Helper()
+	m, err := ParseMove(s)
+	if err != nil {
+		t.Fatalf("parse move %q: %v", s, err)
+	}
+	return m
+}
+
+func TestICCS(t *testing.T) {
+	squares := []struct {
+		s  string
+		sq Square
+	}{
+		{"a0", Square{File: 0, Rank: 0}},
+		{"h2", Square{File: 7, Rank: 2}},
+		{"e9", Square{File: 4, Rank: 9}},
+		{"i9", Square{File: 8, Rank: 9}},
+	}
+	for _, c := range squares {
+		sq, err := ParseSquare(c.s)
+		if err != nil || sq != c.sq {
+			t.Errorf("parse %q: got %v %v, expect %v", c.s, sq, err, c.sq)
+		}
+		if got := c.sq.String(); got != c.s {
+			t.Errorf("format %v: got %q, expect %q", c.sq, got, c.s)
+		}
+	}
+	if sq, err := ParseSquare("H2"); err != nil || sq != (Square{File: 7, Rank: 2}) {
+		t.Errorf("upper case square: got %v %v", sq, err)
+	}
+	for _, s := range []string{"", "a", "j0", "a:", "a10", "`0", "00"} {
+		if _, err := ParseSquare(s); err == nil {
+			t.Errorf("square %q should be invalid", s)
+		}
+	}
+
+	moves := []struct {
+		s      string
+		expect string
+	}{
+		{"h2e2", "h2e2"},
+		{"H2E2", "h2e2"},
+		{"h2-e2", "h2e2"},
+		{"b9c7", "b9c7"},
+	}
+	for _, c := range moves {
+		m, err := ParseMove(c.s)
+		if err != nil {
+			t.Errorf("parse %q: %v", c.s, err)
+			continue
+		}
+		if got := m.String(); got != c.expect {
+			t.Errorf("format %q: got %q, expect %q", c.s, got, c.expect)
+		}
+	}
+	for _, s := range []string{"", "h2e", "h2e22", "h2+e2", "h2j2", "z2e2"} {
+		if _, err := ParseMove(s); err == nil {
+			t.Errorf("move %q should be invalid", s)
+		}
+	}
+}
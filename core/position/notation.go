@@ -0,0 +1,173 @@
+package position
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 红方用中文数字记录纵线和步数，黑方用阿拉伯数字
+var redNumerals = []string{"一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// PieceName 获取棋子在记谱中的名称，红黑双方的相象、仕士、帅将、兵卒名称不同
+func PieceName(p Piece) string {
+	switch p.Code {
+	case core.Ju:
+		return "车"
+	case core.Ma:
+		return "马"
+	case core.Pao:
+		return "炮"
+	case core.Xiang:
+		if p.Red {
+			return "相"
+		}
+		return "象"
+	case core.Shi:
+		if p.Red {
+			return "仕"
+		}
+		return "士"
+	case core.JiangShuai:
+		if p.Red {
+			return "帅"
+		}
+		return "将"
+	case core.BingZu:
+		if p.Red {
+			return "兵"
+		}
+		return "卒"
+	}
+	return ""
+}
+
+// 纵线的编号，红方从右向左为一到九，黑方从右向左为1到9
+func fileNumber(file int, red bool) int {
+	if red {
+		return Files - file
+	}
+	return file + 1
+}
+
+func numeral(n int, red bool) string {
+	if red {
+		return redNumerals[n-1]
+	}
+	return string(rune('0' + n))
+}
+
+// ChineseMove 将着法转换成中文记谱，例如炮二平五、马8进7，局面必须是走棋之前的局面，起始位置没有棋子时返回空串。
+// 同一纵线上有多个同类棋子时用前、中、后区分，兵卒在多条纵线上重叠时用前、后加纵线编号区分
+func (pos *Position) ChineseMove(m Move) string {
+	piece := pos.At(m.From)
+	if len(piece.Code) == 0 {
+		return ""
+	}
+	red := piece.Red
+
+	//向前走的步数，红方向上为前，黑方向下为前
+	forward := m.To.Rank - m.From.Rank
+	if !red {
+		forward = -forward
+	}
+
+	var sb strings.Builder
+	sb.WriteString(pos.chinesePrefix(m.From, piece))
+	switch {
+	case forward > 0:
+		sb.WriteString("进")
+	case forward < 0:
+		sb.WriteString("退")
+	default:
+		sb.WriteString("平")
+	}
+	switch {
+	case forward == 0, piece.Code == core.Ma, piece.Code == core.Xiang, piece.Code == core.Shi:
+		//平移和斜着走的棋子记录到达的纵线
+		sb.WriteString(numeral(fileNumber(m.To.File, red), red))
+	default:
+		if forward < 0 {
+			forward = -forward
+		}
+		sb.WriteString(numeral(forward, red))
+	}
+	return sb.String()
+}
+
+// 记谱的前两个字：棋子名称加纵线编号，或者前后加棋子名称
+func (pos *Position) chinesePrefix(from Square, piece Piece) string {
+	//同一纵线上的同类棋子，按照从前到后排列
+	ranks := pos.sameFileRanks(from.File, piece)
+	if len(ranks) == 1 {
+		return PieceName(piece) + numeral(fileNumber(from.File, piece.Red), piece.Red)
+	}
+	index := 0
+	for i, rank := range ranks {
+		if rank == from.Rank {
+			index = i
+		}
+	}
+
+	var order string
+	switch {
+	case len(ranks) == 2:
+		order = []string{"前", "后"}[index]
+	case len(ranks) == 3:
+		order = []string{"前", "中", "后"}[index]
+	default:
+		order = numeral(index+1, true)
+	}
+
+	//兵卒在两条以上的纵线上重叠时，用纵线编号代替棋子名称
+	if piece.Code == core.BingZu {
+		stacked := 0
+		for file := 0; file < Files; file++ {
+			if len(pos.sameFileRanks(file, piece)) > 1 {
+				stacked++
+			}
+		}
+		if stacked > 1 {
+			return order + numeral(fileNumber(from.File, piece.Red), piece.Red)
+		}
+	}
+	return order + PieceName(piece)
+}
+
+// 纵线上和piece相同的棋子所在的行，按照从前到后排列
+func (pos *Position) sameFileRanks(file int, piece Piece) []int {
+	ranks := make([]int, 0, 2)
+	for rank := 0; rank < Ranks; rank++ {
+		if pos.Squares[rank][file] == piece {
+			ranks = append(ranks, rank)
+		}
+	}
+	if piece.Red {
+		sort.Sort(sort.Reverse(sort.IntSlice(ranks)))
+	}
+	return ranks
+}
+
+// 记谱中写法不同但意义相同的字
+var chineseVariants = strings.NewReplacer(
+	"俥", "车", "車", "车",
+	"傌", "马", "馬", "马",
+	"象", "相",
+	"士", "仕",
+	"帥", "帅", "将", "帅", "將", "帅",
+	"砲", "炮", "包", "炮",
+	"卒", "兵",
+	"進", "进", "後", "后",
+	"一", "1", "二", "2", "三", "3", "四", "4", "五", "5",
+	"六", "6", "七", "7", "八", "8", "九", "9",
+	"１", "1", "２", "2", "３", "3", "４", "4", "５", "5",
+	"６", "6", "７", "7", "８", "8", "９", "9",
+	" ", "", "　", "",
+)
+
+// NormalizeChinese 统一中文记谱的写法，用于比较两个记谱是否表示同一步棋。
+// 繁体字、红黑双方不同的棋子名称以及中文数字和阿拉伯数字都会被统一
+func NormalizeChinese(s string) string {
+	return chineseVariants.Replace(strings.TrimSpace(s))
+}
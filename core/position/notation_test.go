@@ -0,0 +1,76 @@
+package position
+
+import "testing"
+
+func TestChineseMove(t *testing.T) {
+	cases := []struct {
+		name   string
+		fen    string
+		move   string
+		expect string
+	}{
+		{"红炮平", InitialFEN, "h2e2", "炮二平五"},
+		{"红马进", InitialFEN, "b0c2", "马八进七"},
+		{"红车进", InitialFEN, "a0a1", "车九进一"},
+		{"红仕进", InitialFEN, "d0e1", "仕六进五"},
+		{"红相进", InitialFEN, "c0e2", "相七进五"},
+		{"红兵进", InitialFEN, "c3c4", "兵七进一"},
+		{"黑马进", InitialFEN, "h9g7", "马8进7"},
+		{"黑炮进", InitialFEN, "h7h0", "炮8进7"},
+		{"黑士进", InitialFEN, "f9e8", "士6进5"},
+		{"黑象进", InitialFEN, "c9e7", "象3进5"},
+		{"黑卒进", InitialFEN, "g6g5", "卒7进1"},
+		{"黑将平", "3k5/9/9/9/9/9/9/9/9/4K4 b", "d9e9", "将4平5"},
+		{"红帅退", "3k5/9/9/9/9/9/9/9/4K4/9 w", "e1e0", "帅五退一"},
+		{"黑车退", "3k5/9/9/9/9/9/9/4r4/9/4K4 b", "e2e8", "车5退6"},
+		{"起点没有棋子", InitialFEN, "e4e5", ""},
+
+		//同一纵线上的两个车，红方在上方的是前车，黑方在下方的是前车
+		{"红前车", "3k5/9/9/9/4R4/9/9/9/4R4/3K5 w", "e5e7", "前车进二"},
+		{"红后车", "3k5/9/9/9/4R4/9/9/9/4R4/3K5 w", "e1a1", "后车平九"},
+		{"黑前车", "3k5/4r4/9/9/9/4r4/9/9/9/3K5 b", "e4e2", "前车进2"},
+		{"黑后车", "3k5/4r4/9/9/9/4r4/9/9/9/3K5 b", "e8f8", "后车平6"},
+		{"红前马", "3k5/9/9/9/4N4/9/4N4/9/9/3K5 w", "e5d7", "前马进六"},
+		{"不同纵线不区分前后", "3k5/9/9/9/4R4/9/9/9/5R3/3K5 w", "e5e7", "车五进二"},
+
+		//兵卒重叠在一条纵线上用前中后加棋子名称，在两条纵线上重叠时用前后加纵线编号
+		{"红前兵", "3k5/9/9/4P4/4P4/2P6/9/9/9/3K5 w", "e6e7", "前兵进一"},
+		{"红后兵", "3k5/9/9/4P4/4P4/2P6/9/9/9/3K5 w", "e5d5", "后兵平六"},
+		{"黑三卒", "3k5/9/9/9/4p4/4p4/4p4/9/9/3K5 b", "e5e4", "后卒进1"},
+		{"黑中卒", "3k5/9/9/9/4p4/4p4/4p4/9/9/3K5 b", "e4d4", "中卒平4"},
+		{"黑前卒", "3k5/9/9/9/4p4/4p4/4p4/9/9/3K5 b", "e3e2", "前卒进1"},
+		{"两条纵线的红前兵", "3k5/9/9/2P1P4/2P1P4/9/9/9/9/3K5 w", "e6e7", "前五进一"},
+		{"两条纵线的红后兵", "3k5/9/9/2P1P4/2P1P4/9/9/9/9/3K5 w", "c5b5", "后七平八"},
+		{"两条纵线的黑前卒", "3k5/9/9/9/9/9/4p1p2/4p1p2/9/3K5 b", "g2g1", "前7进1"},
+		{"两条纵线的黑后卒", "3k5/9/9/9/9/9/4p1p2/4p1p2/9/3K5 b", "e3d3", "后5平4"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pos := mustParseFEN(t, c.fen)
+			if got := pos.ChineseMove(mustParseMove(t, c.move)); got != c.expect {
+				t.Fatalf("%s: got %q, expect %q", c.move, got, c.expect)
+			}
+		})
+	}
+}
+
+func TestNormalizeChinese(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"炮二平五", "炮2平5"},
+		{"炮二平五", "砲二平五"},
+		{"马8进7", "馬８進７"},
+		{"后车平九", "後俥平九"},
+		{"将4平5", "帅四平五"},
+		{" 兵七进一 ", "卒7进1"},
+	}
+	for _, c := range cases {
+		if NormalizeChinese(c.a) != NormalizeChinese(c.b) {
+			t.Errorf("%q and %q should be the same move", c.a, c.b)
+		}
+	}
+	if NormalizeChinese("炮二平五") == NormalizeChinese("炮二进五") {
+		t.Errorf("different moves should not be the same")
+	}
+}
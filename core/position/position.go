@@ -0,0 +1,190 @@
+package position
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessman"
+)
+
+const (
+	Ranks = 10 //棋盘行数
+	Files = 9  //棋盘列数
+)
+
+// Piece 局面上的一个棋子，Code为空表示没有棋子
+type Piece struct {
+	Code core.ChessmanCode
+	Red  bool
+}
+
+// Square 以红方视角表示的棋盘位置。
+// File从红方左手边开始为0~8，对应ICCS的a~i；Rank从红方底线开始为0~9
+type Square struct {
+	File int
+	Rank int
+}
+
+// Position 以红方视角表示的局面，和棋盘的俯视图方向无关
+type Position struct {
+	Squares   [Ranks][Files]Piece
+	RedToMove bool
+}
+
+// FromMatrix 将核心层棋盘转换成局面。
+// 核心层棋盘的坐标相对于棋盘下方的玩家，需要知道红方阵营以及红方是否位于棋盘下方
+func FromMatrix(matrix [][]chessman.ChessmanInterface, redGroup core.ChessmanGroup, redIsDown, redToMove bool) Position {
+	pos := Position{RedToMove: redToMove}
+	for y, row := range matrix {
+		for x, cm := range row {
+			if cm == nil {
+				continue
+			}
+			sq := ToSquare(core.Coordinate{X: x, Y: y}, redIsDown)
+			pos.Squares[sq.Rank][sq.File] = Piece{
+				Code: cm.GetChessmanCode(),
+				Red:  cm.GetChessmanGroup() == redGroup,
+			}
+		}
+	}
+	return pos
+}
+
+// ToSquare 将核心层坐标转换成红方视角的位置
+func ToSquare(co core.Coordinate, redIsDown bool) Square {
+	if redIsDown {
+		return Square{File: 8 - co.X, Rank: co.Y}
+	}
+	return Square{File: co.X, Rank: 9 - co.Y}
+}
+
+// ToCoordinate 将红方视角的位置转换成核心层坐标
+func ToCoordinate(sq Square, redIsDown bool) core.Coordinate {
+	if redIsDown {
+		return core.Coordinate{X: 8 - sq.File, Y: sq.Rank}
+	}
+	return core.Coordinate{X: sq.File, Y: 9 - sq.Rank}
+}
+
+// At 获取位置上的棋子
+func (pos *Position) At(sq Square) Piece {
+	return pos.Squares[sq.Rank][sq.File]
+}
+
+// fen中的棋子字母，大写为红方，小写为黑方
+var fenLetters = map[core.ChessmanCode]byte{
+	core.JiangShuai: 'K',
+	core.Shi:        'A',
+	core.Xiang:      'B',
+	core.Ma:         'N',
+	core.Ju:         'R',
+	core.Pao:        'C',
+	core.BingZu:     'P',
+}
+
+// Initial 返回开局局面
+func Initial() Position {
+	pos, _ := ParseFEN(InitialFEN)
+	return pos
+}
+
+// InitialFEN 开局局面的fen
+const InitialFEN = "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNR w"
+
+// FEN 生成局面的fen串，只包含棋子和走棋方两部分
+func (pos Position) FEN() string {
+	var sb strings.Builder
+	for rank := Ranks - 1; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < Files; file++ {
+			p := pos.Squares[rank][file]
+			if len(p.Code) == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			letter := fenLetters[p.Code]
+			if !p.Red {
+				letter += 'a' - 'A'
+			}
+			sb.WriteByte(letter)
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	if pos.RedToMove {
+		sb.WriteString(" w")
+	} else {
+		sb.WriteString(" b")
+	}
+	return sb.String()
+}
+
+// ParseFEN 解析fen串，fen中走棋方之后的部分会被忽略
+func ParseFEN(fen string) (Position, error) {
+	pos := Position{RedToMove: true}
+	fields := strings.Fields(fen)
+	if len(fields) == 0 {
+		return pos, errors.New("empty fen")
+	}
+
+	rows := strings.Split(fields[0], "/")
+	if len(rows) != Ranks {
+		return pos, fmt.Errorf("invalid fen %q: expect %d ranks", fen, Ranks)
+	}
+	for i, row := range rows {
+		rank := Ranks - 1 - i
+		file := 0
+		for _, r := range row {
+			if r >= '1' && r <= '9' {
+				file += int(r - '0')
+				continue
+			}
+			code, ok := codeOfLetter(r)
+			if !ok {
+				return pos, fmt.Errorf("invalid fen %q: unknown piece %c", fen, r)
+			}
+			if file >= Files {
+				return pos, fmt.Errorf("invalid fen %q: rank %d is too long", fen, rank)
+			}
+			pos.Squares[rank][file] = Piece{Code: code, Red: r >= 'A' && r <= 'Z'}
+			file++
+		}
+		if file != Files {
+			return pos, fmt.Errorf("invalid fen %q: rank %d has %d files", fen, rank, file)
+		}
+	}
+
+	if len(fields) > 1 && (fields[1] == "b" || fields[1] == "B") {
+		pos.RedToMove = false
+	}
+	return pos, nil
+}
+
+func codeOfLetter(r rune) (core.ChessmanCode, bool) {
+	if r >= 'a' && r <= 'z' {
+		r -= 'a' - 'A'
+	}
+	// fen中也常用H表示马，E表示象
+	switch r {
+	case 'H':
+		return core.Ma, true
+	case 'E':
+		return core.Xiang, true
+	}
+	for code, letter := range fenLetters {
+		if rune(letter) == r {
+			return code, true
+		}
+	}
+	return "", false
+}
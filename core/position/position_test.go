@@ -0,0 +1,124 @@
+package position
+
+import (
+	"testing"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessman"
+)
+
+func mustParseFEN(t *testing.T, fen string) Position {
+	t.Helper()
+	pos, err := ParseFEN(fen)
+	if err != nil {
+		t.Fatalf("parse fen %q: %v", fen, err)
+	}
+	return pos
+}
+
+// 解析fen之后再生成fen，应该得到原来的串
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		InitialFEN,
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C2C4/9/RNBAKABNR b",
+		"3k5/9/9/9/9/9/9/9/4R4/4K4 b",
+		"3akab2/9/4b4/p3p3p/2p6/6P2/P3P3P/4B4/4A4/2BAK4 w",
+		"9/9/9/9/9/9/9/9/9/9 w",
+	}
+	for _, fen := range fens {
+		pos := mustParseFEN(t, fen)
+		if got := pos.FEN(); got != fen {
+			t.Errorf("fen %q round trip to %q", fen, got)
+		}
+	}
+
+	pos := Initial()
+	if !pos.RedToMove {
+		t.Fatalf("red should move first")
+	}
+	checks := map[Square]Piece{
+		{File: 0, Rank: 0}: {Code: core.Ju, Red: true},
+		{File: 4, Rank: 0}: {Code: core.JiangShuai, Red: true},
+		{File: 7, Rank: 2}: {Code: core.Pao, Red: true},
+		{File: 1, Rank: 9}: {Code: core.Ma, Red: false},
+		{File: 8, Rank: 6}: {Code: core.BingZu, Red: false},
+		{File: 4, Rank: 4}: {},
+	}
+	for sq, expect := range checks {
+		if p := pos.At(sq); p != expect {
+			t.Errorf("%v: got %+v, expect %+v", sq, p, expect)
+		}
+	}
+}
+
+// fen中的H、E也表示马和象，走棋方之后的部分被忽略
+func TestFENVariants(t *testing.T) {
+	pos := mustParseFEN(t, "rheakaehr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RHEAKAEHR w - - 0 1")
+	if got := pos.FEN(); got != InitialFEN {
+		t.Fatalf("got %q, expect %q", got, InitialFEN)
+	}
+	pos = mustParseFEN(t, "3k5/9/9/9/9/9/9/9/9/4K4 B")
+	if pos.RedToMove {
+		t.Fatalf("upper case B should mean black to move")
+	}
+}
+
+func TestInvalidFEN(t *testing.T) {
+	fens := []string{
+		"",
+		"   ",
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/RNBAKABNR w",     //少一行
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/9/RNBAKABNR w", //多一行
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNRR w",  //一行超过9列
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABN w",    //一行不足9列
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABN2 w",   //数字之后超过9列
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNX w",   //未知的棋子
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKAB0NR w",  //0不是合法的空格数
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNR1 w",  //末尾多出空格数
+		"rnbakabnr/9/1c5c1/p1p1p1p1p/10/9/P1P1P1P1P/1C5C1/9/RNBAKABNR w",  //两位数
+	}
+	for _, fen := range fens {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("fen %q should be invalid", fen)
+		}
+	}
+}
+
+// 核心层坐标和红方视角的位置互相转换，红方在上方或者下方都要正确
+func TestCoordinateConversion(t *testing.T) {
+	cases := []struct {
+		co        core.Coordinate
+		redIsDown bool
+		sq        Square
+	}{
+		{core.Coordinate{X: 0, Y: 0}, true, Square{File: 8, Rank: 0}},
+		{core.Coordinate{X: 1, Y: 2}, true, Square{File: 7, Rank: 2}},
+		{core.Coordinate{X: 0, Y: 0}, false, Square{File: 0, Rank: 9}},
+		{core.Coordinate{X: 1, Y: 2}, false, Square{File: 1, Rank: 7}},
+	}
+	for _, c := range cases {
+		if got := ToSquare(c.co, c.redIsDown); got != c.sq {
+			t.Errorf("%v redIsDown=%v: got %v, expect %v", c.co, c.redIsDown, got, c.sq)
+		}
+		if got := ToCoordinate(c.sq, c.redIsDown); got != c.co {
+			t.Errorf("%v redIsDown=%v: got %v, expect %v", c.sq, c.redIsDown, got, c.co)
+		}
+	}
+}
+
+// 红方在上方的棋盘转换成局面之后仍然是红方视角
+func TestFromMatrix(t *testing.T) {
+	matrix := make([][]chessman.ChessmanInterface, Ranks)
+	for y := range matrix {
+		matrix[y] = make([]chessman.ChessmanInterface, Files)
+	}
+	//黑将在棋盘下方，红帅和红车在棋盘上方
+	matrix[0][5] = chessman.NewChessman(core.JiangShuai, "", core.Group2, core.Coordinate{X: 5, Y: 0})
+	matrix[9][4] = chessman.NewChessman(core.JiangShuai, "", core.Group1, core.Coordinate{X: 4, Y: 9})
+	matrix[8][4] = chessman.NewChessman(core.Ju, "", core.Group1, core.Coordinate{X: 4, Y: 8})
+
+	pos := FromMatrix(matrix, core.Group1, false, false)
+	if got := pos.FEN(); got != "5k3/9/9/9/9/9/9/9/4R4/4K4 b" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,143 @@
+package ecco
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+//go:embed ecco.txt
+var defaultTable string
+
+// MaxPlies 分类时最多查看的步数
+const MaxPlies = 30
+
+// Opening ECCO开局
+type Opening struct {
+	Code  string          //ECCO编码，例如C00
+	Name  string          //中文名称，例如中炮对屏风马
+	Moves []position.Move //从开局局面开始的定义着法
+}
+
+// String 编码加名称，例如 C00 中炮对屏风马
+func (op Opening) String() string {
+	return op.Code + " " + op.Name
+}
+
+// Classifier 根据着法判断ECCO开局。开局用走完定义着法后的局面识别，左右对称的局面视为同一局面
+type Classifier struct {
+	openings map[uint64]Opening
+	start    *Opening //没有定义着法的开局，其他开局都不匹配时使用
+}
+
+// NewClassifier 用开局表创建分类器，局面相同的开局以后面的为准
+func NewClassifier(openings []Opening) *Classifier {
+	c := &Classifier{openings: make(map[uint64]Opening)}
+	for i, op := range openings {
+		if len(op.Moves) == 0 {
+			c.start = &openings[i]
+			continue
+		}
+		pos := position.Initial()
+		for _, m := range op.Moves {
+			pos.Play(m)
+		}
+		c.openings[key(pos)] = op
+	}
+	return c
+}
+
+var defaultClassifier = NewClassifier(mustParse(defaultTable))
+
+// Default 使用内置ECCO开局表的分类器
+func Default() *Classifier {
+	return defaultClassifier
+}
+
+// Parse 解析开局表，每行为 编码 名称: ICCS着法，忽略空行和以 # 开头的注释行
+func Parse(r io.Reader) ([]Opening, error) {
+	openings := make([]Opening, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		head, moves, ok := strings.Cut(line, ":")
+		fields := strings.Fields(head)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expect \"code name: moves\"", lineNo)
+		}
+		op := Opening{Code: fields[0], Name: fields[1]}
+		for _, s := range strings.Fields(moves) {
+			m, err := position.ParseMove(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			op.Moves = append(op.Moves, m)
+		}
+		openings = append(openings, op)
+	}
+	return openings, scanner.Err()
+}
+
+func mustParse(table string) []Opening {
+	openings, err := Parse(strings.NewReader(table))
+	if err != nil {
+		panic(err)
+	}
+	return openings
+}
+
+// Classify 按照前MaxPlies步判断开局，返回最后一个匹配的开局。
+// 只识别从开局局面开始的对局，没有下过棋时返回false
+func (c *Classifier) Classify(start position.Position, moves []position.Move) (Opening, bool) {
+	if len(moves) == 0 || start != position.Initial() {
+		return Opening{}, false
+	}
+	var found *Opening
+	pos := start
+	for i, m := range moves {
+		if i >= MaxPlies {
+			break
+		}
+		pos.Play(m)
+		if op, ok := c.openings[key(pos)]; ok {
+			found = &op
+		}
+	}
+	if found == nil {
+		found = c.start
+	}
+	if found == nil {
+		return Opening{}, false
+	}
+	return *found, true
+}
+
+// ClassifyGame 判断棋局的开局
+func (c *Classifier) ClassifyGame(game chessgame.ChessGameInterface) (Opening, bool) {
+	history := game.GetHistory()
+	moves := make([]position.Move, len(history))
+	for i, st := range history {
+		moves[i] = game.FormatMove(st)
+	}
+	return c.Classify(game.GetInitialPosition(), moves)
+}
+
+// 局面和左右对称局面的哈希值中较小的一个
+func key(pos position.Position) uint64 {
+	h := pos.Hash()
+	mirror := pos.Mirror()
+	if mh := mirror.Hash(); mh < h {
+		return mh
+	}
+	return h
+}
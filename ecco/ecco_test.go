@@ -0,0 +1,65 @@
+package ecco
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+func parseMoves(t *testing.T, s string) []position.Move {
+	moves := make([]position.Move, 0)
+	for _, f := range strings.Fields(s) {
+		m, err := position.ParseMove(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+func TestClassify(t *testing.T) {
+	c := Default()
+	cases := []struct {
+		moves string
+		code  string
+	}{
+		{"h2e2", "B00"},
+		{"b2e2", "B00"}, //炮八平五和炮二平五对称
+		{"h2e2 h9g7 h0g2 b9c7", "C00"},
+		{"h0g2 b9c7 h2e2 h9g7", "C00"}, //换序到达中炮对屏风马
+		{"h2e2 h9g7 h0g2 b9c7 i0h0 i9h9", "C00"},
+		{"h2e2 h7e7 h0g2 h9g7 i0h0", "D10"},
+		{"c3c4 b7c7", "E10"},
+		{"a3a4", "A08"}, //兵九进一和兵一进一对称
+		{"e3e4", "A00"},
+	}
+	for _, tc := range cases {
+		op, ok := c.Classify(position.Initial(), parseMoves(t, tc.moves))
+		if !ok || op.Code != tc.code {
+			t.Errorf("%s: expect %s, got %s", tc.moves, tc.code, op)
+		}
+	}
+
+	if _, ok := c.Classify(position.Initial(), nil); ok {
+		t.Error("game without moves should not be classified")
+	}
+	start, _ := position.ParseFEN("3k5/9/9/9/9/9/9/9/4R4/4K4 w")
+	if _, ok := c.Classify(start, parseMoves(t, "e1e8")); ok {
+		t.Error("game from a custom position should not be classified")
+	}
+}
+
+func TestParse(t *testing.T) {
+	openings, err := Parse(strings.NewReader("# 注释\n\nX01 测试: h2e2 h9g7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openings) != 1 || openings[0].String() != "X01 测试" || len(openings[0].Moves) != 2 {
+		t.Fatalf("unexpected openings %+v", openings)
+	}
+	if _, err = Parse(strings.NewReader("X01 h2e2")); err == nil {
+		t.Fatal("line without colon should fail")
+	}
+}
@@ -0,0 +1,115 @@
+package i18n
+
+// 英文的译文
+var en = map[string]string{
+	//启动菜单
+	"中国象棋":      "Chinese Chess",
+	"模式":        "Mode",
+	"双人对战":      "Hot seat",
+	"人机对战":      "vs Computer",
+	"网络对战":      "Online",
+	"难度":        "Level",
+	"入门":        "Beginner",
+	"初级":        "Easy",
+	"中级":        "Medium",
+	"高级":        "Hard",
+	"执子":        "Color",
+	"执红先手":      "Red, first",
+	"执黑后手":      "Black, second",
+	"用时":        "Time",
+	"不限时":       "No limit",
+	"5分钟":       "5 min",
+	"10分钟":      "10 min",
+	"20分钟":      "20 min",
+	"音效":        "Sound",
+	"关闭":        "Off",
+	"小":         "Low",
+	"中":         "Medium",
+	"大":         "High",
+	"语言":        "Language",
+	"主题":        "Theme",
+	"默认":        "Default",
+	"矢量汉字":      "Vector, characters",
+	"矢量字母":      "Vector, letters",
+	"%s（%d/%d）": "%s (%d/%d)",
+	"服务器":       "Server",
+	"房间":        "Room",
+	"开始":        "Start",
+	"正在连接服务器…":  "Connecting to server…",
+	"连接失败：%s":   "Connection failed: %s",
+
+	//对局
+	"红方":                 "Red",
+	"黑方":                 "Black",
+	"将军":                 "Check",
+	"结果：%s. ":            "Result: %s. ",
+	"吃棋：%s. ":            "Captured: %s. ",
+	"消息：%s. ":            "Message: %s. ",
+	"对局结束，胜：%s. ":        "Game over, winner: %s. ",
+	"对局结束，和棋. ":          "Game over, draw. ",
+	"将军. ":               "Check. ",
+	"正在连接服务器":            "Connecting to server",
+	"等待对手加入":             "Waiting for opponent",
+	"对局中":                "Playing",
+	"连接断开，正在重连":          "Disconnected, reconnecting",
+	"连接已断开":              "Disconnected",
+	"观战中":                "Watching",
+	"，对手断线，等待重连":         ", opponent disconnected",
+	"，轮到你下棋":             ", your move",
+	"，等待对手下棋":            ", opponent to move",
+	"  红方 %s 黑方 %s":      "  Red %s Black %s",
+	"红方 %s  黑方 %s":       "Red %s  Black %s",
+	"C 换红黑  S 换上下  F 翻转": "C color  S side  F flip",
+	"F 翻转棋盘":             "F flip board",
+
+	//着法列表和吃子
+	"着法":               "Moves",
+	"  电脑思考中…":         "  thinking…",
+	"复盘 第%d/%d步":       "Review %d/%d",
+	"红方吃子":             "Captured by Red",
+	"黑方吃子":             "Captured by Black",
+	"← → 翻看  Esc 回到对局": "← → browse  Esc back",
+
+	//战绩和积分榜
+	"对局保存失败：%s": "Failed to save game: %s",
+	"评分保存失败：%s": "Failed to save rating: %s",
+	"玩家%d":      "Player %d",
+	"%s 执红%d胜%d和%d负 执黑%d胜%d和%d负 吃子%d":     "%s Red %d-%d-%d Black %d-%d-%d captures %d",
+	"%s 玩家%d  Elo %.0f  Glicko %.0f±%.0f": "%s Player %d  Elo %.0f  Glicko %.0f±%.0f",
+	"积分榜":                       "Leaderboard",
+	"%d. 玩家%d  %.0f  %d胜%d和%d负": "%d. Player %d  %.0f  %d-%d-%d",
+
+	//杀局练习
+	"提示：%s":   "Hint: %s",
+	"不符合走棋规则": "Illegal move",
+	"不对，再想想":  "Not quite, try again",
+	"正确，继续":   "Correct, go on",
+	"解题成功！点击棋盘进入下一题":                       "Solved! Click the board for the next puzzle",
+	"解完了，走错%d次、提示%d次。点击棋盘进入下一题":            "Done with %d mistakes and %d hints. Click the board for the next puzzle",
+	"第%d/%d题 %s  本题%d/%d  总成功率%.0f%%（%d次）": "Puzzle %d/%d %s  this %d/%d  success %.0f%% (%d tries)",
+	"%s（%d步杀）":            "%s (mate in %d)",
+	"%s（%d步）":             "%s (%d moves)",
+	"H 提示  R 重新开始  N 下一题": "H hint  R restart  N next",
+
+	//排局欣赏
+	"第%d/%d局 %s": "Study %d/%d %s",
+	"  第%d步 %s":  "  ply %d %s",
+	"→ 前进  ← 后退  ↑↓ 选择变着  Home/End 开始/结束  N/P 换局": "→ forward  ← back  ↑↓ variation  Home/End start/end  N/P study",
+	"变着：%s": "Variations: %s",
+	"结束":    "End",
+
+	//对局分析
+	"分析 第%d/%d步":  "Analysis %d/%d",
+	" %s%s（-%d）":  " %s%s (-%d)",
+	"缓着":          "inaccuracy",
+	"失误":          "mistake",
+	"败着":          "blunder",
+	"最佳（深度%d）：%s": "Best (depth %d): %s",
+	"已分析%d/%d个局面": "Analyzed %d/%d positions",
+	"引擎出错：%s":     "Engine error: %s",
+	"分析完成，%d步有问题": "Done, %d questionable moves",
+	"← → 翻看  Home/End 开始/结尾  M 下一个问题手": "← → browse  Home/End start/end  M next mistake",
+	"红方杀棋":  "Red mates",
+	"黑方杀棋":  "Black mates",
+	"红方%+d": "Red %+d",
+}
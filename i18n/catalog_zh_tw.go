@@ -0,0 +1,115 @@
+package i18n
+
+// 繁体中文的译文
+var zhTW = map[string]string{
+	//启动菜单
+	"中国象棋":      "中國象棋",
+	"模式":        "模式",
+	"双人对战":      "雙人對戰",
+	"人机对战":      "人機對戰",
+	"网络对战":      "網路對戰",
+	"难度":        "難度",
+	"入门":        "入門",
+	"初级":        "初級",
+	"中级":        "中級",
+	"高级":        "高級",
+	"执子":        "執子",
+	"执红先手":      "執紅先手",
+	"执黑后手":      "執黑後手",
+	"用时":        "用時",
+	"不限时":       "不限時",
+	"5分钟":       "5分鐘",
+	"10分钟":      "10分鐘",
+	"20分钟":      "20分鐘",
+	"音效":        "音效",
+	"关闭":        "關閉",
+	"小":         "小",
+	"中":         "中",
+	"大":         "大",
+	"语言":        "語言",
+	"主题":        "主題",
+	"默认":        "預設",
+	"矢量汉字":      "向量漢字",
+	"矢量字母":      "向量字母",
+	"%s（%d/%d）": "%s（%d/%d）",
+	"服务器":       "伺服器",
+	"房间":        "房間",
+	"开始":        "開始",
+	"正在连接服务器…":  "正在連線伺服器…",
+	"连接失败：%s":   "連線失敗：%s",
+
+	//对局
+	"红方":                 "紅方",
+	"黑方":                 "黑方",
+	"将军":                 "將軍",
+	"结果：%s. ":            "結果：%s. ",
+	"吃棋：%s. ":            "吃棋：%s. ",
+	"消息：%s. ":            "訊息：%s. ",
+	"对局结束，胜：%s. ":        "對局結束，勝：%s. ",
+	"对局结束，和棋. ":          "對局結束，和棋. ",
+	"将军. ":               "將軍. ",
+	"正在连接服务器":            "正在連線伺服器",
+	"等待对手加入":             "等待對手加入",
+	"对局中":                "對局中",
+	"连接断开，正在重连":          "連線中斷，正在重連",
+	"连接已断开":              "連線已中斷",
+	"观战中":                "觀戰中",
+	"，对手断线，等待重连":         "，對手斷線，等待重連",
+	"，轮到你下棋":             "，輪到你下棋",
+	"，等待对手下棋":            "，等待對手下棋",
+	"  红方 %s 黑方 %s":      "  紅方 %s 黑方 %s",
+	"红方 %s  黑方 %s":       "紅方 %s  黑方 %s",
+	"C 换红黑  S 换上下  F 翻转": "C 換紅黑  S 換上下  F 翻轉",
+	"F 翻转棋盘":             "F 翻轉棋盤",
+
+	//着法列表和吃子
+	"着法":               "著法",
+	"  电脑思考中…":         "  電腦思考中…",
+	"复盘 第%d/%d步":       "複盤 第%d/%d步",
+	"红方吃子":             "紅方吃子",
+	"黑方吃子":             "黑方吃子",
+	"← → 翻看  Esc 回到对局": "← → 翻看  Esc 回到對局",
+
+	//战绩和积分榜
+	"对局保存失败：%s": "對局儲存失敗：%s",
+	"评分保存失败：%s": "評分儲存失敗：%s",
+	"玩家%d":      "玩家%d",
+	"%s 执红%d胜%d和%d负 执黑%d胜%d和%d负 吃子%d":     "%s 執紅%d勝%d和%d負 執黑%d勝%d和%d負 吃子%d",
+	"%s 玩家%d  Elo %.0f  Glicko %.0f±%.0f": "%s 玩家%d  Elo %.0f  Glicko %.0f±%.0f",
+	"积分榜":                       "積分榜",
+	"%d. 玩家%d  %.0f  %d胜%d和%d负": "%d. 玩家%d  %.0f  %d勝%d和%d負",
+
+	//杀局练习
+	"提示：%s":   "提示：%s",
+	"不符合走棋规则": "不符合走棋規則",
+	"不对，再想想":  "不對，再想想",
+	"正确，继续":   "正確，繼續",
+	"解题成功！点击棋盘进入下一题":                       "解題成功！點擊棋盤進入下一題",
+	"解完了，走错%d次、提示%d次。点击棋盘进入下一题":            "解完了，走錯%d次、提示%d次。點擊棋盤進入下一題",
+	"第%d/%d题 %s  本题%d/%d  总成功率%.0f%%（%d次）": "第%d/%d題 %s  本題%d/%d  總成功率%.0f%%（%d次）",
+	"%s（%d步杀）":            "%s（%d步殺）",
+	"%s（%d步）":             "%s（%d步）",
+	"H 提示  R 重新开始  N 下一题": "H 提示  R 重新開始  N 下一題",
+
+	//排局欣赏
+	"第%d/%d局 %s": "第%d/%d局 %s",
+	"  第%d步 %s":  "  第%d步 %s",
+	"→ 前进  ← 后退  ↑↓ 选择变着  Home/End 开始/结束  N/P 换局": "→ 前進  ← 後退  ↑↓ 選擇變著  Home/End 開始/結束  N/P 換局",
+	"变着：%s": "變著：%s",
+	"结束":    "結束",
+
+	//对局分析
+	"分析 第%d/%d步":  "分析 第%d/%d步",
+	" %s%s（-%d）":  " %s%s（-%d）",
+	"缓着":          "緩著",
+	"失误":          "失誤",
+	"败着":          "敗著",
+	"最佳（深度%d）：%s": "最佳（深度%d）：%s",
+	"已分析%d/%d个局面": "已分析%d/%d個局面",
+	"引擎出错：%s":     "引擎出錯：%s",
+	"分析完成，%d步有问题": "分析完成，%d步有問題",
+	"← → 翻看  Home/End 开始/结尾  M 下一个问题手": "← → 翻看  Home/End 開始/結尾  M 下一個問題手",
+	"红方杀棋":  "紅方殺棋",
+	"黑方杀棋":  "黑方殺棋",
+	"红方%+d": "紅方%+d",
+}
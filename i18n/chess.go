@@ -0,0 +1,202 @@
+package i18n
+
+import (
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 内核和服务器事件的类型、拒绝原因、结束原因，以及服务器拒绝请求的原因的译文
+var reasons = map[Lang]map[string]string{
+	ZhCN: {
+		"DONE":                           "走棋",
+		"ERR":                            "错误",
+		"FIN":                            "对局结束",
+		"UNDONE":                         "悔棋",
+		"invalidMove":                    "走法不符合规则",
+		"ownPiece":                       "目标位置是自己的棋子",
+		"noChessman":                     "起点没有这个棋子",
+		"nothingToUndo":                  "没有可以悔的棋",
+		"undoFailed":                     "悔棋失败",
+		"closed":                         "对局已关闭",
+		"noStatement":                    "没有给出着法",
+		"notRunning":                     "对局没有进行",
+		"undoNotAllowed":                 "不允许悔棋",
+		"notYourChessman":                "不是你的棋子",
+		"notYourTurn":                    "还没轮到你",
+		"spectator":                      "观战者不能走棋",
+		"capture":                        "吃掉将帅",
+		"faceToFace":                     "将帅见面",
+		"timeout":                        "超时判负",
+		"abandoned":                      "弃局判负",
+		"maxPlies":                       "超过步数限制，判和",
+		"noMoves":                        "无棋可走",
+		"illegalMove":                    "走了不符合规则的棋",
+		"engineError":                    "引擎出错",
+		"join a room first":              "请先加入房间",
+		"room not found":                 "房间不存在",
+		"room is full":                   "房间已满",
+		"session expired":                "会话已过期",
+		"spectators cannot move":         "观战者不能走棋",
+		"game is not running":            "对局没有进行",
+		"matchmaking is not enabled":     "服务器没有开启自动匹配",
+		"anonymous players cannot queue": "匿名玩家不能自动匹配",
+		"player is already in the queue": "已经在排队",
+		"queue timeout":                  "排队超时，没有匹配到对手",
+		"opponent left the queue":        "对手已经离开",
+	},
+	ZhTW: {
+		"DONE":                           "走棋",
+		"ERR":                            "錯誤",
+		"FIN":                            "對局結束",
+		"UNDONE":                         "悔棋",
+		"invalidMove":                    "走法不符合規則",
+		"ownPiece":                       "目標位置是自己的棋子",
+		"noChessman":                     "起點沒有這個棋子",
+		"nothingToUndo":                  "沒有可以悔的棋",
+		"undoFailed":                     "悔棋失敗",
+		"closed":                         "對局已關閉",
+		"noStatement":                    "沒有給出著法",
+		"notRunning":                     "對局沒有進行",
+		"undoNotAllowed":                 "不允許悔棋",
+		"notYourChessman":                "不是你的棋子",
+		"notYourTurn":                    "還沒輪到你",
+		"spectator":                      "觀戰者不能走棋",
+		"capture":                        "吃掉將帥",
+		"faceToFace":                     "將帥見面",
+		"timeout":                        "超時判負",
+		"abandoned":                      "棄局判負",
+		"maxPlies":                       "超過步數限制，判和",
+		"noMoves":                        "無棋可走",
+		"illegalMove":                    "走了不符合規則的棋",
+		"engineError":                    "引擎出錯",
+		"join a room first":              "請先加入房間",
+		"room not found":                 "房間不存在",
+		"room is full":                   "房間已滿",
+		"session expired":                "會話已過期",
+		"spectators cannot move":         "觀戰者不能走棋",
+		"game is not running":            "對局沒有進行",
+		"matchmaking is not enabled":     "伺服器沒有開啟自動匹配",
+		"anonymous players cannot queue": "匿名玩家不能自動匹配",
+		"player is already in the queue": "已經在排隊",
+		"queue timeout":                  "排隊逾時，沒有匹配到對手",
+		"opponent left the queue":        "對手已經離開",
+	},
+	En: {
+		"DONE":                           "Move",
+		"ERR":                            "Error",
+		"FIN":                            "Game over",
+		"UNDONE":                         "Undo",
+		"invalidMove":                    "Illegal move",
+		"ownPiece":                       "Target square holds your own piece",
+		"noChessman":                     "No such piece on the starting square",
+		"nothingToUndo":                  "No move to undo",
+		"undoFailed":                     "Undo failed",
+		"closed":                         "Game closed",
+		"noStatement":                    "No move was given",
+		"notRunning":                     "Game is not running",
+		"undoNotAllowed":                 "Undo is not allowed",
+		"notYourChessman":                "Not your piece",
+		"notYourTurn":                    "Not your turn",
+		"spectator":                      "Spectators cannot move",
+		"capture":                        "King captured",
+		"faceToFace":                     "Kings facing each other",
+		"timeout":                        "Lost on time",
+		"abandoned":                      "Lost by abandonment",
+		"maxPlies":                       "Move limit reached, draw",
+		"noMoves":                        "No legal moves",
+		"illegalMove":                    "Played an illegal move",
+		"engineError":                    "Engine error",
+		"join a room first":              "Join a room first",
+		"room not found":                 "Room not found",
+		"room is full":                   "Room is full",
+		"session expired":                "Session expired",
+		"spectators cannot move":         "Spectators cannot move",
+		"game is not running":            "Game is not running",
+		"matchmaking is not enabled":     "Matchmaking is not enabled on this server",
+		"anonymous players cannot queue": "Anonymous players cannot use matchmaking",
+		"player is already in the queue": "Already in the queue",
+		"queue timeout":                  "No opponent found in time",
+		"opponent left the queue":        "Opponent left the queue",
+	},
+}
+
+// 棋子名称，依次是车、马、相象、仕士、帅将、炮、兵卒
+var pieceCodes = []core.ChessmanCode{core.Ju, core.Ma, core.Xiang, core.Shi, core.JiangShuai, core.Pao, core.BingZu}
+
+var pieceNames = map[Lang][2][]string{
+	ZhCN: {
+		{"车", "马", "相", "仕", "帅", "炮", "兵"},
+		{"车", "马", "象", "士", "将", "炮", "卒"},
+	},
+	ZhTW: {
+		{"俥", "傌", "相", "仕", "帥", "炮", "兵"},
+		{"車", "馬", "象", "士", "將", "砲", "卒"},
+	},
+	En: {
+		{"Rook", "Horse", "Elephant", "Advisor", "King", "Cannon", "Pawn"},
+		{"Rook", "Horse", "Elephant", "Advisor", "King", "Cannon", "Pawn"},
+	},
+}
+
+// 中文记谱转换成繁体中文
+var zhTWNotation = strings.NewReplacer("车", "車", "马", "馬", "帅", "帥", "将", "將", "进", "進", "后", "後")
+
+// 中文记谱转换成WXF记法，例如炮二平五记为C2.5，前车进一记为+R+1
+var wxfNotation = strings.NewReplacer(
+	"车", "R", "马", "H", "相", "E", "象", "E", "仕", "A", "士", "A", "帅", "K", "将", "K", "炮", "C", "兵", "P", "卒", "P",
+	"进", "+", "退", "-", "平", ".", "前", "+", "中", "=", "后", "-",
+	"一", "1", "二", "2", "三", "3", "四", "4", "五", "5", "六", "6", "七", "7", "八", "8", "九", "9",
+)
+
+// Reason 按照当前语言翻译消息原因，没有译文时返回原文
+func Reason(msg string) string {
+	return Current().Reason(msg)
+}
+
+// Reason 翻译消息原因，没有译文时返回原文
+func (l Lang) Reason(msg string) string {
+	if s, ok := reasons[l][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// PieceName 按照当前语言获取棋子的名称
+func PieceName(code core.ChessmanCode, red bool) string {
+	return Current().PieceName(code, red)
+}
+
+// PieceName 棋子的名称，红黑双方的名称可能不同
+func (l Lang) PieceName(code core.ChessmanCode, red bool) string {
+	names, ok := pieceNames[l]
+	if !ok {
+		names = pieceNames[ZhCN]
+	}
+	side := names[1]
+	if red {
+		side = names[0]
+	}
+	for i, c := range pieceCodes {
+		if c == code {
+			return side[i]
+		}
+	}
+	return ""
+}
+
+// Move 按照当前语言转换中文记谱
+func Move(notation string) string {
+	return Current().Move(notation)
+}
+
+// Move 转换中文记谱，例如position.Position.ChineseMove的结果。英文使用WXF记法
+func (l Lang) Move(notation string) string {
+	switch l {
+	case ZhTW:
+		return zhTWNotation.Replace(notation)
+	case En:
+		return wxfNotation.Replace(notation)
+	}
+	return notation
+}
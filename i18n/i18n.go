@@ -0,0 +1,90 @@
+// Package i18n 界面文字的本地化，支持简体中文、繁体中文和英文。
+// 界面代码中的文字就是简体中文的原文，同时作为查找其它语言译文的键，目录中没有译文时显示原文。
+// 内核和服务器事件中的原因（RejectReason、Termination等）是英文的代码，由Reason翻译
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Lang 界面语言
+type Lang string
+
+const (
+	ZhCN Lang = "zh-CN" //简体中文
+	ZhTW Lang = "zh-TW" //繁体中文
+	En   Lang = "en"    //英文
+)
+
+// Langs 支持的所有语言，第一个是默认语言
+var Langs = []Lang{ZhCN, ZhTW, En}
+
+// 每种语言的名称用这种语言本身书写
+var langNames = map[Lang]string{
+	ZhCN: "简体中文",
+	ZhTW: "繁體中文",
+	En:   "English",
+}
+
+// 简体中文原文到其它语言的译文
+var catalogs = map[Lang]map[string]string{
+	ZhTW: zhTW,
+	En:   en,
+}
+
+var (
+	mu      sync.RWMutex
+	current = ZhCN
+)
+
+// Name 语言的名称
+func (l Lang) Name() string {
+	return langNames[l]
+}
+
+// Parse 解析语言代码，不区分大小写，下划线和连字符都可以，例如zh_tw、en-US
+func Parse(s string) (Lang, error) {
+	s = strings.ToLower(strings.ReplaceAll(s, "_", "-"))
+	switch {
+	case s == "zh-cn", s == "zh", s == "zh-hans":
+		return ZhCN, nil
+	case s == "zh-tw", s == "zh-hk", s == "zh-hant":
+		return ZhTW, nil
+	case s == "en", strings.HasPrefix(s, "en-"):
+		return En, nil
+	}
+	return "", fmt.Errorf("unknown language %q", s)
+}
+
+// SetLang 设置界面语言
+func SetLang(l Lang) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = l
+}
+
+// Current 当前的界面语言
+func Current() Lang {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T 按照当前语言翻译界面文字，有参数时按照译文格式化
+func T(key string, args ...any) string {
+	return Current().T(key, args...)
+}
+
+// T 翻译界面文字，有参数时按照译文格式化
+func (l Lang) T(key string, args ...any) string {
+	s, ok := catalogs[l][key]
+	if !ok {
+		s = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/transport"
+)
+
+// 格式化动词，%%不算
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func verbs(s string) []string {
+	out := make([]string, 0)
+	for _, v := range verbPattern.FindAllString(s, -1) {
+		if v != "%%" {
+			out = append(out, v[len(v)-1:])
+		}
+	}
+	return out
+}
+
+// 每个目录的译文和原文的格式化参数一致，两个目录的条目相同
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, s := range catalog {
+			if a, b := verbs(key), verbs(s); len(a) != len(b) {
+				t.Errorf("%s %q: verbs %v != %v", lang, key, a, b)
+			}
+		}
+	}
+	for key := range zhTW {
+		if _, ok := en[key]; !ok {
+			t.Errorf("%q missing in en", key)
+		}
+	}
+	for key := range en {
+		if _, ok := zhTW[key]; !ok {
+			t.Errorf("%q missing in zh-TW", key)
+		}
+	}
+	for _, lang := range Langs {
+		if len(reasons[lang]) != len(reasons[ZhCN]) {
+			t.Errorf("%s has %d reasons, expect %d", lang, len(reasons[lang]), len(reasons[ZhCN]))
+		}
+	}
+}
+
+// 界面代码中用到的文字都有译文
+func TestAppStrings(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "app", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := regexp.MustCompile(`i18n\.T\(("(?:[^"\\]|\\.)*")`)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range call.FindAllStringSubmatch(string(data), -1) {
+			key, err := strconv.Unquote(m[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s: %q has no translation", filepath.Base(file), key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if s := En.T("玩家%d", 3); s != "Player 3" {
+		t.Fatalf("expect Player 3, got %s", s)
+	}
+	if s := ZhCN.T("玩家%d", 3); s != "玩家3" {
+		t.Fatalf("expect 玩家3, got %s", s)
+	}
+	//没有译文时显示原文
+	if s := En.T("没有的文字"); s != "没有的文字" {
+		t.Fatalf("unexpected %s", s)
+	}
+	if s := ZhTW.Reason("notYourTurn"); s != "還沒輪到你" {
+		t.Fatalf("unexpected %s", s)
+	}
+	if s := En.Reason("unknownReason"); s != "unknownReason" {
+		t.Fatalf("unexpected %s", s)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]Lang{"zh-CN": ZhCN, "zh_tw": ZhTW, "EN-us": En, "en": En}
+	for s, want := range cases {
+		if l, err := Parse(s); err != nil || l != want {
+			t.Errorf("%s: expect %s, got %s %v", s, want, l, err)
+		}
+	}
+	if _, err := Parse("fr"); err == nil {
+		t.Error("expect error for fr")
+	}
+}
+
+func TestPieceNameAndMove(t *testing.T) {
+	if s := ZhTW.PieceName(core.Ju, true); s != "俥" {
+		t.Fatalf("unexpected %s", s)
+	}
+	if s := ZhCN.PieceName(core.JiangShuai, false); s != "将" {
+		t.Fatalf("unexpected %s", s)
+	}
+	cases := []struct {
+		notation string
+		lang     Lang
+		want     string
+	}{
+		{"炮二平五", En, "C2.5"},
+		{"马8进7", En, "H8+7"},
+		{"前车进一", En, "+R+1"},
+		{"后马退6", ZhTW, "後馬退6"},
+		{"炮二平五", ZhCN, "炮二平五"},
+	}
+	for _, c := range cases {
+		if s := c.lang.Move(c.notation); s != c.want {
+			t.Errorf("%s %s: expect %s, got %s", c.lang, c.notation, c.want, s)
+		}
+	}
+}
+
+// 界面显示的拒绝原因和结束原因都有译文
+func TestReasonTranslations(t *testing.T) {
+	codes := []string{
+		string(chessgame.RejectInvalidMove), string(chessgame.RejectOwnPiece), string(chessgame.RejectNoChessman), string(chessgame.RejectNothingToUndo),
+		string(chessgame.RejectClosed), string(chessgame.RejectNoStatement), string(transport.RejectNotRunning), string(transport.RejectUndoNotAllowed),
+		string(transport.RejectNotYourChessman), string(transport.RejectNotYourTurn), string(transport.RejectSpectator), string(chessgame.RejectUndoFailed),
+		string(chessgame.TerminationCapture), string(chessgame.TerminationFaceToFace), string(chessgame.TerminationTimeout), string(chessgame.TerminationAbandoned),
+		string(chessgame.TerminationMaxPlies), string(chessgame.TerminationNoMoves), string(chessgame.TerminationIllegalMove), string(chessgame.TerminationEngineError),
+	}
+	for _, code := range codes {
+		if En.Reason(code) == code {
+			t.Errorf("%s has no translation", code)
+		}
+	}
+}
@@ -1,16 +1,177 @@
 package main
 
 import (
+	"flag"
+	"github.com/CXeon/xiangqi/ai"
 	"github.com/CXeon/xiangqi/app"
+	"github.com/CXeon/xiangqi/i18n"
+	"github.com/CXeon/xiangqi/puzzle"
+	"github.com/CXeon/xiangqi/study"
 	"github.com/hajimehoshi/ebiten/v2"
 	"log"
+	"os"
 )
 
 func main() {
-	ebiten.SetWindowSize(app.ScreenWidth, app.ScreenHeight)
-	ebiten.SetWindowTitle("XiangQi Demo")
-	game := app.NewGame()
+	server := flag.String("server", "", "对战服务器地址，为空时启动单机对战")
+	room := flag.String("room", "default", "网络对战的房间名称")
+	watch := flag.Bool("watch", false, "观战房间内的对局")
+	id := flag.Int("id", 0, "网络对战的玩家id，为0时匿名对局，不计算评分")
+	match := flag.Bool("match", false, "网络对战时排队自动匹配评分接近的对手，需要 -id，对局时间使用 -time")
+	puzzleMode := flag.Bool("puzzle", false, "做题模式，练习内置的杀局")
+	puzzleFile := flag.String("puzzles", "", "做题模式使用的题目文件，每行 FEN | 解答着法 # 名称")
+	studyMode := flag.Bool("study", false, "排局欣赏模式，打开内置的排局")
+	studyFile := flag.String("studies", "", "排局欣赏模式使用的排局文件，格式与PGN相似")
+	analyze := flag.Int("analyze", -1, "分析模式：回放本机保存的对局并用引擎分析，参数为对局id，0表示最近一局")
+	engine := flag.String("engine", "", "分析模式使用的UCCI引擎程序")
+	depth := flag.Int("depth", ai.DefaultUCCIDepth, "分析模式的搜索深度")
+	color := flag.String("color", "red", "单机对战时玩家1执红（red）还是执黑（black），红方先手")
+	side := flag.String("side", "down", "单机对战时玩家1坐在棋盘下方（down）还是上方（up）")
+	computer := flag.Int("computer", 0, "单机对战时玩家2由电脑操作，参数为难度1-4，0表示两个人轮流下棋")
+	timeControl := flag.Duration("time", 0, "单机对战每方的用时，例如10m，0表示不限时")
+	themeDir := flag.String("themes", "themes", "主题目录，每个子目录或者zip文件是一套主题")
+	themeName := flag.String("theme", "", "使用的主题名称，为空时使用内置的默认主题")
+	volume := flag.Float64("volume", 0.6, "音效的音量，范围0到1")
+	mute := flag.Bool("mute", false, "关闭音效")
+	lang := flag.String("lang", string(i18n.ZhCN), "界面语言：zh-CN、zh-TW或者en")
+	flag.Parse()
+
+	l, err := i18n.Parse(*lang)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i18n.SetLang(l)
+
+	app.SetVolume(*volume)
+	app.SetMuted(*mute)
+
+	if err := app.LoadThemes(*themeDir); err != nil {
+		log.Fatal(err)
+	}
+	if len(*themeName) > 0 {
+		if err := app.SetTheme(*themeName); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	//没有指定任何模式时显示启动菜单
+	local := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "color", "side", "computer", "time":
+			local = true
+		}
+	})
+
+	var game interface {
+		ebiten.Game
+		Close()
+	}
+	if *puzzleMode || len(*puzzleFile) > 0 {
+		puzzles := puzzle.Default()
+		if len(*puzzleFile) > 0 {
+			f, err := os.Open(*puzzleFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			puzzles, err = puzzle.Load(f)
+			f.Close()
+			if err != nil {
+				log.Fatalf("%s: %v", *puzzleFile, err)
+			}
+		}
+		g, err := app.NewPuzzleGame(puzzles)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else if *studyMode || len(*studyFile) > 0 {
+		studies := study.Default()
+		if len(*studyFile) > 0 {
+			f, err := os.Open(*studyFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			studies, err = study.Load(f)
+			f.Close()
+			if err != nil {
+				log.Fatalf("%s: %v", *studyFile, err)
+			}
+		}
+		g, err := app.NewStudyGame(studies)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else if *analyze >= 0 {
+		if len(*engine) == 0 {
+			log.Fatal("analysis needs -engine")
+		}
+		rec, err := app.LoadLocalGame(*analyze)
+		if err != nil {
+			log.Fatal(err)
+		}
+		e, err := ai.NewUCCI(*engine, nil, ai.UCCIOptions{Depth: *depth})
+		if err != nil {
+			log.Fatal(err)
+		}
+		g, err := app.NewAnalysisGame(rec, e)
+		if err != nil {
+			e.Close()
+			log.Fatal(err)
+		}
+		game = g
+	} else if len(*server) > 0 && *watch {
+		g, err := app.NewSpectatorGame(*server, *room)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else if len(*server) > 0 && *match {
+		log.Printf("waiting for an opponent on %s", *server)
+		g, err := app.NewMatchGame(*server, *id, *timeControl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else if len(*server) > 0 {
+		g, err := app.NewClientGame(*server, *room, *id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else if local {
+		seat := app.DefaultSeat
+		switch *color {
+		case "red":
+		case "black":
+			seat.First = false
+		default:
+			log.Fatalf("unknown color %q", *color)
+		}
+		switch *side {
+		case "down":
+		case "up":
+			seat.Down = false
+		default:
+			log.Fatalf("unknown side %q", *side)
+		}
+		g, err := app.NewGame(app.LocalOptions{Seat: seat, Computer: *computer, Time: *timeControl})
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	} else {
+		addr := *server
+		if len(addr) == 0 {
+			addr = "127.0.0.1:7788"
+		}
+		game = app.NewLauncher(addr, *room, *id)
+	}
 	defer game.Close()
+	ebiten.SetWindowSize(game.Layout(0, 0))
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowTitle("XiangQi Demo")
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
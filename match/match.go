@@ -0,0 +1,266 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// EngineFactory 创建引擎，每个并发的对局使用各自的引擎实例
+type EngineFactory func() (ai.Engine, error)
+
+// Side 对战的一方
+type Side struct {
+	ID   int    //归档对局时使用的玩家id，为0时不记录
+	Name string //为空时使用引擎的名称
+	New  EngineFactory
+}
+
+// Config 对战的设置
+type Config struct {
+	Games       int          //一共下多少局
+	Concurrency int          //同时进行的对局数，小于等于0时为1
+	MaxPlies    int          //每局的步数限制
+	Openings    []ai.Opening //开局，每个开局双方交换先后手各下一局，为空时从初始局面开始
+	SPRT        *SPRTConfig  //为nil时不做检验，否则得出结论后提前结束
+}
+
+// GameSummary 一局的结果，Outcome以执红的一方为准
+type GameSummary struct {
+	Index       int //第几局，从0开始
+	Engine1Name string
+	Engine2Name string
+	Engine1Red  bool
+	Opening     string
+	Outcome     rating.Outcome
+	Reason      chessgame.Termination
+	Plies       int
+	GameID      int     //归档后的对局id
+	StatsSoFar  Stats   //包括这一局在内的统计结果
+	SPRTSoFar   SPRT    //包括这一局在内的检验结论
+	LLRSoFar    float64 //包括这一局在内的对数似然比
+}
+
+// Match 两个引擎之间的多局对战，双方交替先手
+type Match struct {
+	cfg    Config
+	sides  [2]Side
+	repo   storage.Repository
+	onGame func(GameSummary)
+
+	mu    sync.Mutex
+	stats Stats
+	sprt  SPRT
+}
+
+// New 创建对战
+func New(cfg Config, engine1, engine2 Side) (*Match, error) {
+	if cfg.Games <= 0 {
+		return nil, errors.New("number of games must be positive")
+	}
+	if engine1.New == nil || engine2.New == nil {
+		return nil, errors.New("engine factory is required")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.SPRT != nil && (cfg.SPRT.Alpha <= 0 || cfg.SPRT.Beta <= 0 || cfg.SPRT.Elo0 >= cfg.SPRT.Elo1) {
+		return nil, errors.New("invalid sprt config")
+	}
+	return &Match{cfg: cfg, sides: [2]Side{engine1, engine2}}, nil
+}
+
+// UseRepository 对局结束后归档到存储中
+func (m *Match) UseRepository(repo storage.Repository) {
+	m.repo = repo
+}
+
+// OnGame 每局结束后调用f，f在对战的协程中按结束顺序依次调用
+func (m *Match) OnGame(f func(GameSummary)) {
+	m.onGame = f
+}
+
+// Stats 当前的统计结果
+func (m *Match) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// 第i局的开局，每个开局连续下两局
+func (m *Match) opening(i int) *ai.Opening {
+	if len(m.cfg.Openings) == 0 {
+		return nil
+	}
+	return &m.cfg.Openings[(i/2)%len(m.cfg.Openings)]
+}
+
+// Run 下完所有对局，或者SPRT得出结论后提前结束
+func (m *Match) Run() (Stats, error) {
+	indexes := make(chan int)
+	done := make(chan struct{})
+	summaries := make(chan GameSummary)
+	errs := make(chan error, m.cfg.Concurrency)
+
+	go func() {
+		defer close(indexes)
+		for i := 0; i < m.cfg.Games; i++ {
+			select {
+			case indexes <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < m.cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.work(indexes, summaries, done); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(summaries)
+	}()
+
+	stopped := false
+	stop := func() {
+		if !stopped {
+			stopped = true
+			close(done)
+		}
+	}
+	var firstErr error
+	for {
+		select {
+		case summary, ok := <-summaries:
+			if !ok {
+				stop()
+				//出错的协程先写入errs再退出，summaries关闭时两个case可能同时就绪，errs中的错误还没有读取
+				select {
+				case err := <-errs:
+					if firstErr == nil {
+						firstErr = err
+					}
+				default:
+				}
+				return m.Stats(), firstErr
+			}
+			if m.onGame != nil {
+				m.onGame(summary)
+			}
+			if summary.SPRTSoFar != SPRTContinue {
+				stop()
+			}
+		case err := <-errs:
+			if firstErr == nil {
+				firstErr = err
+			}
+			stop()
+		}
+	}
+}
+
+// 一个并发的对局：创建双方的引擎，依次下完分配到的对局
+func (m *Match) work(indexes <-chan int, summaries chan<- GameSummary, done <-chan struct{}) error {
+	var bots [2]*ai.Bot
+	for i, side := range m.sides {
+		engine, err := side.New()
+		if err != nil {
+			return err
+		}
+		if c, ok := engine.(io.Closer); ok {
+			defer c.Close()
+		}
+		bots[i] = ai.NewBot(engine)
+	}
+
+	for i := range indexes {
+		summary, err := m.play(i, bots)
+		if err != nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+		select {
+		case summaries <- summary:
+		case <-done:
+			return nil
+		}
+	}
+	return nil
+}
+
+// 下第i局，偶数局第一个引擎先手
+func (m *Match) play(i int, bots [2]*ai.Bot) (GameSummary, error) {
+	engine1Red := i%2 == 0
+	bots[0].SetIsFirst(engine1Red)
+	bots[1].SetIsFirst(!engine1Red)
+	opening := m.opening(i)
+	result, err := ai.PlayBots(bots[0], bots[1], ai.GameOptions{MaxPlies: m.cfg.MaxPlies, Opening: opening})
+	if err != nil {
+		return GameSummary{}, err
+	}
+
+	red, black := m.sides[0], m.sides[1]
+	if !engine1Red {
+		red, black = black, red
+	}
+	summary := GameSummary{
+		Index:       i,
+		Engine1Red:  engine1Red,
+		Outcome:     result.Outcome,
+		Reason:      result.Reason,
+		Plies:       result.Plies,
+		Engine1Name: m.name(0, bots[0]),
+		Engine2Name: m.name(1, bots[1]),
+	}
+	if opening != nil {
+		summary.Opening = opening.Name
+	}
+	if m.repo != nil {
+		rec := result.Record
+		rec.RedID = red.ID
+		rec.BlackID = black.ID
+		rec.Room = fmt.Sprintf("match game %d", i+1)
+		saved, err := m.repo.SaveGame(rec)
+		if err != nil {
+			return GameSummary{}, err
+		}
+		summary.GameID = saved.ID
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case result.Outcome == rating.Draw:
+		m.stats.Draws++
+	case (result.Outcome == rating.RedWin) == engine1Red:
+		m.stats.Wins++
+	default:
+		m.stats.Losses++
+	}
+	if m.cfg.SPRT != nil {
+		m.sprt = m.stats.SPRT(*m.cfg.SPRT)
+		summary.LLRSoFar = m.stats.LLR(*m.cfg.SPRT)
+	}
+	summary.StatsSoFar = m.stats
+	summary.SPRTSoFar = m.sprt
+	return summary, nil
+}
+
+func (m *Match) name(i int, bot *ai.Bot) string {
+	if len(m.sides[i].Name) > 0 {
+		return m.sides[i].Name
+	}
+	return bot.GetEngine().Name()
+}
@@ -0,0 +1,165 @@
+package match
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+func TestStats(t *testing.T) {
+	s := Stats{Wins: 60, Draws: 20, Losses: 20}
+	if got := s.Score(); got != 0.7 {
+		t.Fatalf("score = %v, want 0.7", got)
+	}
+	diff, margin := s.EloDiff()
+	if math.Abs(diff-147.19) > 0.01 {
+		t.Errorf("elo diff = %v, want 147.19", diff)
+	}
+	if margin <= 0 || margin > diff {
+		t.Errorf("unexpected margin %v", margin)
+	}
+	if los := s.LOS(); los < 0.99 {
+		t.Errorf("los = %v, want > 0.99", los)
+	}
+
+	even := Stats{Wins: 10, Draws: 5, Losses: 10}
+	if diff, _ := even.EloDiff(); diff != 0 {
+		t.Errorf("elo diff of even match = %v", diff)
+	}
+	if los := even.LOS(); los != 0.5 {
+		t.Errorf("los of even match = %v", los)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	cfg := SPRTConfig{Elo0: 0, Elo1: 50, Alpha: 0.05, Beta: 0.05}
+	lower, upper := cfg.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Fatalf("bounds = %v, %v", lower, upper)
+	}
+	if got := (Stats{Wins: 2, Draws: 1, Losses: 1}).SPRT(cfg); got != SPRTContinue {
+		t.Errorf("few games: %v", got)
+	}
+	if got := (Stats{Wins: 300, Draws: 100, Losses: 100}).SPRT(cfg); got != SPRTAccept {
+		t.Errorf("strong engine: %v", got)
+	}
+	if got := (Stats{Wins: 100, Draws: 100, Losses: 300}).SPRT(cfg); got != SPRTReject {
+		t.Errorf("weak engine: %v", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	opening, err := ai.ParseOpening("h2e2 h9g7 # 中炮对屏风马")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seed atomic.Int64
+	factory := func(name string) EngineFactory {
+		return func() (ai.Engine, error) {
+			return ai.NewEngine(name, seed.Add(1))
+		}
+	}
+	cfg := Config{Games: 6, Concurrency: 2, MaxPlies: 200, Openings: []ai.Opening{opening}}
+	m, err := New(cfg, Side{ID: 1, New: factory("greedy")}, Side{ID: 2, New: factory("random")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := storage.NewMemoryRepository()
+	m.UseRepository(repo)
+	red := 0
+	m.OnGame(func(g GameSummary) {
+		if g.Engine1Red {
+			red++
+		}
+		if g.Opening != "中炮对屏风马" || g.Plies < len(opening.Moves) {
+			t.Errorf("game %d: opening %q, %d plies", g.Index, g.Opening, g.Plies)
+		}
+	})
+	stats, err := m.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Games() != 6 || red != 3 {
+		t.Fatalf("stats %+v, engine1 red %d times", stats, red)
+	}
+	games := repo.ListGames(0)
+	if len(games) != 6 {
+		t.Fatalf("archived %d games", len(games))
+	}
+	for _, g := range games {
+		if len(g.Moves) == 0 || g.RedID == g.BlackID {
+			t.Fatalf("unexpected record %+v", g)
+		}
+		first := position.Move{From: position.ToSquare(g.Moves[0].Source, g.RedIsDown), To: position.ToSquare(g.Moves[0].Target, g.RedIsDown)}
+		if first.String() != "h2e2" {
+			t.Errorf("game %d starts with %s", g.ID, first)
+		}
+	}
+}
+
+func TestMatchStopsOnSPRT(t *testing.T) {
+	cfg := Config{Games: 1000, MaxPlies: 200, SPRT: &SPRTConfig{Elo0: 0, Elo1: 10, Alpha: 0.2, Beta: 0.2}}
+	newGreedy := func() (ai.Engine, error) { return ai.NewGreedy(1), nil }
+	newRandom := func() (ai.Engine, error) { return ai.NewRandom(2), nil }
+	m, err := New(cfg, Side{New: newGreedy}, Side{New: newRandom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := m.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Games() >= cfg.Games || stats.SPRT(*cfg.SPRT) != SPRTAccept {
+		t.Fatalf("match did not stop: %+v", stats)
+	}
+}
+
+// 第一局之后保存失败的存储
+type failingRepository struct {
+	storage.Repository
+	saved int
+}
+
+func (r *failingRepository) SaveGame(rec storage.GameRecord) (storage.GameRecord, error) {
+	r.saved++
+	if r.saved > 1 {
+		return storage.GameRecord{}, errSave
+	}
+	return r.Repository.SaveGame(rec)
+}
+
+var errSave = errors.New("save failed")
+
+// 对局出错时Run返回错误。回调还在处理上一局时出错的协程已经退出，
+// 对局通道关闭和错误同时就绪，错误也不能丢失
+func TestMatchReportsError(t *testing.T) {
+	newGreedy := func() (ai.Engine, error) { return ai.NewGreedy(1), nil }
+	for i := 0; i < 20; i++ {
+		m, err := New(Config{Games: 3, MaxPlies: 20}, Side{New: newGreedy}, Side{New: newGreedy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.UseRepository(&failingRepository{Repository: storage.NewMemoryRepository()})
+		m.OnGame(func(GameSummary) { time.Sleep(20 * time.Millisecond) })
+		if _, err = m.Run(); !errors.Is(err, errSave) {
+			t.Fatalf("run %d: expect save error, got %v", i, err)
+		}
+	}
+
+	//引擎创建失败
+	failed := errors.New("engine failed to start")
+	newBroken := func() (ai.Engine, error) { return nil, failed }
+	m, err := New(Config{Games: 4, Concurrency: 2, MaxPlies: 20}, Side{New: newGreedy}, Side{New: newBroken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Run(); !errors.Is(err, failed) {
+		t.Fatalf("expect engine error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package match
+
+import "math"
+
+// Stats 对战的统计结果，胜负都以第一个引擎为准
+type Stats struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Games 一共下了多少局
+func (s Stats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score 第一个引擎的平均得分，胜1分和0.5分
+func (s Stats) Score() float64 {
+	n := s.Games()
+	if n == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(n)
+}
+
+// 每局得分的方差
+func (s Stats) variance() float64 {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0
+	}
+	score := s.Score()
+	return (float64(s.Wins)*math.Pow(1-score, 2) +
+		float64(s.Draws)*math.Pow(0.5-score, 2) +
+		float64(s.Losses)*math.Pow(score, 2)) / n
+}
+
+// EloDiff 根据得分估计两个引擎的Elo差，以及95%置信区间的误差范围。
+// 全胜或者全负时Elo差为正负无穷
+func (s Stats) EloDiff() (diff, margin float64) {
+	score := s.Score()
+	diff = eloOfScore(score)
+	n := float64(s.Games())
+	if n == 0 || math.IsInf(diff, 0) {
+		return diff, math.Inf(1)
+	}
+	dev := 1.959964 * math.Sqrt(s.variance()/n)
+	margin = (eloOfScore(score+dev) - eloOfScore(score-dev)) / 2
+	return diff, margin
+}
+
+// LOS 第一个引擎比第二个引擎强的概率，和棋不参与计算
+func (s Stats) LOS() float64 {
+	decisive := float64(s.Wins + s.Losses)
+	if decisive == 0 {
+		return 0.5
+	}
+	return 0.5 * (1 + math.Erf(float64(s.Wins-s.Losses)/math.Sqrt(2*decisive)))
+}
+
+// 得分对应的Elo差
+func eloOfScore(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/score-1)
+}
+
+// Elo差对应的期望得分
+func scoreOfElo(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// SPRTConfig 序贯概率比检验的设置：H0为Elo差等于Elo0，H1为Elo差等于Elo1
+type SPRTConfig struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64 //第一类错误的概率
+	Beta  float64 //第二类错误的概率
+}
+
+// SPRT 检验的结论
+type SPRT int
+
+const (
+	SPRTContinue SPRT = iota //还不能得出结论
+	SPRTAccept               //接受H1，第一个引擎更强
+	SPRTReject               //接受H0
+)
+
+func (r SPRT) String() string {
+	switch r {
+	case SPRTAccept:
+		return "H1 accepted"
+	case SPRTReject:
+		return "H0 accepted"
+	}
+	return "continue"
+}
+
+// Bounds 对数似然比的下界和上界
+func (cfg SPRTConfig) Bounds() (lower, upper float64) {
+	return math.Log(cfg.Beta / (1 - cfg.Alpha)), math.Log((1 - cfg.Beta) / cfg.Alpha)
+}
+
+// LLR 用正态近似计算对数似然比。
+// 全胜、全和或全负时方差为0，计算方差时给胜和负各加半局，避免检验无法结束
+func (s Stats) LLR(cfg SPRTConfig) float64 {
+	if s.Games() == 0 {
+		return 0
+	}
+	variance := s.variance()
+	if variance == 0 {
+		n := float64(s.Games()) + 1
+		score := s.Score()
+		variance = ((float64(s.Wins)+0.5)*math.Pow(1-score, 2) +
+			float64(s.Draws)*math.Pow(0.5-score, 2) +
+			(float64(s.Losses)+0.5)*math.Pow(score, 2)) / n
+	}
+	s0, s1 := scoreOfElo(cfg.Elo0), scoreOfElo(cfg.Elo1)
+	return float64(s.Games()) * (s1 - s0) * (2*s.Score() - s0 - s1) / (2 * variance)
+}
+
+// SPRT 根据当前的统计结果做出检验结论
+func (s Stats) SPRT(cfg SPRTConfig) SPRT {
+	llr := s.LLR(cfg)
+	lower, upper := cfg.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTAccept
+	case llr <= lower:
+		return SPRTReject
+	}
+	return SPRTContinue
+}
@@ -0,0 +1,187 @@
+package matchmaking
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Matchmaker 自动匹配服务：按对局时间和评分为排队的玩家配对，棋局由调用方创建和运行
+type Matchmaker struct {
+	cfg   Config
+	store Store
+
+	mu   sync.Mutex
+	now  func() time.Time
+	rand *rand.Rand
+}
+
+func NewMatchmaker(cfg Config, store Store) *Matchmaker {
+	return &Matchmaker{
+		cfg:   cfg,
+		store: store,
+		now:   time.Now,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Enqueue 玩家加入匹配队列
+func (m *Matchmaker) Enqueue(playerID, rating int, timeControl time.Duration) error {
+	return m.store.Add(Ticket{
+		PlayerID:    playerID,
+		Rating:      rating,
+		TimeControl: timeControl,
+		EnqueuedAt:  m.now(),
+	})
+}
+
+// Cancel 玩家取消匹配
+func (m *Matchmaker) Cancel(playerID int) bool {
+	_, ok := m.store.Remove(playerID)
+	return ok
+}
+
+// Tick 进行一轮匹配：先移出排队超时的玩家，再按排队先后为玩家寻找评分最接近的对手
+func (m *Matchmaker) Tick() (matches []*Match, expired []Ticket, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	waiting := make([]Ticket, 0)
+	for _, t := range m.store.List() {
+		if now.Sub(t.EnqueuedAt) >= m.cfg.QueueTimeout {
+			m.store.Remove(t.PlayerID)
+			expired = append(expired, t)
+			continue
+		}
+		waiting = append(waiting, t)
+	}
+
+	paired := make(map[int]bool)
+	for i, a := range waiting {
+		if paired[a.PlayerID] {
+			continue
+		}
+		best := -1
+		bestDiff := 0
+		for j := i + 1; j < len(waiting); j++ {
+			b := waiting[j]
+			if paired[b.PlayerID] || a.TimeControl != b.TimeControl {
+				continue
+			}
+			diff := abs(a.Rating - b.Rating)
+			//双方都能接受的评分差才能配对
+			if diff > m.band(a, now) || diff > m.band(b, now) {
+				continue
+			}
+			if best < 0 || diff < bestDiff {
+				best = j
+				bestDiff = diff
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		b := waiting[best]
+		match := m.newMatch(a, b, now)
+		paired[a.PlayerID] = true
+		paired[b.PlayerID] = true
+		m.store.Remove(a.PlayerID)
+		m.store.Remove(b.PlayerID)
+		matches = append(matches, match)
+	}
+
+	return matches, expired, nil
+}
+
+// Run 每隔interval进行一轮匹配，直到quit通道收到信号。
+// 配对的对局、排队超时的玩家和匹配出错的原因分别从三个通道接收，三个通道互不阻塞，
+// 调用方只读取其中一部分时也不会影响其它通道，quit收到信号后三个通道关闭
+func (m *Matchmaker) Run(interval time.Duration, quit chan struct{}) (matchChan chan *Match, expiredChan chan Ticket, errChan chan error) {
+	matchChan = make(chan *Match, 16)
+	expiredChan = make(chan Ticket, 16)
+	errChan = make(chan error, 1)
+	go func() {
+		defer close(matchChan)
+		defer close(expiredChan)
+		defer close(errChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		//还没有被调用方接收的结果
+		var matches []*Match
+		var expired []Ticket
+		var errs []error
+		for {
+			//没有待发送的结果时通道为nil，对应的case不会被选中
+			var mc chan *Match
+			var ec chan Ticket
+			var xc chan error
+			var nextMatch *Match
+			var nextExpired Ticket
+			var nextErr error
+			if len(matches) > 0 {
+				mc, nextMatch = matchChan, matches[0]
+			}
+			if len(expired) > 0 {
+				ec, nextExpired = expiredChan, expired[0]
+			}
+			if len(errs) > 0 {
+				xc, nextErr = errChan, errs[0]
+			}
+
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				ms, ex, err := m.Tick()
+				matches = append(matches, ms...)
+				expired = append(expired, ex...)
+				if err != nil {
+					errs = append(errs, err)
+				}
+			case mc <- nextMatch:
+				matches = matches[1:]
+			case ec <- nextExpired:
+				expired = expired[1:]
+			case xc <- nextErr:
+				errs = errs[1:]
+			}
+		}
+	}()
+	return matchChan, expiredChan, errChan
+}
+
+// 玩家当前可以接受的评分差，随排队时间逐渐放宽
+func (m *Matchmaker) band(t Ticket, now time.Time) int {
+	band := m.cfg.InitialBand
+	if m.cfg.BandInterval > 0 {
+		band += m.cfg.BandGrowth * int(now.Sub(t.EnqueuedAt)/m.cfg.BandInterval)
+	}
+	if band > m.cfg.MaxBand {
+		band = m.cfg.MaxBand
+	}
+	return band
+}
+
+// 创建对局，随机决定先手。先手执红棋并位于棋盘下方
+func (m *Matchmaker) newMatch(a, b Ticket, now time.Time) *Match {
+	red, black := a, b
+	if m.rand.Intn(2) == 1 {
+		red, black = b, a
+	}
+	return &Match{
+		Red:         red,
+		Black:       black,
+		TimeControl: red.TimeControl,
+		CreatedAt:   now,
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
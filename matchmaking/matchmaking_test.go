@@ -0,0 +1,151 @@
+package matchmaking
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 创建使用假时钟的匹配服务
+func newTestMatchmaker(now *time.Time) *Matchmaker {
+	m := NewMatchmaker(DefaultConfig(), NewMemoryStore())
+	m.now = func() time.Time { return *now }
+	m.rand = rand.New(rand.NewSource(1))
+	return m
+}
+
+func TestMatchByRatingAndTimeControl(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMatchmaker(&now)
+
+	m.Enqueue(1, 1500, 10*time.Minute)
+	m.Enqueue(2, 1900, 10*time.Minute)
+	m.Enqueue(3, 1550, 5*time.Minute)
+	m.Enqueue(4, 1580, 10*time.Minute)
+
+	if err := m.Enqueue(1, 1500, 10*time.Minute); err == nil {
+		t.Fatal("enqueue twice should fail")
+	}
+
+	matches, expired, err := m.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || len(expired) != 0 {
+		t.Fatalf("expect 1 match, got %d matches %d expired", len(matches), len(expired))
+	}
+
+	//1号和4号对局时间相同而且评分接近
+	match := matches[0]
+	ids := map[int]bool{match.Red.PlayerID: true, match.Black.PlayerID: true}
+	if !ids[1] || !ids[4] {
+		t.Fatalf("expect player 1 and 4, got %d and %d", match.Red.PlayerID, match.Black.PlayerID)
+	}
+	if match.TimeControl != 10*time.Minute {
+		t.Fatalf("expect the players' time control, got %v", match.TimeControl)
+	}
+	if _, ok := m.store.Get(1); ok {
+		t.Fatal("matched player should leave the queue")
+	}
+}
+
+func TestBandGrowsWithWaitingTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMatchmaker(&now)
+
+	m.Enqueue(1, 1500, 10*time.Minute)
+	m.Enqueue(2, 1750, 10*time.Minute)
+
+	matches, _, _ := m.Tick()
+	if len(matches) != 0 {
+		t.Fatal("rating difference is too large at first")
+	}
+
+	//等待30秒后双方可接受的评分差扩大到250
+	now = now.Add(30 * time.Second)
+	matches, _, _ = m.Tick()
+	if len(matches) != 1 {
+		t.Fatal("players should be matched after waiting")
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMatchmaker(&now)
+
+	m.Enqueue(1, 1500, 10*time.Minute)
+	m.Enqueue(2, 1500, 5*time.Minute)
+
+	now = now.Add(DefaultConfig().QueueTimeout)
+	matches, expired, _ := m.Tick()
+	if len(matches) != 0 || len(expired) != 2 {
+		t.Fatalf("expect 2 expired tickets, got %d", len(expired))
+	}
+	if len(m.store.List()) != 0 {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func TestRandomFirstPlayer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMatchmaker(&now)
+
+	redCount := 0
+	for i := 0; i < 20; i++ {
+		m.Enqueue(1, 1500, 10*time.Minute)
+		m.Enqueue(2, 1500, 10*time.Minute)
+		matches, _, _ := m.Tick()
+		if len(matches) != 1 {
+			t.Fatal("expect 1 match")
+		}
+		if matches[0].Red.PlayerID == 1 {
+			redCount++
+		}
+	}
+	if redCount == 0 || redCount == 20 {
+		t.Fatalf("first player should be random, player 1 was red %d times", redCount)
+	}
+}
+
+// 调用方只读取配对的对局时，大量排队超时的玩家不会阻塞配对，quit之后通道关闭
+func TestRunDoesNotBlockOnUnreadChannels(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMatchmaker(&now)
+	for id := 1; id <= 40; id++ {
+		m.Enqueue(id, 1500, time.Duration(id)*time.Minute)
+	}
+	now = now.Add(DefaultConfig().QueueTimeout)
+	m.Enqueue(100, 1500, 10*time.Minute)
+	m.Enqueue(101, 1500, 10*time.Minute)
+
+	quit := make(chan struct{})
+	matchChan, expiredChan, errChan := m.Run(time.Millisecond, quit)
+	select {
+	case match := <-matchChan:
+		if match.Red.PlayerID < 100 || match.Black.PlayerID < 100 {
+			t.Fatalf("unexpected match %d vs %d", match.Red.PlayerID, match.Black.PlayerID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("match should not be blocked by unread expired tickets")
+	}
+
+	close(quit)
+	timeout := time.After(2 * time.Second)
+	for matchChan != nil || errChan != nil {
+		select {
+		case _, ok := <-matchChan:
+			if !ok {
+				matchChan = nil
+			}
+		case _, ok := <-errChan:
+			if !ok {
+				errChan = nil
+			}
+		case <-timeout:
+			t.Fatal("channels should be closed after quit")
+		}
+	}
+	//没有读取的超时玩家最多留在缓冲区中，通道同样关闭
+	for range expiredChan {
+	}
+}
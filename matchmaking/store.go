@@ -0,0 +1,72 @@
+package matchmaking
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Store 匹配队列的存储
+type Store interface {
+	Add(ticket Ticket) error            //加入队列，玩家已经在队列中时报错
+	Remove(playerID int) (Ticket, bool) //移出队列
+	Get(playerID int) (Ticket, bool)    //查询玩家的排队信息
+	List() []Ticket                     //按排队时间先后返回所有排队的玩家
+}
+
+// MemoryStore 内存中的匹配队列
+type MemoryStore struct {
+	mu      sync.Mutex
+	tickets map[int]Ticket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tickets: make(map[int]Ticket),
+	}
+}
+
+// Add 加入队列
+func (s *MemoryStore) Add(ticket Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tickets[ticket.PlayerID]; ok {
+		return errors.New("player is already in the queue")
+	}
+	s.tickets[ticket.PlayerID] = ticket
+	return nil
+}
+
+// Remove 移出队列
+func (s *MemoryStore) Remove(playerID int) (Ticket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticket, ok := s.tickets[playerID]
+	delete(s.tickets, playerID)
+	return ticket, ok
+}
+
+// Get 查询玩家的排队信息
+func (s *MemoryStore) Get(playerID int) (Ticket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticket, ok := s.tickets[playerID]
+	return ticket, ok
+}
+
+// List 按排队时间先后返回所有排队的玩家
+func (s *MemoryStore) List() []Ticket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tickets := make([]Ticket, 0, len(s.tickets))
+	for _, t := range s.tickets {
+		tickets = append(tickets, t)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if tickets[i].EnqueuedAt.Equal(tickets[j].EnqueuedAt) {
+			return tickets[i].PlayerID < tickets[j].PlayerID
+		}
+		return tickets[i].EnqueuedAt.Before(tickets[j].EnqueuedAt)
+	})
+	return tickets
+}
@@ -0,0 +1,41 @@
+package matchmaking
+
+import "time"
+
+// Ticket 排队等待匹配的玩家
+type Ticket struct {
+	PlayerID    int           //玩家id
+	Rating      int           //玩家评分
+	TimeControl time.Duration //期望的对局时间（每一方）
+	EnqueuedAt  time.Time     //开始排队的时间
+}
+
+// Match 匹配成功的对局，先后手随机决定，先手执红棋并位于棋盘下方。
+// 匹配服务只负责配对，调用方按照双方的排队信息安排座位并运行棋局
+type Match struct {
+	Red         Ticket
+	Black       Ticket
+	TimeControl time.Duration
+	CreatedAt   time.Time
+}
+
+// Config 匹配配置。
+// 玩家可接受的评分差从InitialBand开始，每等待BandInterval扩大BandGrowth，最大不超过MaxBand
+type Config struct {
+	InitialBand  int
+	BandGrowth   int
+	BandInterval time.Duration
+	MaxBand      int
+	QueueTimeout time.Duration //排队超时时间，超时的玩家移出队列
+}
+
+// DefaultConfig 默认的匹配配置
+func DefaultConfig() Config {
+	return Config{
+		InitialBand:  100,
+		BandGrowth:   50,
+		BandInterval: 10 * time.Second,
+		MaxBand:      400,
+		QueueTimeout: 3 * time.Minute,
+	}
+}
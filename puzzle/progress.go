@@ -0,0 +1,114 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record 一道题目的练习记录
+type Record struct {
+	Attempts  int       `json:"attempts"`  //做完的次数
+	Successes int       `json:"successes"` //没有走错、没有看提示就做完的次数
+	LastTried time.Time `json:"last_tried"`
+}
+
+// Progress 所有题目的练习记录，保存为json文件
+type Progress struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]Record
+}
+
+// NewProgress 只保存在内存中的练习记录
+func NewProgress() *Progress {
+	return &Progress{records: make(map[string]Record)}
+}
+
+// OpenProgress 读取练习记录文件，文件不存在时创建空的记录，之后每次记录都写回文件
+func OpenProgress(path string) (*Progress, error) {
+	p := NewProgress()
+	p.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.records); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Record 记录做完一道题目
+func (p *Progress) Record(s *Session, at time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := s.Puzzle().ID()
+	rec := p.records[id]
+	rec.Attempts++
+	if s.Succeeded() {
+		rec.Successes++
+	}
+	rec.LastTried = at
+	p.records[id] = rec
+	return p.save()
+}
+
+// Get 获取题目的练习记录
+func (p *Progress) Get(puzzle Puzzle) Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.records[puzzle.ID()]
+}
+
+// SuccessRate 所有题目的成功率，以及一共做完的次数
+func (p *Progress) SuccessRate() (rate float64, attempts int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	successes := 0
+	for _, rec := range p.records {
+		attempts += rec.Attempts
+		successes += rec.Successes
+	}
+	if attempts == 0 {
+		return 0, 0
+	}
+	return float64(successes) / float64(attempts), attempts
+}
+
+// Next 从第from道题目开始，找出下一道还没有成功做出来的题目，全部做出来时返回from
+func (p *Progress) Next(puzzles []Puzzle, from int) int {
+	for i := 0; i < len(puzzles); i++ {
+		idx := (from + i) % len(puzzles)
+		if p.Get(puzzles[idx]).Successes == 0 {
+			return idx
+		}
+	}
+	return from % max(len(puzzles), 1)
+}
+
+// 写回文件，先写临时文件再重命名
+func (p *Progress) save() error {
+	if len(p.path) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(p.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return err
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
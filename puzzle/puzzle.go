@@ -0,0 +1,95 @@
+// Package puzzle 杀局和战术题的练习。
+// 题目由FEN局面和解答着法组成，解答中双方的着法交替出现，第一步和最后一步都是做题一方的着法，
+// 对方的应着由程序自动走出
+package puzzle
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+//go:embed puzzles.txt
+var defaultPuzzles string
+
+// Puzzle 一道题目
+type Puzzle struct {
+	Name     string
+	FEN      string
+	Solution []position.Move //做题一方和对方交替的着法，最后一步是做题一方的着法
+}
+
+// ID 题目的标识，由起始局面的哈希值生成，不随题目在文件中的顺序变化
+func (p Puzzle) ID() string {
+	pos, err := position.ParseFEN(p.FEN)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%016x", pos.Hash())
+}
+
+// Moves 做题一方需要走的步数，杀局就是几步杀
+func (p Puzzle) Moves() int {
+	return (len(p.Solution) + 1) / 2
+}
+
+// Parse 解析题目文件中的一行："FEN | 解答着法 # 名称"，着法为ICCS格式，用空格分隔
+func Parse(line string) (Puzzle, error) {
+	var p Puzzle
+	if i := strings.Index(line, "#"); i >= 0 {
+		p.Name = strings.TrimSpace(line[i+1:])
+		line = line[:i]
+	}
+	fen, moves, ok := strings.Cut(line, "|")
+	if !ok {
+		return p, fmt.Errorf("missing solution in %q", line)
+	}
+	p.FEN = strings.TrimSpace(fen)
+	if _, err := position.ParseFEN(p.FEN); err != nil {
+		return p, err
+	}
+	for _, f := range strings.Fields(moves) {
+		m, err := position.ParseMove(f)
+		if err != nil {
+			return p, err
+		}
+		p.Solution = append(p.Solution, m)
+	}
+	if len(p.Solution)%2 == 0 {
+		return p, fmt.Errorf("solution of %q must end with the solver's move", p.FEN)
+	}
+	return p, nil
+}
+
+// Load 读取题目文件，每行一道题目，忽略空行和以 # 开头的注释行
+func Load(r io.Reader) ([]Puzzle, error) {
+	puzzles := make([]Puzzle, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		puzzles = append(puzzles, p)
+	}
+	return puzzles, scanner.Err()
+}
+
+// Default 内置的题目
+func Default() []Puzzle {
+	puzzles, err := Load(strings.NewReader(defaultPuzzles))
+	if err != nil {
+		panic(err)
+	}
+	return puzzles
+}
@@ -0,0 +1,149 @@
+package puzzle
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+func mustMove(t *testing.T, s string) position.Move {
+	t.Helper()
+	m, err := position.ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// 内置题目的解答都要符合规则，并且最后将死对方
+func TestDefault(t *testing.T) {
+	puzzles := Default()
+	if len(puzzles) == 0 {
+		t.Fatal("no default puzzles")
+	}
+	ids := map[string]bool{}
+	for _, p := range puzzles {
+		s, err := NewSession(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Mate() {
+			t.Fatalf("%s is not a mate puzzle", p.Name)
+		}
+		if ids[p.ID()] {
+			t.Fatalf("duplicate puzzle %s", p.Name)
+		}
+		ids[p.ID()] = true
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("3k5/9/9/9/9/9/9/9/9/R3K4 w a0d0"); err == nil {
+		t.Fatal("expect error for missing separator")
+	}
+	if _, err := Parse("3k5/9/9/9/9/9/9/9/9/R3K4 w | a0d0 d9e9"); err == nil {
+		t.Fatal("expect error for solution ending with the opponent's move")
+	}
+	//解答中有不合法的着法
+	p, err := Parse("3k5/9/9/9/9/9/9/9/9/R3K4 w | a0d1 # 错题")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSession(p); err == nil {
+		t.Fatal("expect error for illegal solution")
+	}
+}
+
+func TestSession(t *testing.T) {
+	p, err := Parse("9/4k4/9/9/9/9/9/9/9/R2K5 w | a0a8 e8e9 a8f8 # 单车擒王")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSession(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Moves() != 2 || !s.Mate() || !s.RedSolves() {
+		t.Fatalf("expect red to mate in 2, got %d moves, mate %v", s.Moves(), s.Mate())
+	}
+
+	//不合法的着法不计入走错次数
+	if _, err := s.Play(mustMove(t, "a0b1")); err != ErrIllegal {
+		t.Fatalf("expect illegal move, got %v", err)
+	}
+	fb, err := s.Play(mustMove(t, "a0a1"))
+	if err != nil || fb.Correct || s.Mistakes() != 1 {
+		t.Fatalf("expect wrong move, got %+v %v", fb, err)
+	}
+	fb, err = s.Play(mustMove(t, "a0a8"))
+	if err != nil || !fb.Correct || fb.Reply == nil || fb.Reply.String() != "e8e9" || fb.Solved {
+		t.Fatalf("unexpected feedback %+v %v", fb, err)
+	}
+	//最后一步没有将死对方
+	if fb, _ = s.Play(mustMove(t, "a8d8")); fb.Correct || s.Mistakes() != 2 {
+		t.Fatalf("expect wrong move, got %+v", fb)
+	}
+	fb, err = s.Play(mustMove(t, "a8f8"))
+	if err != nil || !fb.Correct || !fb.Solved {
+		t.Fatalf("expect solved, got %+v %v", fb, err)
+	}
+	if s.Succeeded() {
+		t.Fatal("session with mistakes should not succeed")
+	}
+
+	s.Restart()
+	if m, ok := s.Hint(); !ok || m.String() != "a0a8" || s.Hints() != 1 {
+		t.Fatalf("unexpected hint %s", m)
+	}
+}
+
+// 最后一步其他将死对方的着法也算正确
+func TestAlternativeMate(t *testing.T) {
+	p, _ := Parse("3k5/9/9/9/9/9/9/9/R8/R3K4 w | a0d0 # 白脸将")
+	s, err := NewSession(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := s.Play(mustMove(t, "a1d1"))
+	if err != nil || !fb.Correct || !fb.Solved || !s.Succeeded() {
+		t.Fatalf("expect alternative mate, got %+v %v", fb, err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "puzzles.json")
+	progress, err := OpenProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzles := Default()
+	s, _ := NewSession(puzzles[0])
+	if _, err := s.Play(puzzles[0].Solution[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := progress.Record(s, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ = NewSession(puzzles[1])
+	s.Hint()
+	s.Play(puzzles[1].Solution[0])
+	progress.Record(s, time.Now())
+
+	reopened, err := OpenProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate, attempts := reopened.SuccessRate(); attempts != 2 || rate != 0.5 {
+		t.Fatalf("unexpected success rate %v of %d", rate, attempts)
+	}
+	if rec := reopened.Get(puzzles[0]); rec.Attempts != 1 || rec.Successes != 1 {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	//第一道已经做出来，下一道是第二道
+	if next := reopened.Next(puzzles, 0); next != 1 {
+		t.Fatalf("expect next puzzle 1, got %d", next)
+	}
+}
@@ -0,0 +1,59 @@
+package puzzle
+
+import (
+	"errors"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// ErrIllegal 着法不符合规则
+var ErrIllegal = errors.New("illegal move")
+
+// 用内核的裁判判断着法是否合法，并判断是否将死
+type rules struct {
+	*chessgame.Referee
+}
+
+func newRules() (*rules, error) {
+	referee, err := chessgame.NewReferee()
+	if err != nil {
+		return nil, err
+	}
+	return &rules{Referee: referee}, nil
+}
+
+// 走棋方能否吃掉对方的将帅
+func (r *rules) canCaptureKing(pos position.Position) (bool, error) {
+	moves, err := r.LegalMoves(pos)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range moves {
+		if target := pos.At(m.To); target.Code == core.JiangShuai {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 走棋方是否已经被将死或者困毙：无论怎么走，对方都能吃掉将帅
+func (r *rules) isMated(pos position.Position) (bool, error) {
+	moves, err := r.LegalMoves(pos)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range moves {
+		child := pos
+		child.Play(m)
+		captured, err := r.canCaptureKing(child)
+		if err != nil {
+			return false, err
+		}
+		if !captured {
+			return false, nil
+		}
+	}
+	return true, nil
+}
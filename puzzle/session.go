@@ -0,0 +1,157 @@
+package puzzle
+
+import (
+	"fmt"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Session 做一道题目的过程。
+// 做题一方走出解答中的着法之后，程序自动走出对方的应着；走错时局面不变，可以重新走
+type Session struct {
+	puzzle Puzzle
+	rules  *rules
+	mate   bool //解答的最后一步将死对方，最后一步可以用其他将死对方的着法代替
+
+	pos      position.Position
+	ply      int //下一步是解答中的第几步
+	mistakes int //走错的次数
+	hints    int //查看提示的次数
+}
+
+// Feedback 走一步棋的结果
+type Feedback struct {
+	Correct bool           //着法是否正确
+	Reply   *position.Move //对方自动走出的应着
+	Solved  bool           //题目已经解完
+}
+
+// NewSession 开始做题，用内核的规则校验解答中的每一步棋
+func NewSession(p Puzzle) (*Session, error) {
+	start, err := position.ParseFEN(p.FEN)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRules()
+	if err != nil {
+		return nil, err
+	}
+	pos := start
+	for i, m := range p.Solution {
+		ok, err := r.IsLegal(pos, m)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s: solution move %d %s is illegal", p.Name, i+1, m)
+		}
+		pos.Play(m)
+	}
+	mate, err := r.isMated(pos)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{puzzle: p, rules: r, mate: mate, pos: start}, nil
+}
+
+// Puzzle 获取题目
+func (s *Session) Puzzle() Puzzle {
+	return s.puzzle
+}
+
+// Moves 做题一方需要走的步数
+func (s *Session) Moves() int {
+	return s.puzzle.Moves()
+}
+
+// Mate 解答的最后一步是否将死对方，界面按照步数和是否杀局显示题目名称，例如 马后炮（一步杀）
+func (s *Session) Mate() bool {
+	return s.mate
+}
+
+// Position 获取当前局面
+func (s *Session) Position() position.Position {
+	return s.pos
+}
+
+// RedSolves 做题一方是否是红方
+func (s *Session) RedSolves() bool {
+	start, _ := position.ParseFEN(s.puzzle.FEN)
+	return start.RedToMove
+}
+
+// Solved 题目是否已经解完
+func (s *Session) Solved() bool {
+	return s.ply >= len(s.puzzle.Solution)
+}
+
+// Mistakes 获取走错的次数
+func (s *Session) Mistakes() int {
+	return s.mistakes
+}
+
+// Hints 获取查看提示的次数
+func (s *Session) Hints() int {
+	return s.hints
+}
+
+// Succeeded 题目解完并且没有走错、没有看提示
+func (s *Session) Succeeded() bool {
+	return s.Solved() && s.mistakes == 0 && s.hints == 0
+}
+
+// Hint 解答中的下一步棋，题目已经解完时返回false
+func (s *Session) Hint() (position.Move, bool) {
+	if s.Solved() {
+		return position.Move{}, false
+	}
+	s.hints++
+	return s.puzzle.Solution[s.ply], true
+}
+
+// Play 走一步棋。不合法的着法返回ErrIllegal，不计入走错次数
+func (s *Session) Play(m position.Move) (Feedback, error) {
+	if s.Solved() {
+		return Feedback{}, fmt.Errorf("puzzle is solved")
+	}
+	ok, err := s.rules.IsLegal(s.pos, m)
+	if err != nil {
+		return Feedback{}, err
+	}
+	if !ok {
+		return Feedback{}, ErrIllegal
+	}
+
+	correct := m == s.puzzle.Solution[s.ply]
+	last := s.ply == len(s.puzzle.Solution)-1
+	if !correct && last && s.mate {
+		//最后一步只要将死对方就算正确
+		child := s.pos
+		child.Play(m)
+		if correct, err = s.rules.isMated(child); err != nil {
+			return Feedback{}, err
+		}
+	}
+	if !correct {
+		s.mistakes++
+		return Feedback{}, nil
+	}
+
+	s.pos.Play(m)
+	s.ply++
+	fb := Feedback{Correct: true}
+	if s.ply < len(s.puzzle.Solution) {
+		reply := s.puzzle.Solution[s.ply]
+		s.pos.Play(reply)
+		s.ply++
+		fb.Reply = &reply
+	}
+	fb.Solved = s.Solved()
+	return fb, nil
+}
+
+// Restart 从头再做一遍，走错和提示的次数保留
+func (s *Session) Restart() {
+	s.pos, _ = position.ParseFEN(s.puzzle.FEN)
+	s.ply = 0
+}
@@ -0,0 +1,16 @@
+package rating
+
+import "math"
+
+// EloExpected 评分为ra的玩家对评分为rb的玩家的期望得分
+func EloExpected(ra, rb float64) float64 {
+	return 1 / (1 + math.Pow(10, (rb-ra)/400))
+}
+
+// EloUpdate 根据玩家a的得分（胜1，和0.5，负0）计算双方新的Elo评分
+func EloUpdate(ra, rb, scoreA, k float64) (newA, newB float64) {
+	ea := EloExpected(ra, rb)
+	newA = ra + k*(scoreA-ea)
+	newB = rb + k*((1-scoreA)-(1-ea))
+	return newA, newB
+}
@@ -0,0 +1,90 @@
+package rating
+
+import "math"
+
+// Glicko-2内部刻度和显示刻度的换算系数
+const glickoScale = 173.7178
+
+// GlickoResult 一个评分周期内的一局对局结果
+type GlickoResult struct {
+	Opponent Glicko2
+	Score    float64 //胜1，和0.5，负0
+}
+
+// Glicko2Update 按照Glicko-2算法计算玩家在一个评分周期之后的评分。
+// 没有对局的评分周期只增大评分偏差
+func Glicko2Update(p Glicko2, results []GlickoResult, tau float64) Glicko2 {
+	mu := (p.Rating - 1500) / glickoScale
+	phi := p.RD / glickoScale
+	sigma := p.Volatility
+
+	if len(results) == 0 {
+		phi = math.Sqrt(phi*phi + sigma*sigma)
+		return Glicko2{Rating: p.Rating, RD: phi * glickoScale, Volatility: sigma}
+	}
+
+	//估计方差v和评分改进量delta
+	vInv := 0.0
+	sum := 0.0
+	for _, r := range results {
+		muJ := (r.Opponent.Rating - 1500) / glickoScale
+		phiJ := r.Opponent.RD / glickoScale
+		g := glickoG(phiJ)
+		e := glickoE(mu, muJ, phiJ)
+		vInv += g * g * e * (1 - e)
+		sum += g * (r.Score - e)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	//迭代计算新的波动率
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+	const epsilon = 0.000001
+	bigA := a
+	var bigB float64
+	if delta*delta > phi*phi+v {
+		bigB = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		bigB = a - k*tau
+	}
+	fA, fB := f(bigA), f(bigB)
+	for math.Abs(bigB-bigA) > epsilon {
+		bigC := bigA + (bigA-bigB)*fA/(fB-fA)
+		fC := f(bigC)
+		if fC*fB <= 0 {
+			bigA, fA = bigB, fB
+		} else {
+			fA = fA / 2
+		}
+		bigB, fB = bigC, fC
+	}
+	newSigma := math.Exp(bigA / 2)
+
+	//更新评分偏差和评分
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*sum
+
+	return Glicko2{
+		Rating:     newMu*glickoScale + 1500,
+		RD:         newPhi * glickoScale,
+		Volatility: newSigma,
+	}
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu, muJ, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phiJ)*(mu-muJ)))
+}
@@ -0,0 +1,121 @@
+package rating
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+)
+
+// Service 根据对局结果更新玩家的Elo和Glicko-2评分
+type Service struct {
+	cfg   Config
+	store Store
+
+	mu sync.Mutex //多个房间可能同时记录结果
+}
+
+func NewService(cfg Config, store Store) *Service {
+	return &Service{
+		cfg:   cfg,
+		store: store,
+	}
+}
+
+// Get 查询玩家评分，没有记录的玩家返回初始评分
+func (s *Service) Get(playerID int) Rating {
+	r, ok := s.store.Get(playerID)
+	if !ok {
+		return NewRating(playerID)
+	}
+	return r
+}
+
+// Record 记录一局对局的结果，返回双方更新后的评分
+func (s *Service) Record(redID, blackID int, outcome Outcome) (red, black Rating, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	red = s.Get(redID)
+	black = s.Get(blackID)
+
+	redScore := 0.5
+	switch outcome {
+	case RedWin:
+		redScore = 1
+		red.Wins++
+		black.Losses++
+	case BlackWin:
+		redScore = 0
+		red.Losses++
+		black.Wins++
+	default:
+		red.Draws++
+		black.Draws++
+	}
+
+	red.Elo, black.Elo = EloUpdate(red.Elo, black.Elo, redScore, s.cfg.K)
+
+	//每一局作为一个评分周期，双方都使用对方更新前的评分计算
+	redGlicko := Glicko2Update(red.Glicko, []GlickoResult{{Opponent: black.Glicko, Score: redScore}}, s.cfg.Tau)
+	blackGlicko := Glicko2Update(black.Glicko, []GlickoResult{{Opponent: red.Glicko, Score: 1 - redScore}}, s.cfg.Tau)
+	red.Glicko, black.Glicko = redGlicko, blackGlicko
+
+	now := time.Now()
+	red.Games++
+	black.Games++
+	red.UpdatedAt = now
+	black.UpdatedAt = now
+
+	if err = s.store.Put(red); err != nil {
+		return red, black, err
+	}
+	if err = s.store.Put(black); err != nil {
+		return red, black, err
+	}
+	return red, black, nil
+}
+
+// OutcomeOf 将内核的结束事件转换成对局结果，赢家为GroupNone时判和
+func OutcomeOf(msg chessgame.GameMsg, redGroup core.ChessmanGroup) (Outcome, bool) {
+	if msg.Event != chessgame.Fin || msg.GameOver == nil {
+		return Draw, false
+	}
+	switch msg.GameOver.Winner {
+	case core.GroupNone:
+		return Draw, true
+	case redGroup:
+		return RedWin, true
+	default:
+		return BlackWin, true
+	}
+}
+
+// SortBy 排行榜的排序依据
+type SortBy int
+
+const (
+	ByGlicko SortBy = iota //按Glicko-2评分排序
+	ByElo                  //按Elo评分排序
+)
+
+// Leaderboard 返回评分最高的n名玩家，n小于等于0时返回所有玩家
+func (s *Service) Leaderboard(n int, by SortBy) []Rating {
+	all := s.store.All()
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i].Glicko.Rating, all[j].Glicko.Rating
+		if by == ByElo {
+			a, b = all[i].Elo, all[j].Elo
+		}
+		if a == b {
+			return all[i].PlayerID < all[j].PlayerID
+		}
+		return a > b
+	})
+	if n > 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
@@ -0,0 +1,61 @@
+package rating
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+)
+
+// Glickman论文中的示例
+func TestGlicko2Update(t *testing.T) {
+	p := Glicko2{Rating: 1500, RD: 200, Volatility: 0.06}
+	results := []GlickoResult{
+		{Opponent: Glicko2{Rating: 1400, RD: 30, Volatility: 0.06}, Score: 1},
+		{Opponent: Glicko2{Rating: 1550, RD: 100, Volatility: 0.06}, Score: 0},
+		{Opponent: Glicko2{Rating: 1700, RD: 300, Volatility: 0.06}, Score: 0},
+	}
+	got := Glicko2Update(p, results, 0.5)
+	if math.Abs(got.Rating-1464.06) > 0.01 || math.Abs(got.RD-151.52) > 0.01 || math.Abs(got.Volatility-0.05999) > 0.00001 {
+		t.Fatalf("unexpected glicko-2 result %+v", got)
+	}
+}
+
+func TestEloUpdate(t *testing.T) {
+	a, b := EloUpdate(1500, 1500, 1, 32)
+	if a != 1516 || b != 1484 {
+		t.Fatalf("unexpected elo %f %f", a, b)
+	}
+}
+
+func TestRecordAndLeaderboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(DefaultConfig(), store)
+
+	outcome, ok := OutcomeOf(chessgame.NewGameOver(core.Group2, chessgame.TerminationCapture), core.Group1)
+	if !ok || outcome != BlackWin {
+		t.Fatal("group 2 should be black")
+	}
+	s.Record(1, 2, outcome)
+	s.Record(3, 2, Draw)
+
+	//重新打开文件，评分应该已经保存
+	store, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = NewService(DefaultConfig(), store)
+	board := s.Leaderboard(2, ByGlicko)
+	if len(board) != 2 || board[0].PlayerID != 2 {
+		t.Fatalf("player 2 should lead, got %+v", board)
+	}
+	if board[0].Games != 2 || board[0].Wins != 1 || board[0].Draws != 1 {
+		t.Fatalf("unexpected record %+v", board[0])
+	}
+}
@@ -0,0 +1,112 @@
+package rating
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store 玩家评分的存储
+type Store interface {
+	Get(playerID int) (Rating, bool) //查询玩家评分
+	Put(r Rating) error              //保存玩家评分
+	All() []Rating                   //返回所有玩家的评分
+}
+
+// MemoryStore 内存中的评分存储
+type MemoryStore struct {
+	mu      sync.Mutex
+	ratings map[int]Rating
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		ratings: make(map[int]Rating),
+	}
+}
+
+// Get 查询玩家评分
+func (s *MemoryStore) Get(playerID int) (Rating, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.ratings[playerID]
+	return r, ok
+}
+
+// Put 保存玩家评分
+func (s *MemoryStore) Put(r Rating) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ratings[r.PlayerID] = r
+	return nil
+}
+
+// All 返回所有玩家的评分
+func (s *MemoryStore) All() []Rating {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make([]Rating, 0, len(s.ratings))
+	for _, r := range s.ratings {
+		all = append(all, r)
+	}
+	return all
+}
+
+// FileStore 保存在json文件中的评分存储，每次保存都会重写整个文件
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewFileStore 打开评分文件，文件不存在时创建空的存储
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ratings []Rating
+	if err = json.Unmarshal(data, &ratings); err != nil {
+		return nil, err
+	}
+	for _, r := range ratings {
+		s.ratings[r.PlayerID] = r
+	}
+	return s, nil
+}
+
+// Put 保存玩家评分并写入文件
+func (s *FileStore) Put(r Rating) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ratings[r.PlayerID] = r
+
+	all := make([]Rating, 0, len(s.ratings))
+	for _, r := range s.ratings {
+		all = append(all, r)
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	//先写临时文件再替换，避免写入中断损坏评分文件
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
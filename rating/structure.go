@@ -0,0 +1,58 @@
+package rating
+
+import "time"
+
+// Outcome 对局结果
+type Outcome int
+
+const (
+	RedWin   Outcome = iota //红方（先手）胜
+	BlackWin                //黑方（后手）胜
+	Draw                    //和棋
+)
+
+// Glicko2 玩家的Glicko-2评分
+type Glicko2 struct {
+	Rating     float64 //评分
+	RD         float64 //评分偏差，越小说明评分越可信
+	Volatility float64 //评分波动率
+}
+
+// Rating 玩家的评分和战绩
+type Rating struct {
+	PlayerID  int
+	Elo       float64
+	Glicko    Glicko2
+	Games     int
+	Wins      int
+	Draws     int
+	Losses    int
+	UpdatedAt time.Time
+}
+
+// Config 评分计算的参数
+type Config struct {
+	K   float64 //Elo的K值
+	Tau float64 //Glicko-2的系统常数，限制波动率的变化
+}
+
+// DefaultConfig 默认的评分参数
+func DefaultConfig() Config {
+	return Config{
+		K:   32,
+		Tau: 0.5,
+	}
+}
+
+// NewRating 新玩家的初始评分
+func NewRating(playerID int) Rating {
+	return Rating{
+		PlayerID: playerID,
+		Elo:      1500,
+		Glicko: Glicko2{
+			Rating:     1500,
+			RD:         350,
+			Volatility: 0.06,
+		},
+	}
+}
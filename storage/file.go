@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/CXeon/xiangqi/rating"
+)
+
+const (
+	playersFile = "players.jsonl"
+	gamesFile   = "games.jsonl"
+	ratingsFile = "ratings.jsonl"
+)
+
+// FileRepository 保存在目录中的存储。玩家、对局和评分各自是一个json lines文件，
+// 每次修改只在文件末尾追加一行，打开时读取全部数据到内存
+type FileRepository struct {
+	*MemoryRepository
+	dir     string
+	players *os.File
+	games   *os.File
+	ratings *fileRatingStore
+}
+
+// OpenFileRepository 打开存储目录，目录不存在时创建
+func OpenFileRepository(dir string) (*FileRepository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := &FileRepository{
+		MemoryRepository: NewMemoryRepository(),
+		dir:              dir,
+	}
+
+	err := readLines(filepath.Join(dir, playersFile), func(dec *json.Decoder) error {
+		var p Profile
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+		r.addPlayer(p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readLines(filepath.Join(dir, gamesFile), func(dec *json.Decoder) error {
+		var rec GameRecord
+		if err := dec.Decode(&rec); err != nil {
+			return err
+		}
+		r.MemoryRepository.games = append(r.MemoryRepository.games, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//同一个玩家的评分可能有多行，以最后一行为准
+	ratings := rating.NewMemoryStore()
+	err = readLines(filepath.Join(dir, ratingsFile), func(dec *json.Decoder) error {
+		var rt rating.Rating
+		if err := dec.Decode(&rt); err != nil {
+			return err
+		}
+		return ratings.Put(rt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.players, err = openAppend(filepath.Join(dir, playersFile)); err != nil {
+		return nil, err
+	}
+	if r.games, err = openAppend(filepath.Join(dir, gamesFile)); err != nil {
+		r.players.Close()
+		return nil, err
+	}
+	ratingsOut, err := openAppend(filepath.Join(dir, ratingsFile))
+	if err != nil {
+		r.players.Close()
+		r.games.Close()
+		return nil, err
+	}
+	r.ratings = &fileRatingStore{MemoryStore: ratings, out: ratingsOut}
+	r.MemoryRepository.ratings = r.ratings
+
+	return r, nil
+}
+
+// CreatePlayer 创建玩家并写入文件
+func (r *FileRepository) CreatePlayer(name string) (Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, err := r.createPlayer(0, name)
+	if err != nil {
+		return p, err
+	}
+	return p, r.appendPlayer(p)
+}
+
+// EnsurePlayer 查询玩家，不存在时使用指定的id创建并写入文件
+func (r *FileRepository) EnsurePlayer(id int, name string) (Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.MemoryRepository.players[id]; ok {
+		return p, nil
+	}
+	p, err := r.createPlayer(id, name)
+	if err != nil {
+		return p, err
+	}
+	return p, r.appendPlayer(p)
+}
+
+// 写入失败时撤销内存中的玩家，保持内存和文件一致
+func (r *FileRepository) appendPlayer(p Profile) error {
+	if err := appendLine(r.players, p); err != nil {
+		delete(r.MemoryRepository.players, p.ID)
+		return err
+	}
+	return nil
+}
+
+// SaveGame 保存对局记录并写入文件
+func (r *FileRepository) SaveGame(rec GameRecord) (GameRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec.ID = len(r.MemoryRepository.games) + 1
+	if err := appendLine(r.games, rec); err != nil {
+		return rec, err
+	}
+	r.MemoryRepository.games = append(r.MemoryRepository.games, rec)
+	return rec, nil
+}
+
+// Close 关闭数据文件
+func (r *FileRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.players.Close(), r.games.Close(), r.ratings.close())
+}
+
+// 保存在json lines文件中的评分存储
+type fileRatingStore struct {
+	*rating.MemoryStore
+	mu  sync.Mutex
+	out *os.File
+}
+
+// Put 保存玩家评分并追加到文件
+func (s *fileRatingStore) Put(rt rating.Rating) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := appendLine(s.out, rt); err != nil {
+		return err
+	}
+	return s.MemoryStore.Put(rt)
+}
+
+func (s *fileRatingStore) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.out.Close()
+}
+
+// 逐行读取json lines文件，文件不存在时不做任何处理。
+// 最后一行不完整说明上次写入时程序中断，截掉这一行，避免之后追加的数据接在后面
+func readLines(path string, decode func(dec *json.Decoder) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	var valid int64
+	for dec.More() {
+		err = decode(dec)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return os.Truncate(path, valid)
+		}
+		if err != nil {
+			return err
+		}
+		valid = dec.InputOffset() + 1 //包括行尾的换行符
+	}
+	return nil
+}
+
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+}
+
+// 将一条数据编码为一行json追加到文件末尾
+func appendLine(f *os.File, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
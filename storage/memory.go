@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// MemoryRepository 内存中的存储，程序退出后数据丢失
+type MemoryRepository struct {
+	mu      sync.Mutex
+	players map[int]Profile
+	games   []GameRecord
+	nextID  int //下一个自动分配的玩家id
+	ratings rating.Store
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		players: make(map[int]Profile),
+		games:   make([]GameRecord, 0),
+		nextID:  1,
+		ratings: rating.NewMemoryStore(),
+	}
+}
+
+// CreatePlayer 创建玩家，自动分配id
+func (r *MemoryRepository) CreatePlayer(name string) (Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createPlayer(0, name)
+}
+
+// EnsurePlayer 查询玩家，不存在时使用指定的id创建
+func (r *MemoryRepository) EnsurePlayer(id int, name string) (Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.players[id]; ok {
+		return p, nil
+	}
+	return r.createPlayer(id, name)
+}
+
+// id为0时自动分配，需要持有锁
+func (r *MemoryRepository) createPlayer(id int, name string) (Profile, error) {
+	for _, p := range r.players {
+		if p.Name == name {
+			return Profile{}, fmt.Errorf("%w: %s", ErrPlayerExists, name)
+		}
+	}
+	if id == 0 {
+		id = r.nextID
+	}
+	if _, ok := r.players[id]; ok {
+		return Profile{}, fmt.Errorf("%w: %d", ErrPlayerExists, id)
+	}
+	p := Profile{
+		ID:        id,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	r.addPlayer(p)
+	return p, nil
+}
+
+func (r *MemoryRepository) addPlayer(p Profile) {
+	r.players[p.ID] = p
+	if p.ID >= r.nextID {
+		r.nextID = p.ID + 1
+	}
+}
+
+// GetPlayer 查询玩家资料
+func (r *MemoryRepository) GetPlayer(id int) (Profile, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.players[id]
+	return p, ok
+}
+
+// FindPlayer 按名称查询玩家资料
+func (r *MemoryRepository) FindPlayer(name string) (Profile, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.players {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// ListPlayers 返回所有玩家，按id排序
+func (r *MemoryRepository) ListPlayers() []Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]Profile, 0, len(r.players))
+	for _, p := range r.players {
+		all = append(all, p)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
+
+// SaveGame 保存对局记录，自动分配id
+func (r *MemoryRepository) SaveGame(rec GameRecord) (GameRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec.ID = len(r.games) + 1
+	r.games = append(r.games, rec)
+	return rec, nil
+}
+
+// GetGame 查询对局记录
+func (r *MemoryRepository) GetGame(id int) (GameRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id <= 0 || id > len(r.games) {
+		return GameRecord{}, false
+	}
+	return r.games[id-1], true
+}
+
+// ListGames 返回玩家参与的对局，playerID为0时返回所有对局
+func (r *MemoryRepository) ListGames(playerID int) []GameRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listGames(playerID)
+}
+
+func (r *MemoryRepository) listGames(playerID int) []GameRecord {
+	games := make([]GameRecord, 0)
+	for _, rec := range r.games {
+		if playerID == 0 || rec.RedID == playerID || rec.BlackID == playerID {
+			games = append(games, rec)
+		}
+	}
+	return games
+}
+
+// GetStats 汇总玩家的统计数据
+func (r *MemoryRepository) GetStats(playerID int) Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return computeStats(playerID, r.listGames(playerID))
+}
+
+// Ratings 玩家评分的存储
+func (r *MemoryRepository) Ratings() rating.Store {
+	return r.ratings
+}
+
+func (r *MemoryRepository) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/ecco"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+var ErrPlayerExists = errors.New("player already exists")
+
+// Repository 玩家资料和对局记录的存储，服务器和桌面程序共用
+type Repository interface {
+	CreatePlayer(name string) (Profile, error)         //创建玩家，自动分配id
+	EnsurePlayer(id int, name string) (Profile, error) //查询玩家，不存在时使用指定的id创建
+	GetPlayer(id int) (Profile, bool)                  //查询玩家资料
+	FindPlayer(name string) (Profile, bool)            //按名称查询玩家资料
+	ListPlayers() []Profile                            //返回所有玩家，按id排序
+
+	SaveGame(rec GameRecord) (GameRecord, error) //保存对局记录，自动分配id
+	GetGame(id int) (GameRecord, bool)           //查询对局记录
+	ListGames(playerID int) []GameRecord         //返回玩家参与的对局，playerID为0时返回所有对局
+	GetStats(playerID int) Stats                 //汇总玩家的统计数据
+
+	Ratings() rating.Store //玩家评分的存储
+	Close() error
+}
+
+// NewGameRecord 根据结束的棋局生成对局记录。msg是内核或者服务器产生的结束事件，red和black是双方玩家
+func NewGameRecord(game chessgame.ChessGameInterface, red, black player.PlayerInterface, msg chessgame.GameMsg, startedAt time.Time) GameRecord {
+	outcome, _ := rating.OutcomeOf(msg, red.GetGroup())
+	rec := GameRecord{
+		RedID:     red.GetID(),
+		BlackID:   black.GetID(),
+		Result:    outcome,
+		Reason:    reasonOf(msg),
+		RedIsDown: red.GetIsDown(),
+		Moves:     game.GetHistory(),
+		FEN:       game.GetPosition().FEN(),
+		StartedAt: startedAt,
+		EndedAt:   time.Now(),
+	}
+	if start := game.GetInitialPosition().FEN(); start != position.InitialFEN {
+		rec.StartFEN = start
+	}
+	if op, ok := ecco.Default().ClassifyGame(game); ok {
+		rec.ECCO = op.Code
+		rec.Opening = op.Name
+	}
+	rec.RedCaptures = captures(red)
+	rec.BlackCaptures = captures(black)
+	return rec
+}
+
+// 对局结束的原因
+func reasonOf(msg chessgame.GameMsg) chessgame.Termination {
+	if msg.GameOver == nil {
+		return ""
+	}
+	return msg.GameOver.Termination
+}
+
+// StartPosition 对局开始时的局面
+func (rec GameRecord) StartPosition() (position.Position, error) {
+	if len(rec.StartFEN) == 0 {
+		return position.Initial(), nil
+	}
+	return position.ParseFEN(rec.StartFEN)
+}
+
+// PositionMoves 将下棋记录转换成红方视角的着法
+func (rec GameRecord) PositionMoves() []position.Move {
+	moves := make([]position.Move, len(rec.Moves))
+	for i, st := range rec.Moves {
+		moves[i] = position.Move{
+			From: position.ToSquare(st.Source, rec.RedIsDown),
+			To:   position.ToSquare(st.Target, rec.RedIsDown),
+		}
+	}
+	return moves
+}
+
+func captures(p player.PlayerInterface) []core.ChessmanCode {
+	won, err := p.GetWonChessmen()
+	if err != nil {
+		return []core.ChessmanCode{}
+	}
+	return append([]core.ChessmanCode{}, won...)
+}
+
+// 按对局记录汇总玩家的统计数据
+func computeStats(playerID int, games []GameRecord) Stats {
+	s := Stats{
+		PlayerID: playerID,
+		Captured: make(map[core.ChessmanCode]int),
+	}
+	for _, rec := range games {
+		var cs *ColorStats
+		var won, lost []core.ChessmanCode
+		var winOutcome rating.Outcome
+		switch playerID {
+		case rec.RedID:
+			cs, won, lost, winOutcome = &s.Red, rec.RedCaptures, rec.BlackCaptures, rating.RedWin
+		case rec.BlackID:
+			cs, won, lost, winOutcome = &s.Black, rec.BlackCaptures, rec.RedCaptures, rating.BlackWin
+		default:
+			continue
+		}
+
+		cs.Games++
+		switch rec.Result {
+		case rating.Draw:
+			cs.Draws++
+		case winOutcome:
+			cs.Wins++
+		default:
+			cs.Losses++
+		}
+
+		s.Captures += len(won)
+		for _, code := range won {
+			s.Captured[code]++
+		}
+		s.Lost += len(lost)
+	}
+	return s
+}
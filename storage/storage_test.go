@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+func TestFileRepositoryReopen(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := OpenFileRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := repo.CreatePlayer("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.CreatePlayer("alice"); err == nil {
+		t.Fatal("duplicated name should fail")
+	}
+	bob, _ := repo.EnsurePlayer(10, "bob")
+	repo.SaveGame(GameRecord{RedID: alice.ID, BlackID: bob.ID, Result: rating.BlackWin, RedCaptures: []core.ChessmanCode{core.Pao}})
+	repo.Ratings().Put(rating.NewRating(alice.ID))
+	r := rating.NewRating(alice.ID)
+	r.Elo = 1516
+	repo.Ratings().Put(r)
+	if err = repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//模拟写入中断，文件末尾留下不完整的一行
+	f, _ := os.OpenFile(filepath.Join(dir, gamesFile), os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"id":2,"redId":`)
+	f.Close()
+
+	repo, err = OpenFileRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if p, ok := repo.FindPlayer("bob"); !ok || p.ID != 10 {
+		t.Fatalf("unexpected player %+v", p)
+	}
+	if p, _ := repo.CreatePlayer("carol"); p.ID != 11 {
+		t.Fatalf("expect id 11, got %d", p.ID)
+	}
+	if games := repo.ListGames(bob.ID); len(games) != 1 || games[0].ID != 1 {
+		t.Fatalf("unexpected games %+v", games)
+	}
+	if rec, _ := repo.SaveGame(GameRecord{RedID: bob.ID, BlackID: alice.ID}); rec.ID != 2 {
+		t.Fatalf("expect game id 2, got %d", rec.ID)
+	}
+	if r, _ := repo.Ratings().Get(alice.ID); r.Elo != 1516 {
+		t.Fatalf("expect the last rating, got %v", r.Elo)
+	}
+}
+
+func TestStats(t *testing.T) {
+	repo := NewMemoryRepository()
+	repo.SaveGame(GameRecord{RedID: 1, BlackID: 2, Result: rating.RedWin,
+		RedCaptures: []core.ChessmanCode{core.Pao, core.BingZu}, BlackCaptures: []core.ChessmanCode{core.Ma}})
+	repo.SaveGame(GameRecord{RedID: 2, BlackID: 1, Result: rating.Draw})
+	repo.SaveGame(GameRecord{RedID: 2, BlackID: 1, Result: rating.RedWin, RedCaptures: []core.ChessmanCode{core.Ju}})
+
+	s := repo.GetStats(1)
+	if s.Red != (ColorStats{Games: 1, Wins: 1}) || s.Black != (ColorStats{Games: 2, Draws: 1, Losses: 1}) {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.Captures != 2 || s.Captured[core.Pao] != 1 || s.Lost != 2 {
+		t.Fatalf("unexpected captures %+v", s)
+	}
+	if total := s.Total(); total.Games != 3 || total.Wins != 1 {
+		t.Fatalf("unexpected total %+v", total)
+	}
+}
+
+func TestNewGameRecord(t *testing.T) {
+	red := player.NewPlayer()
+	red.SetID(1)
+	red.SetGroup(core.Group1)
+	red.SetIsFirst(true)
+	red.SetIsDown(true)
+	black := player.NewPlayer()
+	black.SetID(2)
+	black.SetGroup(core.Group2)
+
+	game := new(chessgame.ChessGame)
+	if err := game.InitialGame(red, black); err != nil {
+		t.Fatal(err)
+	}
+	redCh := make(chan player.Statement, 1)
+	blackCh := make(chan player.Statement, 1)
+	msgCh := game.Run(redCh, blackCh)
+	defer game.Close()
+
+	//炮二进七吃马
+	redCh <- player.Statement{Group: core.Group1, Code: core.Pao, Source: core.Coordinate{X: 1, Y: 2}, Target: core.Coordinate{X: 1, Y: 9}}
+	if msg := <-msgCh; msg.Event != chessgame.Done {
+		t.Fatalf("expect done, got %+v", msg)
+	}
+
+	//红方超时判负
+	rec := NewGameRecord(game, red, black, chessgame.NewGameOver(core.Group2, chessgame.TerminationTimeout), time.Now())
+	if rec.RedID != 1 || rec.BlackID != 2 || rec.Result != rating.BlackWin || rec.Reason != "timeout" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	if len(rec.Moves) != 1 || len(rec.RedCaptures) != 1 || rec.RedCaptures[0] != core.Ma || len(rec.BlackCaptures) != 0 {
+		t.Fatalf("unexpected moves or captures %+v", rec)
+	}
+	if rec.FEN != "rnbakabCr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C7/9/RNBAKABNR b" {
+		t.Fatalf("unexpected fen %s", rec.FEN)
+	}
+	if rec.ECCO != "A00" || len(rec.StartFEN) != 0 {
+		t.Fatalf("unexpected opening %s %s, start %s", rec.ECCO, rec.Opening, rec.StartFEN)
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// Profile 玩家资料
+type Profile struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GameRecord 一局已经结束的对局
+type GameRecord struct {
+	ID      int    `json:"id"`
+	Room    string `json:"room,omitempty"` //网络对战的房间名称
+	RedID   int    `json:"redId"`          //红方（先手）玩家id，为0表示匿名玩家
+	BlackID int    `json:"blackId"`        //黑方（后手）玩家id，为0表示匿名玩家
+
+	Result rating.Outcome        `json:"result"`           //对局结果
+	Reason chessgame.Termination `json:"reason,omitempty"` //结束的原因，例如capture、timeout、abandoned
+
+	ECCO          string              `json:"ecco,omitempty"`     //ECCO开局编码
+	Opening       string              `json:"opening,omitempty"`  //开局名称
+	StartFEN      string              `json:"startFen,omitempty"` //开始局面，为空表示标准的开局局面
+	RedIsDown     bool                `json:"redIsDown"`          //红方是否位于棋盘下方，决定下棋记录的坐标视角
+	Moves         []player.Statement  `json:"moves"`              //下棋记录
+	FEN           string              `json:"fen"`                //终局局面
+	RedCaptures   []core.ChessmanCode `json:"redCaptures"`        //红方吃掉的棋子
+	BlackCaptures []core.ChessmanCode `json:"blackCaptures"`      //黑方吃掉的棋子
+
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
+
+// ColorStats 玩家执某一方时的战绩
+type ColorStats struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+// Stats 玩家的统计数据，由对局记录汇总得到
+type Stats struct {
+	PlayerID int        `json:"playerId"`
+	Red      ColorStats `json:"red"`   //执红的战绩
+	Black    ColorStats `json:"black"` //执黑的战绩
+
+	Captures int                       `json:"captures"` //吃掉对方棋子的总数
+	Captured map[core.ChessmanCode]int `json:"captured"` //按棋子统计吃子数
+	Lost     int                       `json:"lost"`     //被对方吃掉的棋子总数
+}
+
+// Total 不分红黑的总战绩
+func (s Stats) Total() ColorStats {
+	return ColorStats{
+		Games:  s.Red.Games + s.Black.Games,
+		Wins:   s.Red.Wins + s.Black.Wins,
+		Draws:  s.Red.Draws + s.Black.Draws,
+		Losses: s.Red.Losses + s.Black.Losses,
+	}
+}
@@ -0,0 +1,112 @@
+package study
+
+import (
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Cursor 在着法树中前进后退，记录当前的一步棋和局面
+type Cursor struct {
+	study *Study
+	start position.Position
+	node  *Node
+	pos   position.Position
+}
+
+// NewCursor 从排局的起始局面开始
+func NewCursor(s *Study) (*Cursor, error) {
+	start, err := s.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{study: s, start: start, node: s.Root, pos: start}, nil
+}
+
+// Study 获取排局
+func (c *Cursor) Study() *Study {
+	return c.study
+}
+
+// Node 当前的一步棋，位于起始局面时是根节点
+func (c *Cursor) Node() *Node {
+	return c.node
+}
+
+// Position 当前局面
+func (c *Cursor) Position() position.Position {
+	return c.pos
+}
+
+// Comment 当前这一步棋的注释，位于起始局面时是开局的注释
+func (c *Cursor) Comment() string {
+	return c.node.Comment
+}
+
+// LastMove 走到当前局面的一步棋，以及走这步棋之前的局面，用于生成中文记谱
+func (c *Cursor) LastMove() (position.Move, position.Position, bool) {
+	if c.node.IsRoot() {
+		return position.Move{}, position.Position{}, false
+	}
+	return c.node.Move, c.replay(c.node.Parent), true
+}
+
+// Variations 当前局面下可以走的着法，第一个是主变
+func (c *Cursor) Variations() []*Node {
+	return c.node.Children
+}
+
+// Forward 按第i个变着前进一步，0是主变。没有这个变着时返回false
+func (c *Cursor) Forward(i int) bool {
+	if i < 0 || i >= len(c.node.Children) {
+		return false
+	}
+	c.node = c.node.Children[i]
+	c.pos.Play(c.node.Move)
+	return true
+}
+
+// Back 后退一步，位于起始局面时返回false
+func (c *Cursor) Back() bool {
+	if c.node.IsRoot() {
+		return false
+	}
+	c.node = c.node.Parent
+	c.pos = c.replay(c.node)
+	return true
+}
+
+// Home 回到起始局面
+func (c *Cursor) Home() {
+	c.node = c.study.Root
+	c.pos = c.start
+}
+
+// End 沿着主变走到底
+func (c *Cursor) End() {
+	for c.Forward(0) {
+	}
+}
+
+// Sibling 换成同一局面下的另一个变着，offset为1是下一个、-1是上一个，循环选择。
+// 位于起始局面或者没有其他变着时返回false
+func (c *Cursor) Sibling(offset int) bool {
+	if c.node.IsRoot() || len(c.node.Parent.Children) < 2 {
+		return false
+	}
+	siblings := c.node.Parent.Children
+	i := 0
+	for siblings[i] != c.node {
+		i++
+	}
+	i = ((i+offset)%len(siblings) + len(siblings)) % len(siblings)
+	c.Back()
+	return c.Forward(i)
+}
+
+// 从起始局面走到node的局面
+func (c *Cursor) replay(node *Node) position.Position {
+	pos := c.start
+	for _, m := range node.Path() {
+		pos.Play(m)
+	}
+	return pos
+}
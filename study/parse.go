@@ -0,0 +1,236 @@
+package study
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// 排局文件的格式与PGN相似：
+//
+//	[Title "单车擒王"]
+//	[FEN "9/4k4/9/9/9/9/9/9/9/R2K5 w"]
+//	{开局的注释}
+//	1. a0a8 {着法的注释} e8e9 (1... e8e7 2. a8f8) 2. a8f8
+//
+// 着法为ICCS格式，回合数和结果（1-0、0-1、1/2-1/2、*）可以省略；
+// 花括号中的注释属于前一步棋，圆括号中的变着替代前一步棋；
+// 每个排局以Title开始，没有FEN时从开局局面开始。注释之外 # 到行尾是文件的注释
+
+// Load 读取排局文件，用内核的规则校验每一步棋
+func Load(r io.Reader) ([]*Study, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	referee, err := chessgame.NewReferee()
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{referee: referee, positions: make(map[*Node]position.Position)}
+	if err := p.parse(string(data)); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return p.studies, nil
+}
+
+// Parse 解析一个排局
+func Parse(text string) (*Study, error) {
+	studies, err := Load(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(studies) != 1 {
+		return nil, fmt.Errorf("expect 1 study, got %d", len(studies))
+	}
+	return studies[0], nil
+}
+
+type parser struct {
+	referee   *chessgame.Referee
+	studies   []*Study
+	study     *Study
+	node      *Node   //最近的一步棋
+	stack     []*Node //进入变着之前的一步棋
+	positions map[*Node]position.Position
+	line      int
+}
+
+func (p *parser) parse(text string) error {
+	p.line = 1
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		switch {
+		case r == '\n':
+			p.line++
+			text = text[1:]
+		case unicode.IsSpace(r):
+			text = text[size:]
+		case r == '#':
+			end := strings.IndexByte(text, '\n')
+			if end < 0 {
+				end = len(text)
+			}
+			text = text[end:]
+		case r == '[' || r == '{':
+			closing := map[rune]string{'[': "]", '{': "}"}[r]
+			end := strings.Index(text, closing)
+			if end < 0 {
+				return fmt.Errorf("missing %q", closing)
+			}
+			body := text[1:end]
+			var err error
+			if r == '[' {
+				err = p.header(body)
+			} else {
+				err = p.comment(body)
+			}
+			if err != nil {
+				return err
+			}
+			p.line += strings.Count(body, "\n")
+			text = text[end+1:]
+		case r == '(':
+			if err := p.begin(); err != nil {
+				return err
+			}
+			text = text[1:]
+		case r == ')':
+			if err := p.end(); err != nil {
+				return err
+			}
+			text = text[1:]
+		default:
+			end := strings.IndexFunc(text, func(r rune) bool {
+				return unicode.IsSpace(r) || strings.ContainsRune("[]{}()", r)
+			})
+			if end < 0 {
+				end = len(text)
+			}
+			if err := p.token(text[:end]); err != nil {
+				return err
+			}
+			text = text[end:]
+		}
+	}
+	return p.finish()
+}
+
+// 标签：Title开始新的排局，FEN设置起始局面
+func (p *parser) header(body string) error {
+	name, value, ok := strings.Cut(strings.TrimSpace(body), " ")
+	if !ok {
+		return fmt.Errorf("invalid header %q", body)
+	}
+	value = strings.TrimSpace(value)
+	if v, err := strconv.Unquote(value); err == nil {
+		value = v
+	}
+	switch name {
+	case "Title":
+		if err := p.finish(); err != nil {
+			return err
+		}
+		p.study = &Study{Title: value, FEN: position.InitialFEN, Root: &Node{}}
+		p.node = p.study.Root
+		p.positions[p.node] = position.Initial()
+	case "FEN":
+		if p.study == nil || p.node != p.study.Root {
+			return fmt.Errorf("FEN must follow Title and precede moves")
+		}
+		pos, err := position.ParseFEN(value)
+		if err != nil {
+			return err
+		}
+		p.study.FEN = value
+		p.positions[p.node] = pos
+	default:
+		//其他标签忽略
+	}
+	return nil
+}
+
+func (p *parser) comment(body string) error {
+	if p.study == nil {
+		return fmt.Errorf("comment before Title")
+	}
+	body = strings.Join(strings.Fields(body), " ")
+	if len(p.node.Comment) > 0 {
+		p.node.Comment += " "
+	}
+	p.node.Comment += body
+	return nil
+}
+
+// 变着开始：回到前一步棋之前的局面
+func (p *parser) begin() error {
+	if p.study == nil || p.node.IsRoot() {
+		return fmt.Errorf("variation without a move to replace")
+	}
+	p.stack = append(p.stack, p.node)
+	p.node = p.node.Parent
+	return nil
+}
+
+// 变着结束：回到进入变着之前的一步棋
+func (p *parser) end() error {
+	if len(p.stack) == 0 {
+		return fmt.Errorf("unbalanced ')'")
+	}
+	p.node = p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	return nil
+}
+
+// 着法、回合数或者结果
+func (p *parser) token(tok string) error {
+	switch tok {
+	case "1-0", "0-1", "1/2-1/2", "*":
+		return nil
+	}
+	//回合数可以和着法连在一起，例如 1.a0a8
+	tok = strings.TrimLeft(tok, "0123456789")
+	tok = strings.TrimLeft(tok, ".")
+	if len(tok) == 0 {
+		return nil
+	}
+	if p.study == nil {
+		return fmt.Errorf("move %q before Title", tok)
+	}
+	m, err := position.ParseMove(tok)
+	if err != nil {
+		return err
+	}
+	pos := p.positions[p.node]
+	legal, err := p.referee.IsLegal(pos, m)
+	if err != nil {
+		return err
+	}
+	if !legal {
+		return fmt.Errorf("%s: illegal move %s after %d plies", p.study.Title, m, p.node.Ply())
+	}
+	p.node = p.node.add(m)
+	pos.Play(m)
+	p.positions[p.node] = pos
+	return nil
+}
+
+// 结束当前的排局
+func (p *parser) finish() error {
+	if p.study == nil {
+		return nil
+	}
+	if len(p.stack) > 0 {
+		return fmt.Errorf("%s: unclosed variation", p.study.Title)
+	}
+	p.studies = append(p.studies, p.study)
+	p.study = nil
+	p.node = nil
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package study 排局和残局的欣赏。
+// 一个排局由起始局面和着法树组成，树中每个节点是一步棋，第一个子节点是主变，其余子节点是变着，
+// 每步棋可以带有注释
+package study
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+//go:embed studies.txt
+var defaultStudies string
+
+// Node 着法树中的一步棋，根节点没有着法，只保存开局的注释
+type Node struct {
+	Move     position.Move
+	Comment  string
+	Parent   *Node
+	Children []*Node //第一个是主变，其余是变着
+}
+
+// IsRoot 是否是根节点
+func (n *Node) IsRoot() bool {
+	return n.Parent == nil
+}
+
+// Ply 从起始局面走到这一步的步数，根节点为0
+func (n *Node) Ply() int {
+	ply := 0
+	for p := n; p.Parent != nil; p = p.Parent {
+		ply++
+	}
+	return ply
+}
+
+// Path 从根节点走到这一步的着法
+func (n *Node) Path() []position.Move {
+	moves := make([]position.Move, n.Ply())
+	for p, i := n, len(moves)-1; p.Parent != nil; p, i = p.Parent, i-1 {
+		moves[i] = p.Move
+	}
+	return moves
+}
+
+// 添加一步棋，已经有相同着法时返回原来的节点
+func (n *Node) add(m position.Move) *Node {
+	for _, child := range n.Children {
+		if child.Move == m {
+			return child
+		}
+	}
+	child := &Node{Move: m, Parent: n}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Study 一个排局
+type Study struct {
+	Title string
+	FEN   string
+	Root  *Node
+}
+
+// Start 起始局面
+func (s *Study) Start() (position.Position, error) {
+	return position.ParseFEN(s.FEN)
+}
+
+// MainLine 主变的着法
+func (s *Study) MainLine() []position.Move {
+	moves := make([]position.Move, 0)
+	for n := s.Root; len(n.Children) > 0; n = n.Children[0] {
+		moves = append(moves, n.Children[0].Move)
+	}
+	return moves
+}
+
+// String 按排局文件的格式输出
+func (s *Study) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Title %q]\n[FEN %q]\n", s.Title, s.FEN)
+	if len(s.Root.Comment) > 0 {
+		fmt.Fprintf(&b, "{%s}\n", s.Root.Comment)
+	}
+	start, _ := s.Start()
+	w := writer{b: &b, blackFirst: !start.RedToMove}
+	w.line(s.Root, start.RedToMove, true)
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+type writer struct {
+	b          *strings.Builder
+	blackFirst bool //起始局面轮到黑方走，回合数要加上黑方的第一步
+}
+
+// 输出node之后的着法，主变在前，变着放在括号中紧跟着被替代的着法
+func (w writer) line(node *Node, redToMove, number bool) {
+	for len(node.Children) > 0 {
+		main := node.Children[0]
+		w.move(main, redToMove, number)
+		for _, v := range node.Children[1:] {
+			w.b.WriteString("(")
+			w.move(v, redToMove, true)
+			w.line(v, !redToMove, false)
+			trimSpace(w.b)
+			w.b.WriteString(") ")
+		}
+		//被变着或者注释打断之后，黑方的着法前面也要写回合数
+		number = len(node.Children) > 1 || len(main.Comment) > 0
+		node = main
+		redToMove = !redToMove
+	}
+}
+
+func (w writer) move(n *Node, red, number bool) {
+	ply := n.Ply()
+	if w.blackFirst {
+		ply++
+	}
+	round := (ply-1)/2 + 1
+	switch {
+	case red:
+		fmt.Fprintf(w.b, "%d. ", round)
+	case number:
+		fmt.Fprintf(w.b, "%d... ", round)
+	}
+	w.b.WriteString(n.Move.String())
+	w.b.WriteString(" ")
+	if len(n.Comment) > 0 {
+		fmt.Fprintf(w.b, "{%s} ", n.Comment)
+	}
+}
+
+func trimSpace(b *strings.Builder) {
+	s := strings.TrimRight(b.String(), " ")
+	b.Reset()
+	b.WriteString(s)
+}
+
+// Default 内置的排局
+func Default() []*Study {
+	studies, err := Load(strings.NewReader(defaultStudies))
+	if err != nil {
+		panic(err)
+	}
+	return studies
+}
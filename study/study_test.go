@@ -0,0 +1,116 @@
+package study
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `
+# 测试
+[Title "单车擒王"]
+[FEN "9/4k4/9/9/9/9/9/9/9/R2K5 w"]
+{开局}
+1. a0a8 {照将} e8e9 (1... e8e7 2. a8f8 {困毙}) 2.a8f8 1-0
+`
+
+func TestDefault(t *testing.T) {
+	studies := Default()
+	if len(studies) == 0 {
+		t.Fatal("no default studies")
+	}
+	for _, s := range studies {
+		if len(s.Title) == 0 || len(s.MainLine()) == 0 {
+			t.Errorf("incomplete study %+v", s)
+		}
+		//重新解析输出的文本，得到相同的排局
+		again, err := Parse(s.String())
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", s.Title, err, s)
+		}
+		if again.String() != s.String() {
+			t.Errorf("%s: round trip mismatch\n%s\n%s", s.Title, s, again)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Title != "单车擒王" || s.Root.Comment != "开局" {
+		t.Errorf("header = %q %q", s.Title, s.Root.Comment)
+	}
+	var main []string
+	for _, m := range s.MainLine() {
+		main = append(main, m.String())
+	}
+	if got := strings.Join(main, " "); got != "a0a8 e8e9 a8f8" {
+		t.Errorf("main line = %s", got)
+	}
+	first := s.Root.Children[0]
+	if first.Comment != "照将" || len(first.Children) != 2 {
+		t.Fatalf("first move = %+v", first)
+	}
+	v := first.Children[1]
+	if v.Move.String() != "e8e7" || v.Children[0].Comment != "困毙" {
+		t.Errorf("variation = %+v", v)
+	}
+
+	for _, bad := range []string{
+		`[Title "x"] a0a5`, //不合法的着法
+		`[Title "x"] [FEN "9/4k4/9/9/9/9/9/9/9/R2K5 w"] ( a0a8`, //没有被替代的着法
+		`[Title "x"] [FEN "9/4k4/9/9/9/9/9/9/9/R2K5 w"] a0a8 (a0a7`,
+		`[Title "x"] {没有结束`,
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	s, err := Parse(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := c.Position()
+	if c.Back() || c.Sibling(1) {
+		t.Error("moved before the start")
+	}
+	if !c.Forward(0) || c.Comment() != "照将" {
+		t.Fatalf("forward: %q", c.Comment())
+	}
+	if len(c.Variations()) != 2 || !c.Forward(1) {
+		t.Fatal("variation")
+	}
+	m, before, ok := c.LastMove()
+	if !ok || m.String() != "e8e7" || before.ChineseMove(m) != "将5进1" {
+		t.Errorf("last move = %v %s", m, before.ChineseMove(m))
+	}
+	if !c.Sibling(1) || c.Node().Move.String() != "e8e9" {
+		t.Errorf("sibling = %v", c.Node().Move)
+	}
+	c.End()
+	want := start
+	for _, m := range s.MainLine() {
+		want.Play(m)
+	}
+	if c.Position() != want || c.Node().Ply() != 3 {
+		t.Error("end of main line")
+	}
+	for c.Back() {
+	}
+	if c.Position() != start {
+		t.Error("back to start")
+	}
+	c.End()
+	c.Home()
+	if c.Position() != start || !c.Node().IsRoot() {
+		t.Error("home")
+	}
+}
@@ -0,0 +1,252 @@
+package tablebase
+
+import (
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessboard"
+	"github.com/CXeon/xiangqi/core/chessman"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// 生成残局表时使用的棋盘，红方固定在棋盘下方，着法由内核的棋子规则判断
+type board struct {
+	layout   *layout
+	matrix   [][]chessman.ChessmanInterface
+	rowGroup map[int]core.ChessmanGroup
+	men      []chessman.ChessmanInterface //每个棋子对应的内核棋子
+	pos      []int                        //每个棋子所在的格子，被吃掉时为-1
+}
+
+const (
+	redGroup   = core.Group1
+	blackGroup = core.Group2
+)
+
+func newBoard(l *layout) *board {
+	b := &board{
+		layout:   l,
+		matrix:   make([][]chessman.ChessmanInterface, position.Ranks),
+		rowGroup: make(map[int]core.ChessmanGroup, position.Ranks),
+		pos:      make([]int, len(l.slots)),
+	}
+	for y := range b.matrix {
+		b.matrix[y] = make([]chessman.ChessmanInterface, position.Files)
+		if y < 5 {
+			b.rowGroup[y] = redGroup
+		} else {
+			b.rowGroup[y] = blackGroup
+		}
+	}
+	for _, gi := range l.slots {
+		g := l.groups[gi]
+		group := redGroup
+		if !g.red {
+			group = blackGroup
+		}
+		cm := chessman.NewChessman(g.code, string(g.code), group, core.Coordinate{})
+		cm.BindRule(chessman.Rules[g.code])
+		b.men = append(b.men, cm)
+	}
+	return b
+}
+
+// 红方在下方时格子对应的内核坐标
+func coordinate(sq int) core.Coordinate {
+	return position.ToCoordinate(squareAt(sq), true)
+}
+
+func (b *board) at(sq int) chessman.ChessmanInterface {
+	co := coordinate(sq)
+	return b.matrix[co.Y][co.X]
+}
+
+func (b *board) set(sq int, cm chessman.ChessmanInterface) {
+	co := coordinate(sq)
+	b.matrix[co.Y][co.X] = cm
+}
+
+// 摆放局面，有两个棋子在同一个格子时返回false
+func (b *board) place(pos []int) bool {
+	for i, sq := range b.pos {
+		if sq >= 0 && b.at(sq) == b.men[i] {
+			b.set(sq, nil)
+		}
+	}
+	copy(b.pos, pos)
+	ok := true
+	for i, sq := range b.pos {
+		if b.at(sq) != nil {
+			ok = false
+		}
+		b.set(sq, b.men[i])
+	}
+	return ok
+}
+
+func (b *board) red(i int) bool {
+	return b.layout.groups[b.layout.slots[i]].red
+}
+
+func (b *board) code(i int) core.ChessmanCode {
+	return b.layout.groups[b.layout.slots[i]].code
+}
+
+// 将帅的序号，布局中红帅和黑将总是前两个棋子
+func kingOf(red bool) int {
+	if red {
+		return 0
+	}
+	return 1
+}
+
+// 棋子i能否按规则从from走到to
+func (b *board) canMove(i, from, to int) bool {
+	if !reach[codeIndex(b.code(i))][from][to] {
+		return false
+	}
+	ok, err := b.men[i].CheckMove(b.matrix, b.rowGroup, coordinate(from), coordinate(to))
+	return err == nil && ok
+}
+
+// 一方能否吃掉对方的将帅
+func (b *board) attacks(red bool) bool {
+	target := b.pos[kingOf(!red)]
+	if target < 0 {
+		return false
+	}
+	for i, sq := range b.pos {
+		if sq >= 0 && b.red(i) == red && b.canMove(i, sq, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// 局面是否合法：将帅不能照面，不走棋的一方不能被吃掉将帅
+func (b *board) legal(redToMove bool) bool {
+	return !chessboard.JiangShuaiFace2Face(b.matrix) && !b.attacks(redToMove)
+}
+
+// 一步着法，captured为被吃掉的棋子序号，没有吃子时为-1
+type move struct {
+	piece    int
+	from, to int
+	captured int
+}
+
+// 生成走棋方的合法着法：走完之后将帅不能照面，也不能让对方吃掉己方的将帅
+func (b *board) moves(redToMove bool, out []move) []move {
+	out = out[:0]
+	for i, from := range b.pos {
+		if from < 0 || b.red(i) != redToMove {
+			continue
+		}
+		for _, to := range targets[codeIndex(b.code(i))][from] {
+			if !b.canMove(i, from, to) {
+				continue
+			}
+			m := move{piece: i, from: from, to: to, captured: -1}
+			if cm := b.at(to); cm != nil {
+				m.captured = b.indexOf(cm)
+			}
+			b.apply(m)
+			ok := b.legal(!redToMove)
+			b.undo(m)
+			if ok {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+func (b *board) indexOf(cm chessman.ChessmanInterface) int {
+	for i, man := range b.men {
+		if man == cm {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *board) apply(m move) {
+	if m.captured >= 0 {
+		b.pos[m.captured] = -1
+	}
+	b.set(m.from, nil)
+	b.set(m.to, b.men[m.piece])
+	b.pos[m.piece] = m.to
+}
+
+func (b *board) undo(m move) {
+	b.set(m.to, nil)
+	b.set(m.from, b.men[m.piece])
+	b.pos[m.piece] = m.from
+	if m.captured >= 0 {
+		b.pos[m.captured] = m.to
+		b.set(m.to, b.men[m.captured])
+	}
+}
+
+// 当前局面转换成红方视角的局面
+func (b *board) position(redToMove bool) position.Position {
+	p := position.Position{RedToMove: redToMove}
+	for i, sq := range b.pos {
+		if sq < 0 {
+			continue
+		}
+		s := squareAt(sq)
+		p.Squares[s.Rank][s.File] = position.Piece{Code: b.code(i), Red: b.red(i)}
+	}
+	return p
+}
+
+// 各类棋子从每个格子出发，几何上可能到达的格子，用于减少规则校验的次数。
+// 这些走法是对称的，反过来也是可能走到该格子的来源格子
+var (
+	targets [7][squares][]int
+	reach   [7][squares][squares]bool
+)
+
+var codeIndexes = map[core.ChessmanCode]int{
+	core.JiangShuai: 0, core.Shi: 1, core.Xiang: 2, core.Ma: 3, core.Ju: 4, core.Pao: 5, core.BingZu: 6,
+}
+
+func codeIndex(code core.ChessmanCode) int {
+	return codeIndexes[code]
+}
+
+func init() {
+	offsets := map[int][][2]int{
+		0: {{0, 1}, {0, -1}, {1, 0}, {-1, 0}},
+		1: {{1, 1}, {1, -1}, {-1, 1}, {-1, -1}},
+		2: {{2, 2}, {2, -2}, {-2, 2}, {-2, -2}},
+		3: {{1, 2}, {2, 1}, {-1, 2}, {-2, 1}, {1, -2}, {2, -1}, {-1, -2}, {-2, -1}},
+		6: {{0, 1}, {0, -1}, {1, 0}, {-1, 0}},
+	}
+	for ci := 0; ci < 7; ci++ {
+		for from := 0; from < squares; from++ {
+			f := squareAt(from)
+			var out []int
+			if ci == 4 || ci == 5 {
+				//车和炮走直线
+				for to := 0; to < squares; to++ {
+					t := squareAt(to)
+					if to != from && (t.File == f.File || t.Rank == f.Rank) {
+						out = append(out, to)
+					}
+				}
+			} else {
+				for _, d := range offsets[ci] {
+					t := position.Square{File: f.File + d[0], Rank: f.Rank + d[1]}
+					if t.Valid() {
+						out = append(out, squareOf(t))
+					}
+				}
+			}
+			targets[ci][from] = out
+			for _, to := range out {
+				reach[ci][from][to] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package tablebase
+
+import (
+	"fmt"
+)
+
+// 逆向分析生成残局表。
+// 先正向扫描所有局面：标记不合法的局面，统计每个局面不吃子的着法数量，
+// 吃子之后的局面属于子力更少的残局表，直接查询结果。
+// 然后从已经确定胜负的局面出发，按DTM从小到大逐层倒推：
+// 走棋方被将死的局面，所有能走到这里的前一个局面都是胜局；
+// 走棋方必胜的局面，前一个局面所有着法都走到对方必胜的局面时才是负局。
+// 最后仍然没有确定的局面都是和棋，包括长将、长捉之类的循环
+func generate(m Material, sub func(Material) (*Table, error)) (*Table, error) {
+	l, err := newLayout(m)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		layout: l,
+		board:  newBoard(l),
+		sub:    sub,
+		values: make([]uint16, l.size),
+		counts: make([]uint8, l.size),
+		best:   make([]uint16, l.size),
+		pos:    make([]int, len(l.slots)),
+	}
+	if err := g.scan(); err != nil {
+		return nil, err
+	}
+	g.retrograde()
+	return &Table{material: m, layout: l, values: g.values}, nil
+}
+
+type generator struct {
+	layout *layout
+	board  *board
+	sub    func(Material) (*Table, error)
+
+	values []uint16 //生成过程中0表示还没有确定
+	counts []uint8  //还没有确定为对方胜的着法数量，吃子后不是对方胜的着法始终计入
+	best   []uint16 //已知的对方胜的着法中最长的DTM+1
+
+	buckets [][]int //按DTM分层的待确定局面
+	pos     []int
+	moves   []move
+}
+
+// 把局面加入第dtm层，处理到这一层时再确定结果
+func (g *generator) push(idx, dtm int) {
+	for len(g.buckets) <= dtm {
+		g.buckets = append(g.buckets, nil)
+	}
+	g.buckets[dtm] = append(g.buckets[dtm], idx)
+}
+
+func (g *generator) scan() error {
+	b := g.board
+	for idx := 0; idx < g.layout.size; idx++ {
+		redToMove := g.layout.decode(idx, g.pos)
+		if !b.place(g.pos) || !b.legal(redToMove) {
+			g.values[idx] = valueInvalid
+			continue
+		}
+		g.moves = b.moves(redToMove, g.moves)
+		if len(g.moves) == 0 {
+			//被将死或者困毙
+			g.push(idx, 0)
+			continue
+		}
+		win := -1
+		count := 0
+		for _, m := range g.moves {
+			if m.captured < 0 {
+				count++
+				continue
+			}
+			r, err := g.probeCapture(m, redToMove)
+			if err != nil {
+				return err
+			}
+			switch r.WDL {
+			case Loss:
+				if win < 0 || r.DTM+1 < win {
+					win = r.DTM + 1
+				}
+				count++
+			case Win:
+				if uint16(r.DTM+1) > g.best[idx] {
+					g.best[idx] = uint16(r.DTM + 1)
+				}
+			default:
+				//吃子后和棋的着法不会被倒推确定，这个局面不可能是负局
+				count++
+			}
+		}
+		g.counts[idx] = uint8(count)
+		switch {
+		case win >= 0:
+			g.push(idx, win)
+		case count == 0:
+			g.push(idx, int(g.best[idx]))
+		}
+	}
+	return nil
+}
+
+// 查询吃子之后的局面
+func (g *generator) probeCapture(m move, redToMove bool) (Result, error) {
+	b := g.board
+	captured := m.captured
+	child := g.layout.material(b, captured)
+	t, err := g.sub(child)
+	if err != nil {
+		return Result{}, err
+	}
+	b.apply(m)
+	pos := b.position(!redToMove)
+	b.undo(m)
+	r, err := t.Probe(pos)
+	if err != nil {
+		return Result{}, fmt.Errorf("probe %s: %w", child, err)
+	}
+	return r, nil
+}
+
+// 逐层倒推，层号就是DTM
+func (g *generator) retrograde() {
+	b := g.board
+	prev := make([]int, len(g.pos))
+	for dtm := 0; dtm < len(g.buckets); dtm++ {
+		for _, idx := range g.buckets[dtm] {
+			if g.values[idx] != 0 {
+				continue
+			}
+			g.values[idx] = uint16(dtm + 1)
+			redToMove := g.layout.decode(idx, g.pos)
+			b.place(g.pos)
+			//上一步是对方走的，枚举对方不吃子的着法的来源格子
+			mover := !redToMove
+			for i, to := range g.pos {
+				if b.red(i) != mover {
+					continue
+				}
+				group := &g.layout.groups[g.layout.slots[i]]
+				for _, from := range targets[codeIndex(group.code)][to] {
+					if group.slot[from] < 0 || b.at(from) != nil {
+						continue
+					}
+					m := move{piece: i, from: to, to: from, captured: -1}
+					b.apply(m)
+					ok := b.canMove(i, from, to)
+					b.undo(m)
+					if !ok {
+						continue
+					}
+					copy(prev, g.pos)
+					prev[i] = from
+					p, ok := g.layout.index(prev, mover)
+					if !ok || g.values[p] != 0 {
+						continue
+					}
+					if dtm%2 == 0 {
+						//对方走到这里就赢了
+						g.push(p, dtm+1)
+						continue
+					}
+					if uint16(dtm+1) > g.best[p] {
+						g.best[p] = uint16(dtm + 1)
+					}
+					g.counts[p]--
+					if g.counts[p] == 0 {
+						g.push(p, int(g.best[p]))
+					}
+				}
+			}
+		}
+		g.buckets[dtm] = nil
+	}
+}
+
+// 吃掉一个棋子之后的子力组成
+func (l *layout) material(b *board, captured int) Material {
+	var m Material
+	for i := range l.slots {
+		if i < 2 || i == captured {
+			continue
+		}
+		if b.red(i) {
+			m.Red = append(m.Red, b.code(i))
+		} else {
+			m.Black = append(m.Black, b.code(i))
+		}
+	}
+	return m
+}
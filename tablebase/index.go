@@ -0,0 +1,222 @@
+package tablebase
+
+import (
+	"errors"
+	"math"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// 棋盘上的格子，按红方视角rank*9+file编号
+const squares = position.Ranks * position.Files
+
+func squareOf(sq position.Square) int {
+	return sq.Rank*position.Files + sq.File
+}
+
+func squareAt(i int) position.Square {
+	return position.Square{File: i % position.Files, Rank: i / position.Files}
+}
+
+// 黑方的格子是红方格子的中心对称
+func flipSquare(i int) int {
+	return squares - 1 - i
+}
+
+// 棋子可能出现的格子，将帅士象只能在己方的固定位置，兵不会出现在己方兵线之后
+func domainOf(code core.ChessmanCode, red bool) []int {
+	var out []int
+	for i := 0; i < squares; i++ {
+		sq := squareAt(i)
+		if !red {
+			//黑方的棋子和红方的棋子位置中心对称
+			sq = squareAt(flipSquare(i))
+		}
+		if redCanStand(code, sq) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func redCanStand(code core.ChessmanCode, sq position.Square) bool {
+	switch code {
+	case core.JiangShuai:
+		return sq.File >= 3 && sq.File <= 5 && sq.Rank <= 2
+	case core.Shi:
+		return sq.File >= 3 && sq.File <= 5 && sq.Rank <= 2 && (sq.File+sq.Rank)%2 == 1
+	case core.Xiang:
+		return sq.Rank <= 4 && sq.Rank%2 == 0 && sq.File%2 == 0 && (sq.File/2+sq.Rank/2)%2 == 1
+	case core.BingZu:
+		return sq.Rank >= 5 || (sq.Rank >= 3 && sq.File%2 == 0)
+	}
+	return true
+}
+
+// 一组相同的棋子，例如红方的两个士。相同棋子的位置按组合编号，不区分先后
+type pieceGroup struct {
+	code   core.ChessmanCode
+	red    bool
+	count  int
+	domain []int        //可以出现的格子
+	slot   [squares]int //格子在domain中的序号，不能出现时为-1
+	size   int          //组合的数量
+}
+
+// 残局表的索引布局：双方将帅和各组棋子的位置按混合进制编号，最后一位是走棋方
+type layout struct {
+	groups []pieceGroup
+	slots  []int //每个棋子所属的组，棋子按组的顺序排列
+	size   int   //局面数量，包括双方走棋
+}
+
+// 索引数量的上限，超过时残局表无法在内存中生成
+const maxTableSize = math.MaxInt32
+
+// 组合数
+var binomial [squares + 1][position.Files + 1]int
+
+func init() {
+	for n := 0; n <= squares; n++ {
+		binomial[n][0] = 1
+		for k := 1; k <= position.Files && k <= n; k++ {
+			binomial[n][k] = binomial[n-1][k-1] + binomial[n-1][k]
+		}
+	}
+}
+
+func newLayout(m Material) (*layout, error) {
+	l := &layout{size: 2}
+	add := func(code core.ChessmanCode, red bool, count int) {
+		g := pieceGroup{code: code, red: red, count: count, domain: domainOf(code, red)}
+		for i := range g.slot {
+			g.slot[i] = -1
+		}
+		for i, sq := range g.domain {
+			g.slot[sq] = i
+		}
+		g.size = binomial[len(g.domain)][count]
+		l.groups = append(l.groups, g)
+		for i := 0; i < count; i++ {
+			l.slots = append(l.slots, len(l.groups)-1)
+		}
+	}
+	add(core.JiangShuai, true, 1)
+	add(core.JiangShuai, false, 1)
+	for side, codes := range [][]core.ChessmanCode{m.Red, m.Black} {
+		for i := 0; i < len(codes); {
+			j := i
+			for j < len(codes) && codes[j] == codes[i] {
+				j++
+			}
+			add(codes[i], side == 0, j-i)
+			i = j
+		}
+	}
+	for gi := range l.groups {
+		g := &l.groups[gi]
+		if l.size > maxTableSize/g.size {
+			return nil, errors.New("table of " + m.String() + " is too large")
+		}
+		l.size *= g.size
+	}
+	return l, nil
+}
+
+// 由棋子的位置和走棋方计算索引，棋子不在可能出现的格子上时返回false。
+// pos按slots的顺序记录每个棋子的格子，相同的棋子可以是任意顺序
+func (l *layout) index(pos []int, redToMove bool) (int, bool) {
+	idx := 0
+	at := 0
+	var buf [position.Files]int
+	for gi := range l.groups {
+		g := &l.groups[gi]
+		c := buf[:g.count]
+		for i := range c {
+			c[i] = g.slot[pos[at+i]]
+			if c[i] < 0 {
+				return 0, false
+			}
+		}
+		at += g.count
+		//插入排序，同组的棋子很少
+		for i := 1; i < len(c); i++ {
+			for j := i; j > 0 && c[j] < c[j-1]; j-- {
+				c[j], c[j-1] = c[j-1], c[j]
+			}
+		}
+		rank := 0
+		for i, v := range c {
+			if i > 0 && v == c[i-1] {
+				return 0, false
+			}
+			rank += binomial[v][i+1]
+		}
+		idx = idx*g.size + rank
+	}
+	idx *= 2
+	if !redToMove {
+		idx++
+	}
+	return idx, true
+}
+
+// 由索引还原棋子的位置和走棋方，同组的棋子按格子升序排列
+func (l *layout) decode(idx int, pos []int) (redToMove bool) {
+	redToMove = idx%2 == 0
+	idx /= 2
+	at := len(pos)
+	for gi := len(l.groups) - 1; gi >= 0; gi-- {
+		g := &l.groups[gi]
+		rank := idx % g.size
+		idx /= g.size
+		at -= g.count
+		//组合数系统的逆运算，从最大的序号开始
+		v := len(g.domain) - 1
+		for k := g.count; k >= 1; k-- {
+			for binomial[v][k] > rank {
+				v--
+			}
+			rank -= binomial[v][k]
+			pos[at+k-1] = g.domain[v]
+			v--
+		}
+	}
+	return redToMove
+}
+
+// 从局面中取出棋子的位置，局面的子力必须和布局一致
+func (l *layout) squaresOf(p position.Position) ([]int, bool) {
+	pos := make([]int, len(l.slots))
+	filled := make([]int, len(l.groups))
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := p.Squares[rank][file]
+			if piece.Code == "" {
+				continue
+			}
+			at := 0
+			found := false
+			for gi := range l.groups {
+				g := &l.groups[gi]
+				if g.code == piece.Code && g.red == piece.Red && filled[gi] < g.count {
+					pos[at+filled[gi]] = squareOf(position.Square{File: file, Rank: rank})
+					filled[gi]++
+					found = true
+					break
+				}
+				at += g.count
+			}
+			if !found {
+				return nil, false
+			}
+		}
+	}
+	for gi := range l.groups {
+		if filled[gi] != l.groups[gi].count {
+			return nil, false
+		}
+	}
+	return pos, true
+}
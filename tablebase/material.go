@@ -0,0 +1,178 @@
+package tablebase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// MaxAttackers 残局库支持的进攻子力（车马炮兵）总数上限
+const MaxAttackers = 4
+
+// Material 残局的子力组成，双方都有将帅，这里只记录将帅以外的棋子
+type Material struct {
+	Red   []core.ChessmanCode
+	Black []core.ChessmanCode
+}
+
+// 子力字母按这个顺序排列
+var pieceOrder = []core.ChessmanCode{core.Ju, core.Ma, core.Pao, core.BingZu, core.Shi, core.Xiang}
+
+var pieceLetters = map[core.ChessmanCode]byte{
+	core.Ju:     'R',
+	core.Ma:     'N',
+	core.Pao:    'C',
+	core.BingZu: 'P',
+	core.Shi:    'A',
+	core.Xiang:  'B',
+}
+
+func orderOf(code core.ChessmanCode) int {
+	for i, c := range pieceOrder {
+		if c == code {
+			return i
+		}
+	}
+	return len(pieceOrder)
+}
+
+// ParseMaterial 解析子力组成，例如车兵对士象全写作"KRP-KAABB"，也可以用v分隔双方
+func ParseMaterial(s string) (Material, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	sides := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == 'V' })
+	if len(sides) != 2 {
+		return Material{}, fmt.Errorf("invalid material %q", s)
+	}
+	var m Material
+	for i, side := range sides {
+		if !strings.HasPrefix(side, "K") {
+			return Material{}, fmt.Errorf("invalid material %q: missing king", s)
+		}
+		var codes []core.ChessmanCode
+		for _, r := range side[1:] {
+			code, ok := codeOfLetter(byte(r))
+			if !ok {
+				return Material{}, fmt.Errorf("invalid material %q: unknown piece %q", s, r)
+			}
+			codes = append(codes, code)
+		}
+		if i == 0 {
+			m.Red = codes
+		} else {
+			m.Black = codes
+		}
+	}
+	m.normalize()
+	if err := m.validate(); err != nil {
+		return Material{}, err
+	}
+	return m, nil
+}
+
+func codeOfLetter(b byte) (core.ChessmanCode, bool) {
+	for code, letter := range pieceLetters {
+		if letter == b {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// MaterialOf 统计局面的子力组成，缺少将帅时返回false
+func MaterialOf(pos position.Position) (Material, bool) {
+	var m Material
+	kings := [2]int{}
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := pos.Squares[rank][file]
+			switch {
+			case piece.Code == "":
+			case piece.Code == core.JiangShuai && piece.Red:
+				kings[0]++
+			case piece.Code == core.JiangShuai:
+				kings[1]++
+			case piece.Red:
+				m.Red = append(m.Red, piece.Code)
+			default:
+				m.Black = append(m.Black, piece.Code)
+			}
+		}
+	}
+	if kings != [2]int{1, 1} {
+		return Material{}, false
+	}
+	m.normalize()
+	return m, true
+}
+
+func (m *Material) normalize() {
+	for _, codes := range [][]core.ChessmanCode{m.Red, m.Black} {
+		sort.SliceStable(codes, func(i, j int) bool { return orderOf(codes[i]) < orderOf(codes[j]) })
+	}
+}
+
+// 每方的士象不能超过两个、兵不能超过五个，进攻子力不能太多
+func (m Material) validate() error {
+	limits := map[core.ChessmanCode]int{core.Ju: 2, core.Ma: 2, core.Pao: 2, core.BingZu: 5, core.Shi: 2, core.Xiang: 2}
+	attackers := 0
+	for _, codes := range [][]core.ChessmanCode{m.Red, m.Black} {
+		counts := map[core.ChessmanCode]int{}
+		for _, code := range codes {
+			counts[code]++
+			if counts[code] > limits[code] {
+				return fmt.Errorf("invalid material %s: too many %s", m, string(pieceLetters[code]))
+			}
+			if code != core.Shi && code != core.Xiang {
+				attackers++
+			}
+		}
+	}
+	if attackers > MaxAttackers {
+		return errors.New("invalid material " + m.String() + ": too many attacking pieces")
+	}
+	return nil
+}
+
+// Flip 交换红黑双方的子力
+func (m Material) Flip() Material {
+	return Material{Red: m.Black, Black: m.Red}
+}
+
+// String 返回"KRP-KAABB"形式的子力组成
+func (m Material) String() string {
+	var sb strings.Builder
+	for i, codes := range [][]core.ChessmanCode{m.Red, m.Black} {
+		if i == 1 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte('K')
+		for _, code := range codes {
+			sb.WriteByte(pieceLetters[code])
+		}
+	}
+	return sb.String()
+}
+
+// 去掉一个被吃掉的棋子后的子力组成，用于查找吃子之后的残局
+func (m Material) without(red bool, code core.ChessmanCode) Material {
+	remove := func(codes []core.ChessmanCode) []core.ChessmanCode {
+		out := make([]core.ChessmanCode, 0, len(codes))
+		removed := false
+		for _, c := range codes {
+			if c == code && !removed {
+				removed = true
+				continue
+			}
+			out = append(out, c)
+		}
+		return out
+	}
+	if red {
+		return Material{Red: remove(m.Red), Black: m.Black}
+	}
+	return Material{Red: m.Red, Black: remove(m.Black)}
+}
@@ -0,0 +1,204 @@
+package tablebase
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// WDL 走棋方的胜负
+type WDL int
+
+const (
+	Draw WDL = iota
+	Win
+	Loss
+)
+
+func (w WDL) String() string {
+	switch w {
+	case Win:
+		return "胜"
+	case Loss:
+		return "负"
+	}
+	return "和"
+}
+
+// Result 查询残局表的结果，都是走棋方视角。
+// DTM为双方都走最好的着法时距离将死的步数（按半回合计），走棋方被将死或困毙时为0，和棋时为0
+type Result struct {
+	WDL WDL
+	DTM int
+}
+
+func (r Result) String() string {
+	if r.WDL == Draw {
+		return r.WDL.String()
+	}
+	return fmt.Sprintf("%s %d", r.WDL, r.DTM)
+}
+
+// 残局表中每个局面占2字节：0为和棋，0xFFFF为不合法的局面，其他值为DTM+1。
+// 胜局的DTM总是奇数，负局的DTM总是偶数
+const (
+	valueDraw    = 0
+	valueInvalid = 0xFFFF
+)
+
+func resultOf(v uint16) (Result, bool) {
+	switch {
+	case v == valueInvalid:
+		return Result{}, false
+	case v == valueDraw:
+		return Result{WDL: Draw}, true
+	}
+	dtm := int(v) - 1
+	if dtm%2 == 1 {
+		return Result{WDL: Win, DTM: dtm}, true
+	}
+	return Result{WDL: Loss, DTM: dtm}, true
+}
+
+// Table 一种子力组成的残局表
+type Table struct {
+	material Material
+	layout   *layout
+	values   []uint16
+}
+
+// Material 获取残局表的子力组成
+func (t *Table) Material() Material {
+	return t.material
+}
+
+// Len 获取残局表的局面数量，包括不合法的局面
+func (t *Table) Len() int {
+	return len(t.values)
+}
+
+// Probe 查询局面，局面的子力必须和残局表一致。不合法的局面返回错误
+func (t *Table) Probe(pos position.Position) (Result, error) {
+	squares, ok := t.layout.squaresOf(pos)
+	if !ok {
+		return Result{}, fmt.Errorf("position is not %s", t.material)
+	}
+	idx, ok := t.layout.index(squares, pos.RedToMove)
+	if !ok {
+		return Result{}, errors.New("invalid position")
+	}
+	r, ok := resultOf(t.values[idx])
+	if !ok {
+		return Result{}, errors.New("invalid position")
+	}
+	return r, nil
+}
+
+// 二进制文件格式：
+// 文件头为4字节的magic、2字节的版本号、1字节的子力组成长度和子力组成字符串、4字节的局面数量，
+// 之后按索引顺序每个局面2字节。所有整数都是大端字节序
+const (
+	magic   = "XQTB"
+	version = 1
+	// Ext 残局表文件的扩展名
+	Ext = ".xqtb"
+)
+
+// WriteTo 以二进制格式写出残局表
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	name := t.material.String()
+	bw.WriteString(magic)
+	binary.Write(bw, binary.BigEndian, uint16(version))
+	bw.WriteByte(byte(len(name)))
+	bw.WriteString(name)
+	binary.Write(bw, binary.BigEndian, uint32(len(t.values)))
+	if err := binary.Write(bw, binary.BigEndian, t.values); err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return int64(len(magic) + 2 + 1 + len(name) + 4 + 2*len(t.values)), nil
+}
+
+// ReadTable 读取二进制格式的残局表
+func ReadTable(r io.Reader) (*Table, error) {
+	br := bufio.NewReader(r)
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, err
+	}
+	if string(head) != magic {
+		return nil, errors.New("not a tablebase file")
+	}
+	var v uint16
+	if err := binary.Read(br, binary.BigEndian, &v); err != nil {
+		return nil, err
+	}
+	if v != version {
+		return nil, fmt.Errorf("unsupported tablebase version %d", v)
+	}
+	n, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	name := make([]byte, n)
+	if _, err := io.ReadFull(br, name); err != nil {
+		return nil, err
+	}
+	m, err := ParseMaterial(string(name))
+	if err != nil {
+		return nil, err
+	}
+	l, err := newLayout(m)
+	if err != nil {
+		return nil, err
+	}
+	var count uint32
+	if err := binary.Read(br, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+	if int(count) != l.size {
+		return nil, fmt.Errorf("tablebase %s has %d positions, want %d", m, count, l.size)
+	}
+	values := make([]uint16, count)
+	if err := binary.Read(br, binary.BigEndian, values); err != nil {
+		return nil, err
+	}
+	return &Table{material: m, layout: l, values: values}, nil
+}
+
+// Save 保存残局表，先写临时文件再重命名，避免写到一半的文件被读取
+func (t *Table) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := t.WriteTo(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadTable 从文件读取残局表
+func LoadTable(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTable(f)
+}
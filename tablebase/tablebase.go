@@ -0,0 +1,197 @@
+// Package tablebase 小子力残局的残局库。
+// 残局表用逆向分析生成，着法规则和对局内核相同，可以查询局面的胜负和距离将死的步数
+package tablebase
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+// Tablebase 多种子力组成的残局表集合。
+// 红黑互换的子力组成共用一张残局表，查询时把局面旋转180度并交换红黑
+type Tablebase struct {
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+func New() *Tablebase {
+	return &Tablebase{tables: make(map[string]*Table)}
+}
+
+// Add 加入残局表，相同子力组成的残局表会被替换
+func (tb *Tablebase) Add(t *Table) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.tables[t.material.String()] = t
+}
+
+// Table 获取子力组成的残局表，flipped表示残局表是红黑互换的
+func (tb *Tablebase) Table(m Material) (t *Table, flipped bool, ok bool) {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	if t, ok := tb.tables[m.String()]; ok {
+		return t, false, true
+	}
+	if t, ok := tb.tables[m.Flip().String()]; ok {
+		return t, true, true
+	}
+	return nil, false, false
+}
+
+// Tables 获取所有残局表，按子力组成排序
+func (tb *Tablebase) Tables() []*Table {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	tables := make([]*Table, 0, len(tb.tables))
+	for _, t := range tb.tables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].material.String() < tables[j].material.String()
+	})
+	return tables
+}
+
+// Generate 生成子力组成的残局表，吃子之后的残局表不存在时先生成它们。
+// 已经有的残局表直接返回
+func (tb *Tablebase) Generate(m Material) (*Table, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if t, _, ok := tb.Table(m); ok {
+		return t, nil
+	}
+	t, err := generate(m, tb.Generate)
+	if err != nil {
+		return nil, err
+	}
+	tb.Add(t)
+	return t, nil
+}
+
+// Probe 查询局面，没有对应的残局表或者局面不合法时返回false
+func (tb *Tablebase) Probe(pos position.Position) (Result, bool) {
+	m, ok := MaterialOf(pos)
+	if !ok {
+		return Result{}, false
+	}
+	t, flipped, ok := tb.Table(m)
+	if !ok {
+		return Result{}, false
+	}
+	if flipped {
+		pos = flip(pos)
+	}
+	r, err := t.Probe(pos)
+	if err != nil {
+		return Result{}, false
+	}
+	return r, true
+}
+
+// MoveResult 一个着法，以及走完之后对方视角的结果
+type MoveResult struct {
+	Move   position.Move
+	Result Result
+}
+
+// ProbeMoves 查询局面所有合法着法的结果，按对走棋方从好到坏排序：
+// 先是最快将死对方的着法，然后是和棋的着法，最后是最慢被将死的着法
+func (tb *Tablebase) ProbeMoves(pos position.Position) ([]MoveResult, bool) {
+	if _, ok := tb.Probe(pos); !ok {
+		return nil, false
+	}
+	m, _ := MaterialOf(pos)
+	t, flipped, _ := tb.Table(m)
+	view := pos
+	if flipped {
+		view = flip(pos)
+	}
+	squares, _ := t.layout.squaresOf(view)
+	b := newBoard(t.layout)
+	b.place(squares)
+
+	var out []MoveResult
+	for _, mv := range b.moves(view.RedToMove, nil) {
+		from, to := mv.from, mv.to
+		if flipped {
+			from, to = flipSquare(from), flipSquare(to)
+		}
+		move := position.Move{From: squareAt(from), To: squareAt(to)}
+		child := pos
+		child.Play(move)
+		r, ok := tb.Probe(child)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, MoveResult{Move: move, Result: r})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return moveScore(out[i].Result) > moveScore(out[j].Result)
+	})
+	return out, true
+}
+
+// 着法的分数，r是走完之后对方视角的结果
+func moveScore(r Result) int {
+	switch r.WDL {
+	case Loss:
+		return 10000 - r.DTM
+	case Win:
+		return -10000 + r.DTM
+	}
+	return 0
+}
+
+// 局面旋转180度并交换红黑双方，胜负关系不变
+func flip(pos position.Position) position.Position {
+	out := position.Position{RedToMove: !pos.RedToMove}
+	for rank := 0; rank < position.Ranks; rank++ {
+		for file := 0; file < position.Files; file++ {
+			piece := pos.Squares[rank][file]
+			if piece.Code != "" {
+				piece.Red = !piece.Red
+			}
+			out.Squares[position.Ranks-1-rank][position.Files-1-file] = piece
+		}
+	}
+	return out
+}
+
+// Save 把所有残局表保存到目录，文件名为子力组成
+func (tb *Tablebase) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, t := range tb.Tables() {
+		if err := t.Save(filepath.Join(dir, t.material.String()+Ext)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load 读取目录中所有的残局表
+func Load(dir string) (*Tablebase, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	tb := New()
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), Ext) {
+			continue
+		}
+		t, err := LoadTable(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tb.Add(t)
+	}
+	return tb, nil
+}
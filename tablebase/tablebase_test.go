@@ -0,0 +1,189 @@
+package tablebase
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CXeon/xiangqi/core/position"
+)
+
+func mustFEN(t *testing.T, fen string) position.Position {
+	t.Helper()
+	pos, err := position.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pos
+}
+
+func TestParseMaterial(t *testing.T) {
+	m, err := ParseMaterial("kpr v kbaba")
+	if err == nil {
+		t.Fatalf("expect error for spaces, got %s", m)
+	}
+	m, err = ParseMaterial("KPRvKBABA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.String() != "KRP-KAABB" || m.Flip().String() != "KAABB-KRP" {
+		t.Fatalf("unexpected material %s", m)
+	}
+	for _, s := range []string{"KRR", "RK-K", "KX-K", "KAAA-K", "KRRNN-KP"} {
+		if _, err := ParseMaterial(s); err == nil {
+			t.Fatalf("expect error for %s", s)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	m, _ := ParseMaterial("KAA-KBP")
+	l, err := newLayout(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//士的组合只有C(5,2)种
+	if want := 9 * 9 * 10 * 7 * 55 * 2; l.size != want {
+		t.Fatalf("expect size %d, got %d", want, l.size)
+	}
+	pos := make([]int, len(l.slots))
+	for _, idx := range []int{0, 1, 12345, l.size - 1} {
+		redToMove := l.decode(idx, pos)
+		got, ok := l.index(pos, redToMove)
+		if !ok || got != idx {
+			t.Fatalf("index %d decoded to %v, encoded to %d", idx, pos, got)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tb := New()
+	m, _ := ParseMaterial("KR-KA")
+	table, err := tb.Generate(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//吃子之后的残局表也一起生成
+	for _, s := range []string{"KR-K", "K-KA", "K-K"} {
+		sub, _ := ParseMaterial(s)
+		if _, _, ok := tb.Table(sub); !ok {
+			t.Fatalf("missing table %s", s)
+		}
+	}
+
+	//车占肋道，黑将不能和帅照面，一步杀
+	r, ok := tb.Probe(mustFEN(t, "3k5/9/9/9/9/9/9/9/9/R3K4 w"))
+	if !ok || r != (Result{WDL: Win, DTM: 1}) {
+		t.Fatalf("unexpected result %v %v", r, ok)
+	}
+	//红黑互换之后结果相同
+	r, ok = tb.Probe(flip(mustFEN(t, "3k5/9/9/9/9/9/9/9/9/R3K4 w")))
+	if !ok || r != (Result{WDL: Win, DTM: 1}) {
+		t.Fatalf("unexpected flipped result %v %v", r, ok)
+	}
+	//最好的着法排在最前面，走完之后对方被将死
+	moves, ok := tb.ProbeMoves(mustFEN(t, "3k5/9/9/9/9/9/9/9/9/R3K4 w"))
+	if !ok || moves[0].Result != (Result{WDL: Loss}) || moves[0].Move.String() != "a0d0" {
+		t.Fatalf("unexpected moves %+v", moves)
+	}
+	moves, ok = tb.ProbeMoves(flip(mustFEN(t, "3k5/9/9/9/9/9/9/9/9/R3K4 w")))
+	if !ok || moves[0].Move.String() != "i9f9" {
+		t.Fatalf("unexpected flipped moves %+v", moves)
+	}
+	//单车对单士，红方走棋不会输
+	pos := make([]int, len(table.layout.slots))
+	for idx, v := range table.values {
+		res, ok := resultOf(v)
+		if ok && table.layout.decode(idx, pos) && res.WDL == Loss {
+			t.Fatalf("red loses at %d", idx)
+		}
+	}
+	//将帅照面的局面不合法
+	if _, ok := tb.Probe(mustFEN(t, "4k4/9/9/9/9/9/9/9/9/R3K4 w")); ok {
+		t.Fatal("expect invalid position")
+	}
+	//没有对应的残局表
+	if _, ok := tb.Probe(position.Initial()); ok {
+		t.Fatal("expect no table for the initial position")
+	}
+}
+
+// 每个局面的结果都要和走一步之后的局面一致
+func TestConsistency(t *testing.T) {
+	tb := New()
+	for _, s := range []string{"KN-K", "KP-KA"} {
+		m, _ := ParseMaterial(s)
+		table, err := tb.Generate(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := newBoard(table.layout)
+		pos := make([]int, len(table.layout.slots))
+		for idx, v := range table.values {
+			got, ok := resultOf(v)
+			if !ok {
+				continue
+			}
+			redToMove := table.layout.decode(idx, pos)
+			b.place(pos)
+			win, best, allWin := -1, 0, true
+			for _, m := range b.moves(redToMove, nil) {
+				b.apply(m)
+				child, ok := tb.Probe(b.position(!redToMove))
+				b.undo(m)
+				if !ok {
+					t.Fatalf("%s: missing child of %d", s, idx)
+				}
+				switch child.WDL {
+				case Loss:
+					allWin = false
+					if win < 0 || child.DTM+1 < win {
+						win = child.DTM + 1
+					}
+				case Draw:
+					allWin = false
+				case Win:
+					best = max(best, child.DTM+1)
+				}
+			}
+			want := Result{}
+			switch {
+			case win >= 0:
+				want = Result{WDL: Win, DTM: win}
+			case allWin:
+				want = Result{WDL: Loss, DTM: best}
+			}
+			if got != want {
+				t.Fatalf("%s: %s expect %v, got %v", s, b.position(redToMove).FEN(), want, got)
+			}
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	tb := New()
+	m, _ := ParseMaterial("KN-K")
+	if _, err := tb.Generate(m); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "tb")
+	if err := tb.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Tables()) != len(tb.Tables()) {
+		t.Fatalf("expect %d tables, got %d", len(tb.Tables()), len(loaded.Tables()))
+	}
+	want, _, _ := tb.Table(m)
+	got, _, ok := loaded.Table(m)
+	if !ok || got.Len() != want.Len() {
+		t.Fatal("table not loaded")
+	}
+	for i := range want.values {
+		if got.values[i] != want.values[i] {
+			t.Fatalf("value %d differs", i)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Package theme 读取界面的主题：棋盘和棋子的图片。
+// 主题是一个目录或者zip文件，图片的文件名和assets中的相同，例如board.png、red_ju.png、black_jiang.png，
+// 可以只提供其中一部分，缺少的图片由界面用矢量图形绘制。目录中可选的theme.json设置主题的名称和棋子的字形：
+//
+//	{"name": "木纹", "glyphs": "western"}
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/CXeon/xiangqi/assets"
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 图片的大小，和界面的布局一致
+const (
+	BoardWidth  = 576 //棋盘图片的宽度
+	BoardHeight = 672 //棋盘图片的高度
+	PieceSize   = 52  //棋子图片的宽度和高度
+)
+
+// 主题的设置文件
+const manifestFile = "theme.json"
+
+// Glyphs 矢量绘制的棋子上的字形
+type Glyphs string
+
+const (
+	Traditional Glyphs = "traditional" //汉字：车马相仕帅炮兵
+	Western     Glyphs = "western"     //西式字母：R N B A K C P
+)
+
+// Piece 一种棋子
+type Piece struct {
+	Code core.ChessmanCode
+	Red  bool
+}
+
+// Pieces 所有的棋子，红方在前
+var Pieces = []Piece{
+	{core.Ju, true}, {core.Ma, true}, {core.Xiang, true}, {core.Shi, true},
+	{core.JiangShuai, true}, {core.Pao, true}, {core.BingZu, true},
+	{core.Ju, false}, {core.Ma, false}, {core.Xiang, false}, {core.Shi, false},
+	{core.JiangShuai, false}, {core.Pao, false}, {core.BingZu, false},
+}
+
+// 棋子图片的文件名，和assets中的相同
+var pieceFiles = map[Piece]string{
+	{core.Ju, true}:          "red_ju.png",
+	{core.Ma, true}:          "red_ma.png",
+	{core.Xiang, true}:       "red_xiang.png",
+	{core.Shi, true}:         "red_shi.png",
+	{core.JiangShuai, true}:  "red_shuai.png",
+	{core.Pao, true}:         "red_pao.png",
+	{core.BingZu, true}:      "red_bing.png",
+	{core.Ju, false}:         "black_ju.png",
+	{core.Ma, false}:         "black_ma.png",
+	{core.Xiang, false}:      "black_xiang.png",
+	{core.Shi, false}:        "black_shi.png",
+	{core.JiangShuai, false}: "black_jiang.png",
+	{core.Pao, false}:        "black_pao.png",
+	{core.BingZu, false}:     "black_zu.png",
+}
+
+// 棋盘图片的文件名
+const boardFile = "board.png"
+
+// 汉字字形，红黑双方的用字不同
+var traditionalGlyphs = map[Piece]string{
+	{core.Ju, true}:          "俥",
+	{core.Ma, true}:          "傌",
+	{core.Xiang, true}:       "相",
+	{core.Shi, true}:         "仕",
+	{core.JiangShuai, true}:  "帥",
+	{core.Pao, true}:         "炮",
+	{core.BingZu, true}:      "兵",
+	{core.Ju, false}:         "車",
+	{core.Ma, false}:         "馬",
+	{core.Xiang, false}:      "象",
+	{core.Shi, false}:        "士",
+	{core.JiangShuai, false}: "將",
+	{core.Pao, false}:        "砲",
+	{core.BingZu, false}:     "卒",
+}
+
+// 西式字母，红黑双方只靠颜色区分
+var westernGlyphs = map[core.ChessmanCode]string{
+	core.Ju:         "R",
+	core.Ma:         "N",
+	core.Xiang:      "B",
+	core.Shi:        "A",
+	core.JiangShuai: "K",
+	core.Pao:        "C",
+	core.BingZu:     "P",
+}
+
+// Pack 一套主题。Board或者Pieces中缺少的图片由界面按照Glyphs用矢量图形绘制
+type Pack struct {
+	Name   string
+	Glyphs Glyphs
+	Board  image.Image
+	Pieces map[Piece]image.Image
+}
+
+// Glyph 矢量绘制时棋子上的字
+func (p *Pack) Glyph(piece Piece) string {
+	if p.Glyphs == Western {
+		return westernGlyphs[piece.Code]
+	}
+	return traditionalGlyphs[piece]
+}
+
+// 主题的设置
+type manifest struct {
+	Name   string `json:"name"`
+	Glyphs Glyphs `json:"glyphs"`
+}
+
+// Default 内置的主题，使用assets中的图片
+func Default() (*Pack, error) {
+	files := map[string][]byte{
+		boardFile:         assets.Board,
+		"red_ju.png":      assets.RedJu,
+		"red_ma.png":      assets.RedMa,
+		"red_xiang.png":   assets.RedXiang,
+		"red_shi.png":     assets.RedShi,
+		"red_shuai.png":   assets.RedJiang,
+		"red_pao.png":     assets.RedPao,
+		"red_bing.png":    assets.RedBing,
+		"black_ju.png":    assets.BlackJu,
+		"black_ma.png":    assets.BlackMa,
+		"black_xiang.png": assets.BlackXiang,
+		"black_shi.png":   assets.BlackShi,
+		"black_jiang.png": assets.BlackJiang,
+		"black_pao.png":   assets.BlackPao,
+		"black_zu.png":    assets.BlackZu,
+	}
+	p := &Pack{Name: "默认", Glyphs: Traditional, Pieces: make(map[Piece]image.Image)}
+	var err error
+	if p.Board, err = decode(boardFile, files[boardFile], BoardWidth, BoardHeight); err != nil {
+		return nil, err
+	}
+	for piece, name := range pieceFiles {
+		if p.Pieces[piece], err = decode(name, files[name], PieceSize, PieceSize); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Vector 没有图片、全部用矢量图形绘制的主题
+func Vector(glyphs Glyphs) *Pack {
+	name := "矢量汉字"
+	if glyphs == Western {
+		name = "矢量字母"
+	}
+	return &Pack{Name: name, Glyphs: glyphs, Pieces: make(map[Piece]image.Image)}
+}
+
+// Builtin 内置的主题：默认图片、矢量汉字和矢量字母
+func Builtin() ([]*Pack, error) {
+	def, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return []*Pack{def, Vector(Traditional), Vector(Western)}, nil
+}
+
+// Load 读取目录或者zip文件中的主题，没有设置名称时使用去掉扩展名的文件名
+func Load(path string) (*Pack, error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return LoadFS(r, name)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: 主题应该是目录或者zip文件", path)
+	}
+	return LoadFS(os.DirFS(path), name)
+}
+
+// LoadFS 读取文件系统根目录中的主题，name是没有设置名称时使用的名称
+func LoadFS(fsys fs.FS, name string) (*Pack, error) {
+	p := &Pack{Name: name, Glyphs: Traditional, Pieces: make(map[Piece]image.Image)}
+	data, err := fs.ReadFile(fsys, manifestFile)
+	switch {
+	case err == nil:
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", manifestFile, err)
+		}
+		if len(m.Name) > 0 {
+			p.Name = m.Name
+		}
+		switch m.Glyphs {
+		case "":
+		case Traditional, Western:
+			p.Glyphs = m.Glyphs
+		default:
+			return nil, fmt.Errorf("%s: 未知的字形%q", manifestFile, m.Glyphs)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	if p.Board, err = readImage(fsys, boardFile, BoardWidth, BoardHeight); err != nil {
+		return nil, err
+	}
+	for piece, file := range pieceFiles {
+		img, err := readImage(fsys, file, PieceSize, PieceSize)
+		if err != nil {
+			return nil, err
+		}
+		if img != nil {
+			p.Pieces[piece] = img
+		}
+	}
+	return p, nil
+}
+
+// Discover 读取目录中的所有主题，每个子目录或者zip文件是一套主题，按名称排序。目录不存在时没有主题
+func Discover(dir string) ([]*Pack, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	packs := make([]*Pack, 0)
+	for _, e := range entries {
+		if !e.IsDir() && !strings.EqualFold(filepath.Ext(e.Name()), ".zip") {
+			continue
+		}
+		p, err := Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, p)
+	}
+	sort.SliceStable(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// 读取一张图片并检查大小，文件不存在时返回nil
+func readImage(fsys fs.FS, name string, width, height int) (image.Image, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(name, data, width, height)
+}
+
+func decode(name string, data []byte, width, height int) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		return nil, fmt.Errorf("%s: 图片大小应为%dx%d，实际为%dx%d", name, width, height, b.Dx(), b.Dy())
+	}
+	return img, nil
+}
@@ -0,0 +1,102 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/CXeon/xiangqi/core"
+)
+
+// 生成指定大小的png图片
+func pngData(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDefault(t *testing.T) {
+	p, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Board == nil || len(p.Pieces) != len(Pieces) {
+		t.Fatalf("expect board and %d pieces, got %d", len(Pieces), len(p.Pieces))
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"theme.json": {Data: []byte(`{"name": "测试", "glyphs": "western"}`)},
+		"red_ju.png": {Data: pngData(t, PieceSize, PieceSize)},
+	}
+	p, err := LoadFS(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "测试" || p.Glyphs != Western || p.Board != nil || len(p.Pieces) != 1 {
+		t.Fatalf("unexpected pack %+v", p)
+	}
+	if g := p.Glyph(Piece{core.Ju, false}); g != "R" {
+		t.Fatalf("expect R, got %s", g)
+	}
+
+	//图片大小和布局不符
+	fsys["board.png"] = &fstest.MapFile{Data: pngData(t, 100, 100)}
+	if _, err := LoadFS(fsys, "dir"); err == nil {
+		t.Fatal("expect size error")
+	}
+
+	//未知的字形
+	if _, err := LoadFS(fstest.MapFS{"theme.json": {Data: []byte(`{"glyphs": "runes"}`)}}, "dir"); err == nil {
+		t.Fatal("expect glyphs error")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "plain"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "wood.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("board.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(pngData(t, BoardWidth, BoardHeight)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	packs, err := Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 2 || packs[0].Name != "plain" || packs[1].Name != "wood" || packs[1].Board == nil {
+		t.Fatalf("unexpected packs %+v", packs)
+	}
+
+	if packs, err := Discover(filepath.Join(dir, "missing")); err != nil || packs != nil {
+		t.Fatalf("expect no packs, got %v %v", packs, err)
+	}
+}
@@ -0,0 +1,207 @@
+package tournament
+
+// 选手已经下过的对局，用于决定先后手和避免重复配对
+type history struct {
+	colors   map[int][]bool       //每名选手每一局是否执红
+	met      map[int]map[int]bool //已经交手过的对手
+	hadBye   map[int]bool         //已经轮空过
+	seedRank map[int]int          //种子顺序，数字越小种子越靠前
+}
+
+func newHistory(entrants []Entrant) *history {
+	h := &history{
+		colors:   make(map[int][]bool),
+		met:      make(map[int]map[int]bool),
+		hadBye:   make(map[int]bool),
+		seedRank: make(map[int]int),
+	}
+	for i, e := range entrants {
+		h.met[e.ID] = make(map[int]bool)
+		h.seedRank[e.ID] = i
+	}
+	return h
+}
+
+// 记录一台对局的配对
+func (h *history) add(p Pairing) {
+	if p.IsBye() {
+		h.hadBye[p.Red] = true
+		return
+	}
+	h.colors[p.Red] = append(h.colors[p.Red], true)
+	h.colors[p.Black] = append(h.colors[p.Black], false)
+	h.met[p.Red][p.Black] = true
+	h.met[p.Black][p.Red] = true
+}
+
+// 执红局数减去执黑局数
+func (h *history) colorDiff(id int) int {
+	diff := 0
+	for _, red := range h.colors[id] {
+		if red {
+			diff++
+		} else {
+			diff--
+		}
+	}
+	return diff
+}
+
+// 选手必须执的颜色：1表示必须执红，-1表示必须执黑，0表示都可以。
+// 执红执黑的局数相差2局，或者连续两局同色时，下一局必须换色
+func (h *history) mustColor(id int) int {
+	diff := h.colorDiff(id)
+	colors := h.colors[id]
+	n := len(colors)
+	if diff >= 2 || n >= 2 && colors[n-1] && colors[n-2] {
+		return -1
+	}
+	if diff <= -2 || n >= 2 && !colors[n-1] && !colors[n-2] {
+		return 1
+	}
+	return 0
+}
+
+// 两名选手的颜色要求是否冲突
+func (h *history) colorsCompatible(a, b int) bool {
+	ma, mb := h.mustColor(a), h.mustColor(b)
+	return ma == 0 || ma != mb
+}
+
+// 决定先后手：执红较少的一方执红，相同时上一局执黑的一方执红，仍然相同时种子靠前的一方按轮次交替先后手
+func (h *history) assignColors(a, b, round int) (red, black int) {
+	da, db := h.colorDiff(a), h.colorDiff(b)
+	if da != db {
+		if da < db {
+			return a, b
+		}
+		return b, a
+	}
+	la, lb := h.lastColor(a), h.lastColor(b)
+	if la != lb {
+		if la < lb {
+			return a, b
+		}
+		return b, a
+	}
+	if h.seedRank[b] < h.seedRank[a] {
+		a, b = b, a
+	}
+	if round%2 == 1 {
+		return a, b
+	}
+	return b, a
+}
+
+// 上一局的颜色：1表示执红，-1表示执黑，0表示还没有下过
+func (h *history) lastColor(id int) int {
+	colors := h.colors[id]
+	if len(colors) == 0 {
+		return 0
+	}
+	if colors[len(colors)-1] {
+		return 1
+	}
+	return -1
+}
+
+// 循环赛的对阵表，使用轮转法：第一名选手固定，其余选手每轮顺时针轮转一个位置。
+// 人数为奇数时加入0号虚拟选手，和0号选手配对表示轮空
+func roundRobinSchedule(ids []int) [][][2]int {
+	arr := append([]int{}, ids...)
+	if len(arr)%2 == 1 {
+		arr = append(arr, 0)
+	}
+	n := len(arr)
+	schedule := make([][][2]int, 0, n-1)
+	for r := 0; r < n-1; r++ {
+		round := make([][2]int, 0, n/2)
+		for i := 0; i < n/2; i++ {
+			round = append(round, [2]int{arr[i], arr[n-1-i]})
+		}
+		schedule = append(schedule, round)
+
+		last := arr[n-1]
+		copy(arr[2:], arr[1:n-1])
+		arr[1] = last
+	}
+	return schedule
+}
+
+// 配对搜索在一种条件下最多尝试的步数，超过后放弃这种条件，改用更宽松的条件
+const pairSearchBudget = 20000
+
+// 瑞士制配对。ranked是按当前名次排好序的选手，依次为每名选手寻找名次最接近的合适对手。
+// 首先要求没有交过手而且颜色不冲突，无法配对时放宽颜色要求，最后允许重复配对。
+// 找不到满足条件的配对时回溯的代价随人数阶乘增长，所以每种条件的搜索步数有上限
+func swissPairs(ranked []int, h *history) [][2]int {
+	rules := []func(a, b int) bool{
+		func(a, b int) bool { return !h.met[a][b] && h.colorsCompatible(a, b) },
+		func(a, b int) bool { return !h.met[a][b] },
+		func(a, b int) bool { return true },
+	}
+	for _, ok := range rules {
+		s := newPairSearch(ranked, ok)
+		if pairs, found := s.pair(ranked); found {
+			return pairs
+		}
+	}
+	return nil
+}
+
+// 一种条件下的配对搜索
+type pairSearch struct {
+	ok     func(a, b int) bool
+	index  map[int]int     //选手在名次中的位置
+	failed map[string]bool //已经确定无法配完的剩余选手
+	steps  int
+}
+
+func newPairSearch(ranked []int, ok func(a, b int) bool) *pairSearch {
+	s := &pairSearch{ok: ok, index: make(map[int]int, len(ranked)), failed: make(map[string]bool)}
+	for i, id := range ranked {
+		s.index[id] = i
+	}
+	return s
+}
+
+// 剩余选手的集合，用名次位置的位图表示
+func (s *pairSearch) key(list []int) string {
+	bits := make([]byte, (len(s.index)+7)/8)
+	for _, id := range list {
+		i := s.index[id]
+		bits[i/8] |= 1 << (i % 8)
+	}
+	return string(bits)
+}
+
+// 回溯查找满足条件的完整配对。同样的剩余选手只会以同样的顺序出现，失败过的不再重复搜索
+func (s *pairSearch) pair(list []int) ([][2]int, bool) {
+	if len(list) == 0 {
+		return [][2]int{}, true
+	}
+	if s.steps >= pairSearchBudget {
+		return nil, false
+	}
+	s.steps++
+	key := s.key(list)
+	if s.failed[key] {
+		return nil, false
+	}
+
+	a := list[0]
+	for i := 1; i < len(list); i++ {
+		b := list[i]
+		if !s.ok(a, b) {
+			continue
+		}
+		rest := make([]int, 0, len(list)-2)
+		rest = append(rest, list[1:i]...)
+		rest = append(rest, list[i+1:]...)
+		if pairs, found := s.pair(rest); found {
+			return append([][2]int{{a, b}}, pairs...), true
+		}
+	}
+	s.failed[key] = true
+	return nil, false
+}
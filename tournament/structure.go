@@ -0,0 +1,83 @@
+package tournament
+
+import (
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// Format 赛制
+type Format int
+
+const (
+	RoundRobin Format = iota //循环赛
+	Swiss                    //瑞士制
+)
+
+func (f Format) String() string {
+	if f == Swiss {
+		return "swiss"
+	}
+	return "round-robin"
+}
+
+// Config 比赛的参数
+type Config struct {
+	Format   Format
+	Rounds   int     //瑞士制的轮数，为0时按人数计算
+	Cycles   int     //循环赛的循环次数，双循环时为2，第二循环交换先后手
+	ByeScore float64 //轮空的得分
+	MaxPlies int     //电脑对局的步数限制，超过后判和
+}
+
+// DefaultConfig 默认的比赛参数：循环赛轮空不得分，瑞士制轮空得1分
+func DefaultConfig(format Format) Config {
+	cfg := Config{
+		Format:   format,
+		Cycles:   1,
+		MaxPlies: ai.DefaultMaxPlies,
+	}
+	if format == Swiss {
+		cfg.ByeScore = 1
+	}
+	return cfg
+}
+
+// Entrant 参赛选手
+type Entrant struct {
+	ID     int       `json:"id"`
+	Name   string    `json:"name"`
+	Rating float64   `json:"rating"` //赛前评分，决定种子顺序
+	Engine ai.Engine `json:"-"`      //不为nil时由电脑自动下棋
+}
+
+// Pairing 一轮比赛中的一台对局，Black为0表示Red轮空
+type Pairing struct {
+	Round    int            `json:"round"`
+	Board    int            `json:"board"`
+	Red      int            `json:"red"`   //红方（先手）选手id
+	Black    int            `json:"black"` //黑方（后手）选手id
+	Finished bool           `json:"finished"`
+	Outcome  rating.Outcome `json:"outcome"`
+	Reason   string         `json:"reason,omitempty"`
+	GameID   int            `json:"gameId,omitempty"` //归档的对局记录id
+}
+
+// IsBye 是否是轮空
+func (p Pairing) IsBye() bool {
+	return p.Black == 0
+}
+
+// Standing 选手的排名
+type Standing struct {
+	Rank            int     `json:"rank"`
+	EntrantID       int     `json:"entrantId"`
+	Name            string  `json:"name"`
+	Score           float64 `json:"score"`
+	Buchholz        float64 `json:"buchholz"`        //对手得分之和
+	SonnebornBerger float64 `json:"sonnebornBerger"` //战胜的对手得分之和加上战和的对手得分的一半
+	Games           int     `json:"games"`
+	Wins            int     `json:"wins"`
+	Draws           int     `json:"draws"`
+	Losses          int     `json:"losses"`
+	RedGames        int     `json:"redGames"` //执红（先手）的局数
+}
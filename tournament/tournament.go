@@ -0,0 +1,451 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// Tournament 比赛管理：生成每一轮的配对，记录结果并计算排名。
+// 双方都是电脑的对局由内核自动下完，其他对局的结果由调用方记录
+type Tournament struct {
+	cfg      Config
+	entrants []Entrant //按种子顺序排列
+	repo     storage.Repository
+
+	mu       sync.Mutex
+	rounds   [][]Pairing
+	schedule [][][2]int //循环赛预先生成的对阵和先后手
+}
+
+// New 创建比赛，选手按赛前评分排列种子顺序
+func New(cfg Config, entrants []Entrant) (*Tournament, error) {
+	if len(entrants) < 2 {
+		return nil, errors.New("at least 2 entrants are required")
+	}
+	ids := make(map[int]bool)
+	for _, e := range entrants {
+		if e.ID <= 0 {
+			return nil, fmt.Errorf("invalid entrant id %d", e.ID)
+		}
+		if ids[e.ID] {
+			return nil, fmt.Errorf("duplicated entrant id %d", e.ID)
+		}
+		ids[e.ID] = true
+	}
+	if cfg.Cycles <= 0 {
+		cfg.Cycles = 1
+	}
+	if cfg.Format == Swiss && cfg.Rounds <= 0 {
+		cfg.Rounds = int(math.Ceil(math.Log2(float64(len(entrants)))))
+	}
+
+	t := &Tournament{
+		cfg:      cfg,
+		entrants: append([]Entrant{}, entrants...),
+		rounds:   make([][]Pairing, 0),
+	}
+	sort.SliceStable(t.entrants, func(i, j int) bool {
+		return t.entrants[i].Rating > t.entrants[j].Rating
+	})
+	if cfg.Format == RoundRobin {
+		t.schedule = t.roundRobinColors()
+	}
+	return t, nil
+}
+
+// UseRepository 归档电脑自动下完的对局
+func (t *Tournament) UseRepository(repo storage.Repository) {
+	t.repo = repo
+}
+
+// GetConfig 获取比赛参数
+func (t *Tournament) GetConfig() Config {
+	return t.cfg
+}
+
+// GetEntrants 获取按种子顺序排列的选手
+func (t *Tournament) GetEntrants() []Entrant {
+	return append([]Entrant{}, t.entrants...)
+}
+
+// TotalRounds 比赛的总轮数
+func (t *Tournament) TotalRounds() int {
+	if t.cfg.Format == Swiss {
+		return t.cfg.Rounds
+	}
+	return len(t.schedule)
+}
+
+// Rounds 返回已经生成的所有轮次的配对
+func (t *Tournament) Rounds() [][]Pairing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rounds := make([][]Pairing, len(t.rounds))
+	for i, r := range t.rounds {
+		rounds[i] = append([]Pairing{}, r...)
+	}
+	return rounds
+}
+
+// Finished 比赛是否已经结束
+func (t *Tournament) Finished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.rounds) == t.TotalRounds() && t.roundFinished()
+}
+
+// 当前轮次的对局是否都已经结束，需要持有锁
+func (t *Tournament) roundFinished() bool {
+	if len(t.rounds) == 0 {
+		return true
+	}
+	for _, p := range t.rounds[len(t.rounds)-1] {
+		if !p.Finished {
+			return false
+		}
+	}
+	return true
+}
+
+// NextRound 生成下一轮的配对，当前轮次的对局必须都已经结束
+func (t *Tournament) NextRound() ([]Pairing, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.roundFinished() {
+		return nil, errors.New("current round is not finished")
+	}
+	if len(t.rounds) >= t.TotalRounds() {
+		return nil, errors.New("tournament is finished")
+	}
+
+	round := len(t.rounds) + 1
+	var pairs [][2]int
+	if t.cfg.Format == Swiss {
+		pairs = t.swissRound(round)
+	} else {
+		pairs = t.schedule[round-1]
+	}
+
+	pairings := make([]Pairing, 0, len(pairs))
+	for _, pair := range pairs {
+		p := Pairing{Round: round, Red: pair[0], Black: pair[1]}
+		if p.Red == 0 {
+			p.Red, p.Black = p.Black, p.Red
+		}
+		if p.IsBye() {
+			p.Finished = true
+			p.Outcome = rating.RedWin
+			p.Reason = "bye"
+		}
+		pairings = append(pairings, p)
+	}
+	//轮空放在最后一台
+	sort.SliceStable(pairings, func(i, j int) bool {
+		return !pairings[i].IsBye() && pairings[j].IsBye()
+	})
+	for i := range pairings {
+		pairings[i].Board = i + 1
+	}
+
+	t.rounds = append(t.rounds, pairings)
+	return append([]Pairing{}, pairings...), nil
+}
+
+// RecordResult 记录当前轮次一台对局的结果
+func (t *Tournament) RecordResult(board int, outcome rating.Outcome, reason string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.recordResult(board, outcome, reason, 0)
+}
+
+func (t *Tournament) recordResult(board int, outcome rating.Outcome, reason string, gameID int) error {
+	if len(t.rounds) == 0 {
+		return errors.New("tournament is not started")
+	}
+	round := t.rounds[len(t.rounds)-1]
+	if board <= 0 || board > len(round) {
+		return fmt.Errorf("invalid board %d", board)
+	}
+	p := &round[board-1]
+	if p.IsBye() {
+		return errors.New("cannot record result of a bye")
+	}
+	p.Finished = true
+	p.Outcome = outcome
+	p.Reason = reason
+	p.GameID = gameID
+	return nil
+}
+
+// PlayRound 自动下完当前轮次中双方都是电脑的对局，各台对局同时进行
+func (t *Tournament) PlayRound() error {
+	t.mu.Lock()
+	if len(t.rounds) == 0 {
+		t.mu.Unlock()
+		return errors.New("tournament is not started")
+	}
+	pending := make([]Pairing, 0)
+	for _, p := range t.rounds[len(t.rounds)-1] {
+		if !p.Finished && t.entrant(p.Red).Engine != nil && t.entrant(p.Black).Engine != nil {
+			pending = append(pending, p)
+		}
+	}
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(pending))
+	for i, p := range pending {
+		wg.Add(1)
+		go func(i int, p Pairing) {
+			defer wg.Done()
+			errs[i] = t.playGame(p)
+		}(i, p)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// 电脑自动下完一台对局，归档并记录结果
+func (t *Tournament) playGame(p Pairing) error {
+	red, black := t.entrant(p.Red), t.entrant(p.Black)
+	result, err := ai.PlayGame(red.Engine, black.Engine, t.cfg.MaxPlies)
+	if err != nil {
+		return err
+	}
+
+	gameID := 0
+	if t.repo != nil {
+		rec := result.Record
+		rec.RedID = red.ID
+		rec.BlackID = black.ID
+		rec.Room = fmt.Sprintf("round %d board %d", p.Round, p.Board)
+		saved, err := t.repo.SaveGame(rec)
+		if err != nil {
+			return err
+		}
+		gameID = saved.ID
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.recordResult(p.Board, result.Outcome, string(result.Reason), gameID)
+}
+
+// Run 自动进行所有轮次，只适用于所有选手都是电脑的比赛
+func (t *Tournament) Run() error {
+	for _, e := range t.entrants {
+		if e.Engine == nil {
+			return fmt.Errorf("entrant %d is not a bot", e.ID)
+		}
+	}
+	for !t.Finished() {
+		if _, err := t.NextRound(); err != nil {
+			return err
+		}
+		if err := t.PlayRound(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tournament) entrant(id int) Entrant {
+	for _, e := range t.entrants {
+		if e.ID == id {
+			return e
+		}
+	}
+	return Entrant{}
+}
+
+// 根据已经生成的轮次整理对局历史，需要持有锁
+func (t *Tournament) history() *history {
+	h := newHistory(t.entrants)
+	for _, round := range t.rounds {
+		for _, p := range round {
+			h.add(p)
+		}
+	}
+	return h
+}
+
+// 生成循环赛每一轮的对阵，并按照先后手平衡的原则决定颜色。多循环时后面的循环交换先后手
+func (t *Tournament) roundRobinColors() [][][2]int {
+	ids := make([]int, len(t.entrants))
+	for i, e := range t.entrants {
+		ids[i] = e.ID
+	}
+	cycle := roundRobinSchedule(ids)
+
+	h := newHistory(t.entrants)
+	for r, round := range cycle {
+		for i, pair := range round {
+			if pair[0] == 0 || pair[1] == 0 {
+				continue
+			}
+			red, black := h.assignColors(pair[0], pair[1], r+1)
+			cycle[r][i] = [2]int{red, black}
+			h.add(Pairing{Red: red, Black: black})
+		}
+	}
+
+	schedule := make([][][2]int, 0, len(cycle)*t.cfg.Cycles)
+	for c := 0; c < t.cfg.Cycles; c++ {
+		for _, round := range cycle {
+			pairs := make([][2]int, len(round))
+			for i, pair := range round {
+				if c%2 == 1 {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				pairs[i] = pair
+			}
+			schedule = append(schedule, pairs)
+		}
+	}
+	return schedule
+}
+
+// 生成瑞士制一轮的配对，需要持有锁
+func (t *Tournament) swissRound(round int) [][2]int {
+	h := t.history()
+	ranked := make([]int, 0, len(t.entrants))
+	for _, s := range t.standings() {
+		ranked = append(ranked, s.EntrantID)
+	}
+
+	pairs := make([][2]int, 0, len(ranked)/2+1)
+	//人数为奇数时，名次最低的没有轮空过的选手轮空
+	if len(ranked)%2 == 1 {
+		bye := len(ranked) - 1
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !h.hadBye[ranked[i]] {
+				bye = i
+				break
+			}
+		}
+		pairs = append(pairs, [2]int{ranked[bye], 0})
+		ranked = append(ranked[:bye:bye], ranked[bye+1:]...)
+	}
+
+	for _, pair := range swissPairs(ranked, h) {
+		red, black := h.assignColors(pair[0], pair[1], round)
+		pairs = append(pairs, [2]int{red, black})
+	}
+	return pairs
+}
+
+// Standings 按得分和对手分排列的名次
+func (t *Tournament) Standings() []Standing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.standings()
+}
+
+// 计算名次，需要持有锁。
+// 循环赛所有选手的对手相同，先比较索伯分再比较布赫兹分；瑞士制先比较布赫兹分
+func (t *Tournament) standings() []Standing {
+	index := make(map[int]*Standing)
+	standings := make([]Standing, len(t.entrants))
+	for i, e := range t.entrants {
+		standings[i] = Standing{EntrantID: e.ID, Name: e.Name}
+		index[e.ID] = &standings[i]
+	}
+
+	type game struct {
+		opponent int
+		score    float64
+	}
+	games := make(map[int][]game)
+	for _, round := range t.rounds {
+		for _, p := range round {
+			if !p.Finished {
+				continue
+			}
+			if p.IsBye() {
+				index[p.Red].Score += t.cfg.ByeScore
+				continue
+			}
+			redScore := 0.5
+			switch p.Outcome {
+			case rating.RedWin:
+				redScore = 1
+			case rating.BlackWin:
+				redScore = 0
+			}
+			games[p.Red] = append(games[p.Red], game{opponent: p.Black, score: redScore})
+			games[p.Black] = append(games[p.Black], game{opponent: p.Red, score: 1 - redScore})
+			index[p.Red].RedGames++
+		}
+	}
+
+	for id, gs := range games {
+		s := index[id]
+		for _, g := range gs {
+			s.Score += g.score
+			s.Games++
+			switch g.score {
+			case 1:
+				s.Wins++
+			case 0:
+				s.Losses++
+			default:
+				s.Draws++
+			}
+		}
+	}
+	//对手分需要所有选手的得分
+	for id, gs := range games {
+		s := index[id]
+		for _, g := range gs {
+			opponentScore := index[g.opponent].Score
+			s.Buchholz += opponentScore
+			s.SonnebornBerger += g.score * opponentScore
+		}
+	}
+
+	seed := make(map[int]int)
+	for i, e := range t.entrants {
+		seed[e.ID] = i
+	}
+	tieBreaks := func(s Standing) []float64 {
+		if t.cfg.Format == Swiss {
+			return []float64{s.Score, s.Buchholz, s.SonnebornBerger, float64(s.Wins)}
+		}
+		return []float64{s.Score, s.SonnebornBerger, s.Buchholz, float64(s.Wins)}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := tieBreaks(standings[i]), tieBreaks(standings[j])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] > b[k]
+			}
+		}
+		return seed[standings[i].EntrantID] < seed[standings[j].EntrantID]
+	})
+
+	//得分和对手分都相同的选手名次并列
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && equalFloats(tieBreaks(standings[i]), tieBreaks(standings[i-1])) {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
+
+func equalFloats(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,263 @@
+package tournament
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/ai"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+func bots(n int) []Entrant {
+	entrants := make([]Entrant, n)
+	for i := range entrants {
+		var engine ai.Engine = ai.NewRandom(int64(i))
+		if i%2 == 0 {
+			engine = ai.NewGreedy(int64(i))
+		}
+		entrants[i] = Entrant{ID: i + 1, Name: engine.Name(), Rating: float64(1500 - i), Engine: engine}
+	}
+	return entrants
+}
+
+func TestRoundRobin(t *testing.T) {
+	tm, err := New(DefaultConfig(RoundRobin), bots(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := storage.NewMemoryRepository()
+	tm.UseRepository(repo)
+	if err = tm.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	met := make(map[[2]int]int)
+	for _, round := range tm.Rounds() {
+		for _, p := range round {
+			if !p.Finished || p.GameID == 0 {
+				t.Fatalf("unfinished pairing %+v", p)
+			}
+			a, b := p.Red, p.Black
+			if a > b {
+				a, b = b, a
+			}
+			met[[2]int{a, b}]++
+		}
+	}
+	if len(met) != 15 {
+		t.Fatalf("expect 15 pairs, got %d", len(met))
+	}
+	for pair, n := range met {
+		if n != 1 {
+			t.Fatalf("pair %v met %d times", pair, n)
+		}
+	}
+	if n := len(repo.ListGames(0)); n != 15 {
+		t.Fatalf("expect 15 archived games, got %d", n)
+	}
+
+	total := 0.0
+	for _, s := range tm.Standings() {
+		total += s.Score
+		if s.Games != 5 || s.RedGames < 2 || s.RedGames > 3 {
+			t.Fatalf("unbalanced colors %+v", s)
+		}
+	}
+	if total != 15 {
+		t.Fatalf("expect total score 15, got %v", total)
+	}
+}
+
+func TestDoubleRoundRobinSwapsColors(t *testing.T) {
+	cfg := DefaultConfig(RoundRobin)
+	cfg.Cycles = 2
+	tm, _ := New(cfg, bots(3))
+	if tm.TotalRounds() != 6 {
+		t.Fatalf("expect 6 rounds, got %d", tm.TotalRounds())
+	}
+	if err := tm.Run(); err != nil {
+		t.Fatal(err)
+	}
+	colors := make(map[[2]int]int)
+	for _, round := range tm.Rounds() {
+		for _, p := range round {
+			if !p.IsBye() {
+				colors[[2]int{p.Red, p.Black}]++
+			}
+		}
+	}
+	for pair, n := range colors {
+		if n != 1 || colors[[2]int{pair[1], pair[0]}] != 1 {
+			t.Fatalf("each pair should play once with each color, got %v", colors)
+		}
+	}
+	for _, s := range tm.Standings() {
+		if s.Games != 4 || s.RedGames != 2 {
+			t.Fatalf("unexpected standing %+v", s)
+		}
+	}
+}
+
+func TestSwiss(t *testing.T) {
+	cfg := DefaultConfig(Swiss)
+	cfg.Rounds = 4
+	tm, err := New(cfg, bots(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tm.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	met := make(map[[2]int]bool)
+	byes := make(map[int]int)
+	for _, round := range tm.Rounds() {
+		if len(round) != 4 || !round[3].IsBye() {
+			t.Fatalf("expect 3 games and a bye, got %+v", round)
+		}
+		for _, p := range round {
+			if p.IsBye() {
+				byes[p.Red]++
+				continue
+			}
+			a, b := p.Red, p.Black
+			if a > b {
+				a, b = b, a
+			}
+			if met[[2]int{a, b}] {
+				t.Fatalf("%d and %d met twice", a, b)
+			}
+			met[[2]int{a, b}] = true
+		}
+	}
+	for id, n := range byes {
+		if n > 1 {
+			t.Fatalf("entrant %d got %d byes", id, n)
+		}
+	}
+	total := 0.0
+	for _, s := range tm.Standings() {
+		total += s.Score
+	}
+	//每轮3局棋加1分轮空
+	if total != 16 {
+		t.Fatalf("expect total score 16, got %v", total)
+	}
+}
+
+// 人数多、轮次多时，后几轮几乎所有选手都交过手，配对也要很快完成
+func TestSwissManyRounds(t *testing.T) {
+	const n, rounds = 34, 30
+	entrants := make([]Entrant, n)
+	for i := range entrants {
+		entrants[i] = Entrant{ID: i + 1, Rating: float64(2000 - i)}
+	}
+	cfg := DefaultConfig(Swiss)
+	cfg.Rounds = rounds
+	tm, err := New(cfg, entrants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	outcomes := []rating.Outcome{rating.RedWin, rating.BlackWin, rating.Draw}
+	for r := 0; r < rounds; r++ {
+		round, err := tm.NextRound()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(round) != n/2 {
+			t.Fatalf("round %d: expect %d games, got %d", r+1, n/2, len(round))
+		}
+		for _, p := range round {
+			if err = tm.RecordResult(p.Board, outcomes[(p.Red*7+p.Black*3+r)%3], ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("pairing %d rounds took %v", rounds, elapsed)
+	}
+	if !tm.Finished() {
+		t.Fatal("tournament should be finished")
+	}
+}
+
+// 名次最低的选手和所有人都交过手时不存在不重复的配对，搜索要很快放弃，改用更宽松的条件
+func TestSwissPairsGivesUpQuickly(t *testing.T) {
+	const n = 30
+	entrants := make([]Entrant, n)
+	ranked := make([]int, n)
+	for i := range entrants {
+		entrants[i] = Entrant{ID: i + 1}
+		ranked[i] = i + 1
+	}
+	h := newHistory(entrants)
+	for id := 1; id < n; id++ {
+		h.met[n][id] = true
+		h.met[id][n] = true
+	}
+
+	start := time.Now()
+	pairs := swissPairs(ranked, h)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("pairing took %v", elapsed)
+	}
+	seen := make(map[int]bool)
+	for _, p := range pairs {
+		seen[p[0]] = true
+		seen[p[1]] = true
+	}
+	if len(pairs) != n/2 || len(seen) != n {
+		t.Fatalf("expect %d pairs covering everyone, got %v", n/2, pairs)
+	}
+}
+
+func TestTieBreaks(t *testing.T) {
+	entrants := []Entrant{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}, {ID: 4, Name: "d"}}
+	tm, _ := New(DefaultConfig(RoundRobin), entrants)
+	if err := tm.PlayRound(); err == nil {
+		t.Fatal("play before the first round should fail")
+	}
+
+	//结果：a胜b，a负c，a和d，b胜c，b胜d，c负d
+	//得分：a 1.5，b 2，c 1，d 1.5
+	results := map[[2]int]float64{{1, 2}: 1, {1, 3}: 0, {1, 4}: 0.5, {2, 3}: 1, {2, 4}: 1, {3, 4}: 0}
+	for !tm.Finished() {
+		pairings, err := tm.NextRound()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range pairings {
+			score, ok := results[[2]int{p.Red, p.Black}]
+			if !ok {
+				score = 1 - results[[2]int{p.Black, p.Red}]
+			}
+			outcome := rating.Draw
+			if score == 1 {
+				outcome = rating.RedWin
+			} else if score == 0 {
+				outcome = rating.BlackWin
+			}
+			if err = tm.RecordResult(p.Board, outcome, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	//a和d同分，a的索伯分更高排在前面
+	standings := tm.Standings()
+	expect := []Standing{
+		{Rank: 1, EntrantID: 2, Score: 2, SonnebornBerger: 2.5, Buchholz: 4},      //战胜c(1)和d(1.5)
+		{Rank: 2, EntrantID: 1, Score: 1.5, SonnebornBerger: 2.75, Buchholz: 4.5}, //战胜b(2)，战和d(1.5)
+		{Rank: 3, EntrantID: 4, Score: 1.5, SonnebornBerger: 1.75, Buchholz: 4.5}, //战胜c(1)，战和a(1.5)
+		{Rank: 4, EntrantID: 3, Score: 1, SonnebornBerger: 1.5, Buchholz: 5},      //战胜a(1.5)
+	}
+	for i, e := range expect {
+		s := standings[i]
+		if s.Rank != e.Rank || s.EntrantID != e.EntrantID || s.Score != e.Score || s.SonnebornBerger != e.SonnebornBerger || s.Buchholz != e.Buchholz {
+			t.Fatalf("expect %+v, got %+v", e, s)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// 断线后重新连接服务器的间隔
+var reconnectInterval = 2 * time.Second
+
+// Client 连接服务器的客户端。
+// 客户端总是以自己位于棋盘下方的视角收发下棋意图，和服务器棋盘的坐标转换由客户端完成。
+// 对局进行中连接断开时，客户端使用会话令牌自动重连，重连成功后会收到Sync数据包
+type Client struct {
+	addr     string
+	room     string
+	playerID int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	enc    *json.Encoder
+	status ConnStatus //连接状态
+	seat   Packet     //服务器分配的座位
+	closed bool
+
+	spectator bool //观战者只接收消息，不能下棋
+
+	queue       bool          //通过自动匹配入座，房间由服务器分配
+	timeControl time.Duration //自动匹配期望的对局时间
+
+	packets chan Packet //从服务器收到的数据包
+}
+
+// 服务器拒绝了加入房间的请求
+type rejectError struct {
+	msg string
+}
+
+func (e *rejectError) Error() string {
+	return e.msg
+}
+
+// Dial 连接服务器并加入房间，分配到座位后返回。playerID为0表示匿名玩家，对局不计算评分
+func Dial(addr, room string, playerID int) (*Client, error) {
+	c := &Client{
+		addr:     addr,
+		room:     room,
+		playerID: playerID,
+		status:   Connecting,
+		packets:  make(chan Packet, 16),
+	}
+
+	dec, err := c.connect("")
+	if err != nil {
+		return nil, err
+	}
+	c.setStatus(Waiting)
+
+	go c.run(dec)
+
+	return c, nil
+}
+
+// DialQueue 连接服务器排队等待自动匹配，匹配成功并在服务器新建的房间入座之后返回。
+// 自动匹配按评分寻找对手，所以playerID不能为0。timeControl为0时使用服务器的对局时间
+func DialQueue(addr string, playerID int, timeControl time.Duration) (*Client, error) {
+	c := &Client{
+		addr:        addr,
+		playerID:    playerID,
+		status:      Connecting,
+		packets:     make(chan Packet, 16),
+		queue:       true,
+		timeControl: timeControl,
+	}
+
+	dec, err := c.connect("")
+	if err != nil {
+		return nil, err
+	}
+	c.setStatus(Waiting)
+
+	go c.run(dec)
+
+	return c, nil
+}
+
+// Watch 连接服务器观战。观战者以服务器棋盘下方玩家（先手方）的视角接收消息，
+// 第一个数据包是包含延迟之后局面的Sync
+func Watch(addr, room string) (*Client, error) {
+	c := &Client{
+		addr:      addr,
+		room:      room,
+		status:    Connecting,
+		packets:   make(chan Packet, 16),
+		spectator: true,
+	}
+
+	dec, err := c.connect("")
+	if err != nil {
+		return nil, err
+	}
+
+	go c.run(dec)
+
+	return c, nil
+}
+
+// 建立连接并加入房间，token不为空时找回原来的座位
+func (c *Client) connect(token string) (*json.Decoder, error) {
+	conn, err := net.Dial("tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(conn)
+	join := Packet{Type: Join, Room: c.room, Token: token, PlayerID: c.playerID}
+	if c.spectator {
+		join = Packet{Type: Spectate, Room: c.room}
+	}
+	if c.queue && len(token) == 0 {
+		join = Packet{Type: Queue, PlayerID: c.playerID, TimeControl: c.timeControl}
+	}
+	err = enc.Encode(join)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	//等待服务器分配座位，观战者直接收到对局状态
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	var p Packet
+	if err = dec.Decode(&p); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if p.Type == Fail {
+		conn.Close()
+		return nil, &rejectError{msg: p.Msg}
+	}
+	if !c.spectator && p.Type != Seat || c.spectator && p.Type != Sync {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected packet %s", p.Type)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return nil, errors.New("client is closed")
+	}
+	c.conn = conn
+	c.enc = enc
+	if c.spectator {
+		c.seat = Packet{Type: Seat, Room: c.room, Group: core.Group1, IsFirst: true, IsDown: true}
+	} else {
+		c.seat = p
+		//自动匹配的房间由服务器分配，断线重连时按这个名称找回座位
+		c.room = p.Room
+	}
+	c.mu.Unlock()
+
+	if c.spectator {
+		c.deliver(p)
+	}
+
+	return dec, nil
+}
+
+// 读取服务器的数据包，对局进行中连接断开时自动重连
+func (c *Client) run(dec *json.Decoder) {
+	defer close(c.packets)
+	for {
+		c.readLoop(dec)
+
+		//对局还没开始时服务器不保留座位，无法重连
+		if c.isClosed() || c.GetStatus() != Playing {
+			c.setStatus(Disconnected)
+			return
+		}
+
+		c.setStatus(Reconnecting)
+		for {
+			time.Sleep(reconnectInterval)
+			if c.isClosed() {
+				c.setStatus(Disconnected)
+				return
+			}
+			var err error
+			dec, err = c.connect(c.getToken())
+			if err == nil {
+				break
+			}
+			var reject *rejectError
+			if errors.As(err, &reject) {
+				//宽限期已过或者对局已经结束
+				c.setStatus(Disconnected)
+				return
+			}
+		}
+	}
+}
+
+// 持续读取服务器的数据包，直到连接断开
+func (c *Client) readLoop(dec *json.Decoder) {
+	for {
+		var p Packet
+		if err := dec.Decode(&p); err != nil {
+			return
+		}
+		c.deliver(p)
+	}
+}
+
+// 将数据包中的坐标转换为客户端视角，然后交给调用方
+func (c *Client) deliver(p Packet) {
+	if p.Type == Start || p.Type == Sync {
+		c.setStatus(Playing)
+	}
+	if p.Statement != nil {
+		st := c.flipStatement(*p.Statement)
+		p.Statement = &st
+	}
+	for i := range p.History {
+		p.History[i] = c.flipStatement(p.History[i])
+	}
+	if p.GameMsg != nil && p.GameMsg.Move != nil {
+		msg, move := *p.GameMsg, *p.GameMsg.Move
+		move.From, move.To = c.flipCoordinates(move.From, move.To)
+		msg.Move = &move
+		p.GameMsg = &msg
+	}
+	c.packets <- p
+}
+
+// SendStatement 提交下棋意图，坐标使用客户端视角的坐标
+func (c *Client) SendStatement(st player.Statement) error {
+	st = c.flipStatement(st)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spectator {
+		return errors.New("spectators cannot move")
+	}
+	if c.status != Playing {
+		return errors.New("game is not running")
+	}
+	return c.enc.Encode(Packet{Type: Move, Statement: &st})
+}
+
+// Packets 返回接收服务器数据包的通道，连接彻底断开后通道关闭
+func (c *Client) Packets() <-chan Packet {
+	return c.packets
+}
+
+// GetGroup 获取分配到的阵营
+func (c *Client) GetGroup() core.ChessmanGroup {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seat.Group
+}
+
+// GetIsFirst 获取分配到的座位是否先手
+func (c *Client) GetIsFirst() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seat.IsFirst
+}
+
+// Addr 获取服务器地址
+func (c *Client) Addr() string {
+	return c.addr
+}
+
+// IsSpectator 查询是否是观战者
+func (c *Client) IsSpectator() bool {
+	return c.spectator
+}
+
+// GetStatus 获取连接状态
+func (c *Client) GetStatus() ConnStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+func (c *Client) setStatus(status ConnStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+func (c *Client) getToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seat.Token
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 断开连接，不再重连
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return c.conn.Close()
+}
+
+// 客户端的座位在服务器棋盘上方时，双方视角相差180度，坐标需要翻转
+func (c *Client) flipStatement(st player.Statement) player.Statement {
+	st.Source, st.Target = c.flipCoordinates(st.Source, st.Target)
+	return st
+}
+
+// 按照客户端的座位翻转着法的起点和终点
+func (c *Client) flipCoordinates(from, to core.Coordinate) (core.Coordinate, core.Coordinate) {
+	c.mu.Lock()
+	isDown := c.seat.IsDown
+	c.mu.Unlock()
+	if isDown {
+		return from, to
+	}
+	return FlipCoordinate(from), FlipCoordinate(to)
+}
+
+// QueryLeaderboard 查询服务器积分榜上评分最高的n名玩家
+func QueryLeaderboard(addr string, n int) ([]rating.Rating, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = json.NewEncoder(conn).Encode(Packet{Type: Leaders, N: n}); err != nil {
+		return nil, err
+	}
+	var p Packet
+	if err = json.NewDecoder(bufio.NewReader(conn)).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Type != Leaders {
+		return nil, fmt.Errorf("unexpected packet %s", p.Type)
+	}
+	return p.Ratings, nil
+}
+
+// FlipCoordinate 将棋盘坐标旋转180度，转换为对面玩家视角的坐标
+func FlipCoordinate(co core.Coordinate) core.Coordinate {
+	return core.Coordinate{
+		X: 8 - co.X,
+		Y: 9 - co.Y,
+	}
+}
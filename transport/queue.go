@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/CXeon/xiangqi/matchmaking"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// 排队失败的原因，作为Fail数据包的消息发给客户端
+var (
+	errQueueTimeout = errors.New("queue timeout")           //排队超时，没有匹配到对手
+	errOpponentLeft = errors.New("opponent left the queue") //匹配到的对手已经断线
+)
+
+// 正在排队的连接
+type queuedConn struct {
+	w      *writer
+	result chan queueResult //匹配成功入座或者排队失败之后收到结果
+}
+
+// 排队的结果，err为nil时玩家已经在新房间入座
+type queueResult struct {
+	room *Room
+	seat *seat
+	err  error
+}
+
+// UseMatchmaker 使用匹配服务接受排队请求，每隔interval匹配一轮，直到quit通道收到信号。
+// 匹配成功的两名玩家被安排到新建的房间，先手先入座
+func (s *Server) UseMatchmaker(m *matchmaking.Matchmaker, interval time.Duration, quit chan struct{}) {
+	s.matchmaker = m
+	matchChan, expiredChan, errChan := m.Run(interval, quit)
+	go func() {
+		for matchChan != nil || expiredChan != nil || errChan != nil {
+			select {
+			case match, ok := <-matchChan:
+				if !ok {
+					matchChan = nil
+					continue
+				}
+				s.seatMatch(match)
+			case t, ok := <-expiredChan:
+				if !ok {
+					expiredChan = nil
+					continue
+				}
+				if q := s.takeQueued(t.PlayerID); q != nil {
+					q.result <- queueResult{err: errQueueTimeout}
+				}
+			case err, ok := <-errChan:
+				if !ok {
+					errChan = nil
+					continue
+				}
+				log.Printf("matchmaking: %v", err)
+			}
+		}
+	}()
+}
+
+// 处理排队的连接：等待匹配，匹配成功后和加入房间的玩家一样下棋。排队期间连接断开时取消排队
+func (s *Server) queue(conn net.Conn, dec *json.Decoder, w *writer, p Packet) {
+	if s.matchmaker == nil {
+		w.send(Packet{Type: Fail, Msg: "matchmaking is not enabled"})
+		return
+	}
+	if p.PlayerID == 0 {
+		w.send(Packet{Type: Fail, Msg: "anonymous players cannot queue"})
+		return
+	}
+
+	r := rating.NewRating(p.PlayerID)
+	if s.ratings != nil {
+		r = s.ratings.Get(p.PlayerID)
+	}
+	timeControl := p.TimeControl
+	if timeControl <= 0 {
+		timeControl = s.cfg.TimeControl
+	}
+
+	q := &queuedConn{w: w, result: make(chan queueResult, 1)}
+	s.mu.Lock()
+	if _, ok := s.queued[p.PlayerID]; ok {
+		s.mu.Unlock()
+		w.send(Packet{Type: Fail, Msg: "player is already in the queue"})
+		return
+	}
+	s.queued[p.PlayerID] = q
+	s.mu.Unlock()
+	if err := s.matchmaker.Enqueue(p.PlayerID, int(r.Glicko.Rating), timeControl); err != nil {
+		s.takeQueued(p.PlayerID)
+		w.send(Packet{Type: Fail, Msg: err.Error()})
+		return
+	}
+
+	packets := readPackets(dec)
+	var res queueResult
+	for waiting := true; waiting; {
+		select {
+		case res = <-q.result:
+			waiting = false
+		case _, ok := <-packets:
+			if ok {
+				//排队期间忽略客户端的数据包
+				continue
+			}
+			s.matchmaker.Cancel(p.PlayerID)
+			if s.takeQueued(p.PlayerID) != nil {
+				return
+			}
+			//已经匹配成功，等到入座之后按照断线处理，对手等待宽限期
+			if res = <-q.result; res.err == nil {
+				res.room.leave(res.seat, w)
+			}
+			return
+		}
+	}
+
+	if res.err != nil {
+		w.send(Packet{Type: Fail, Msg: res.err.Error()})
+		//连接关闭之后读取协程才会退出
+		go func() {
+			for range packets {
+			}
+		}()
+		return
+	}
+	s.play(conn, packets, w, res.room, res.seat)
+}
+
+// 为匹配成功的两名玩家创建新房间并入座，然后通知双方的连接
+func (s *Server) seatMatch(match *matchmaking.Match) {
+	red, black := s.takeQueued(match.Red.PlayerID), s.takeQueued(match.Black.PlayerID)
+	if red == nil || black == nil {
+		for _, q := range []*queuedConn{red, black} {
+			if q != nil {
+				q.result <- queueResult{err: errOpponentLeft}
+			}
+		}
+		return
+	}
+
+	cfg := s.cfg
+	cfg.TimeControl = match.TimeControl
+	room := s.createRoom(cfg)
+	redSeat, err := room.join(red.w, "", match.Red.PlayerID)
+	if err == nil {
+		var blackSeat *seat
+		blackSeat, err = room.join(black.w, "", match.Black.PlayerID)
+		if err == nil {
+			log.Printf("room %s: matched player %d and %d", room.name, match.Red.PlayerID, match.Black.PlayerID)
+			red.result <- queueResult{room: room, seat: redSeat}
+			black.result <- queueResult{room: room, seat: blackSeat}
+			return
+		}
+	}
+	room.mu.Lock()
+	room.finish()
+	room.mu.Unlock()
+	red.result <- queueResult{err: err}
+	black.result <- queueResult{err: err}
+}
+
+// 取出排队的连接，连接已经不在队列中时返回nil
+func (s *Server) takeQueued(playerID int) *queuedConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := s.queued[playerID]
+	delete(s.queued, playerID)
+	return q
+}
+
+// 为匹配的对局创建名称不重复的房间
+func (s *Server) createRoom(cfg Config) *Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		name := "match-" + newToken()[:8]
+		if _, ok := s.rooms[name]; ok {
+			continue
+		}
+		room := newRoom(name, cfg, s.ratings, s.repo, s.removeRoom)
+		s.rooms[name] = room
+		return room
+	}
+}
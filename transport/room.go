@@ -0,0 +1,476 @@
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessclock"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// 房间内的一个座位
+type seat struct {
+	w      *writer //为nil表示玩家已经断线，座位空置
+	token  string  //会话令牌
+	group  core.ChessmanGroup
+	player player.PlayerInterface
+
+	graceTimer *time.Timer //断线宽限期计时器
+}
+
+// Room 对战房间，两个座位都有人后开始对局。
+// mu只保护房间的状态，持有mu时不等待内核、不读写存储，发给玩家的数据包只放进连接的发送队列
+type Room struct {
+	name    string
+	cfg     Config
+	ratings *rating.Service
+	repo    storage.Repository
+	onClose func(room *Room) //房间不再需要时回调，由服务器销毁房间
+
+	mu        sync.Mutex
+	seats     []*seat
+	game      *chessgame.ChessGame
+	ch1       chan player.Statement //阵营1的下棋意图
+	ch2       chan player.Statement //阵营2的下棋意图
+	coreCh    chan chessgame.GameMsg
+	clock     *chessclock.Clock
+	flagTimer *time.Timer //走棋方超时计时器
+	startedAt time.Time   //对局开始的时间
+	finished  bool        //对局已经结束，房间不再接受新的玩家
+	closed    bool
+	moving    bool       //内核正在处理下棋意图，这时不能读取或者关闭棋局
+	idle      *sync.Cond //内核处理完下棋意图时通知等待的协程
+}
+
+func newRoom(name string, cfg Config, ratings *rating.Service, repo storage.Repository, onClose func(room *Room)) *Room {
+	r := &Room{
+		name:    name,
+		cfg:     cfg,
+		ratings: ratings,
+		repo:    repo,
+		onClose: onClose,
+		seats:   make([]*seat, 0, 2),
+		ch1:     make(chan player.Statement, 1),
+		ch2:     make(chan player.Statement, 1),
+	}
+	r.idle = sync.NewCond(&r.mu)
+	return r
+}
+
+// 入座。token不为空时表示断线重连，找回原来的座位并同步对局状态。
+// 第一个入座的玩家先手并位于服务器棋盘下方
+func (r *Room) join(w *writer, token string, playerID int) (*seat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(token) > 0 {
+		return r.rejoin(w, token)
+	}
+
+	if r.finished || r.closed || len(r.seats) >= 2 {
+		return nil, errors.New("room is full")
+	}
+
+	p := player.NewPlayer()
+	p.SetID(playerID)
+	st := &seat{w: w, token: newToken(), player: p}
+	if len(r.seats) == 0 {
+		st.group = core.Group1
+		p.SetIsFirst(true)
+		p.SetIsDown(true)
+	} else {
+		st.group = core.Group2
+	}
+	p.SetGroup(st.group)
+	r.seats = append(r.seats, st)
+
+	st.send(r.seatPacket(st))
+
+	if len(r.seats) < 2 {
+		return st, nil
+	}
+
+	//双方就位，启动内核和棋钟
+	game := new(chessgame.ChessGame)
+	err := game.InitialGame(r.seats[0].player, r.seats[1].player)
+	if err != nil {
+		r.seats = r.seats[:1]
+		return nil, err
+	}
+	r.game = game
+	r.coreCh = game.Run(r.ch1, r.ch2)
+	r.startedAt = time.Now()
+	r.clock = chessclock.NewClock(r.cfg.TimeControl)
+	r.clock.Start(game.GetNextRoundGroup())
+	r.resetFlagTimer()
+
+	state := r.clock.GetState()
+	r.broadcast(Packet{Type: Start, Room: r.name, Clock: &state})
+
+	return st, nil
+}
+
+// 断线重连，找回座位后下发完整的对局状态
+func (r *Room) rejoin(w *writer, token string) (*seat, error) {
+	var st *seat
+	for _, s := range r.seats {
+		if s.token == token {
+			st = s
+		}
+	}
+	if st == nil || r.closed {
+		//房间已经被销毁，按名称新建的空房间也不再需要
+		if len(r.seats) == 0 {
+			r.close()
+		}
+		return nil, errors.New("session expired")
+	}
+
+	if st.graceTimer != nil {
+		st.graceTimer.Stop()
+		st.graceTimer = nil
+	}
+	st.w = w
+	st.send(r.seatPacket(st))
+
+	if r.game == nil {
+		return st, nil
+	}
+	r.waitCore()
+
+	state := r.clock.GetState()
+	st.send(Packet{
+		Type:    Sync,
+		Room:    r.name,
+		FEN:     r.game.GetPosition().FEN(),
+		History: r.game.GetHistory(),
+		Clock:   &state,
+	})
+	r.broadcastExcept(st, Packet{Type: Resume, Room: r.name, Group: st.group})
+
+	return st, nil
+}
+
+func (r *Room) seatPacket(st *seat) Packet {
+	return Packet{
+		Type:    Seat,
+		Room:    r.name,
+		Group:   st.group,
+		IsFirst: st.player.GetIsFirst(),
+		IsDown:  st.player.GetIsDown(),
+		Token:   st.token,
+	}
+}
+
+// 加入观战，对局开始之后才能观战
+func (r *Room) spectate() (*chessgame.Spectator, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.waitCore()
+	if r.game == nil || r.finished {
+		return nil, errors.New("game is not running")
+	}
+	return r.game.Spectate(r.cfg.WatchDelay), nil
+}
+
+// 处理玩家提交的下棋意图，交给内核校验后广播结果。等待内核时不持有锁，超时检查不会被卡住
+func (r *Room) handleStatement(st *seat, statement player.Statement) {
+	r.mu.Lock()
+	r.waitCore()
+	if r.game == nil || r.finished {
+		st.reject(statement, RejectNotRunning)
+		r.mu.Unlock()
+		return
+	}
+	//网络对战不支持悔棋
+	if statement.Undo {
+		st.reject(statement, RejectUndoNotAllowed)
+		r.mu.Unlock()
+		return
+	}
+	//只能移动自己阵营的棋子，而且只能在自己的回合下棋
+	if statement.Group != st.group {
+		st.reject(statement, RejectNotYourChessman)
+		r.mu.Unlock()
+		return
+	}
+	if r.game.GetNextRoundGroup() != st.group {
+		st.reject(statement, RejectNotYourTurn)
+		r.mu.Unlock()
+		return
+	}
+	ch := r.ch1
+	if st.group == core.Group2 {
+		ch = r.ch2
+	}
+	r.moving = true
+	r.mu.Unlock()
+
+	ch <- statement
+	msg := <-r.coreCh
+
+	r.mu.Lock()
+	r.moving = false
+	r.idle.Broadcast()
+
+	if msg.Event == chessgame.Err {
+		//移动无效只通知下棋的一方
+		st.send(Packet{Type: Result, Statement: &statement, GameMsg: &msg})
+		r.mu.Unlock()
+		return
+	}
+	//等待内核期间对局已经因为超时或者弃局结束，这一步棋不再生效
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+
+	if msg.Event != chessgame.Fin {
+		r.clock.Start(r.game.GetNextRoundGroup())
+		r.resetFlagTimer()
+		state := r.clock.GetState()
+		r.broadcast(Packet{Type: Result, Statement: &statement, GameMsg: &msg, Clock: &state})
+		r.mu.Unlock()
+		return
+	}
+
+	//内核判定胜负之后已经退出，也已经把结果推送给观战者
+	r.clock.Stop()
+	state := r.clock.GetState()
+	writers := r.finish()
+	r.mu.Unlock()
+	r.conclude(writers, Packet{Type: Result, Statement: &statement, GameMsg: &msg, Clock: &state}, false)
+}
+
+// 玩家的连接断开。对局进行中时保留座位，超过宽限期没有重连判负
+func (r *Room) leave(st *seat, w *writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//玩家已经用新的连接重连，旧连接断开不影响座位
+	if st.w != w {
+		return
+	}
+	st.w = nil
+
+	if r.game == nil {
+		//对局还没开始，直接让出座位
+		for i, s := range r.seats {
+			if s == st {
+				r.seats = append(r.seats[:i], r.seats[i+1:]...)
+				break
+			}
+		}
+		if len(r.seats) == 0 {
+			r.close()
+		}
+		return
+	}
+
+	if r.finished {
+		return
+	}
+
+	r.broadcast(Packet{Type: Vacant, Room: r.name, Group: st.group})
+	st.graceTimer = time.AfterFunc(r.cfg.GracePeriod, func() {
+		r.abandon(st)
+	})
+}
+
+// 宽限期结束玩家仍未重连，判对方获胜
+func (r *Room) abandon(st *seat) {
+	r.mu.Lock()
+	r.waitCore()
+	if r.finished || st.w != nil {
+		r.mu.Unlock()
+		return
+	}
+	r.endGame(opponentGroup(st.group), chessgame.TerminationAbandoned)
+}
+
+// 走棋方超时检查
+func (r *Room) checkFlag() {
+	r.mu.Lock()
+	r.waitCore()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	group, expired := r.clock.Expired()
+	if !expired {
+		r.mu.Unlock()
+		return
+	}
+	r.endGame(opponentGroup(group), chessgame.TerminationTimeout)
+}
+
+// 等待内核处理完正在提交的下棋意图，调用方持有r.mu。
+// 内核只做计算，不读写连接，所以等待的时间很短
+func (r *Room) waitCore() {
+	for r.moving {
+		r.idle.Wait()
+	}
+}
+
+// 为正在计时的一方设置超时计时器
+func (r *Room) resetFlagTimer() {
+	if r.flagTimer != nil {
+		r.flagTimer.Stop()
+	}
+	r.flagTimer = time.AfterFunc(r.clock.Remaining(r.clock.Running()), r.checkFlag)
+}
+
+// 非下棋原因结束对局，例如超时或者弃局。调用方持有r.mu，返回之前释放
+func (r *Room) endGame(wonGroup core.ChessmanGroup, termination chessgame.Termination) {
+	r.clock.Stop()
+	state := r.clock.GetState()
+	msg := chessgame.NewGameOver(wonGroup, termination)
+	writers := r.finish()
+	r.mu.Unlock()
+	r.conclude(writers, Packet{Type: Result, GameMsg: &msg, Clock: &state}, true)
+}
+
+// 对局结束之后的收尾，不持有锁：记录结果并通知双方，然后关闭内核。
+// publish表示结果不是内核产生的，观战者只能从内核收到消息，需要在关闭棋局之前把结果推送给他们
+func (r *Room) conclude(writers []*writer, p Packet, publish bool) {
+	msg := *p.GameMsg
+	p.Ratings = r.recordResult(msg)
+	for _, w := range writers {
+		w.send(p)
+	}
+	if publish {
+		r.game.Publish(msg)
+	}
+	r.game.Close()
+}
+
+// 记录对局结果：归档对局，双方都是实名玩家时更新评分，返回双方更新后的评分
+func (r *Room) recordResult(msg chessgame.GameMsg) []rating.Rating {
+	if len(r.seats) < 2 {
+		return nil
+	}
+	red, black := r.seats[0].player, r.seats[1].player
+	if black.GetIsFirst() {
+		red, black = black, red
+	}
+	r.archive(red, black, msg)
+
+	if r.ratings == nil || red.GetID() == 0 || black.GetID() == 0 {
+		return nil
+	}
+	outcome, ok := rating.OutcomeOf(msg, red.GetGroup())
+	if !ok {
+		return nil
+	}
+	redRating, blackRating, err := r.ratings.Record(red.GetID(), black.GetID(), outcome)
+	if err != nil {
+		return nil
+	}
+	return []rating.Rating{redRating, blackRating}
+}
+
+// 归档结束的对局，实名玩家没有资料时自动创建
+func (r *Room) archive(red, black player.PlayerInterface, msg chessgame.GameMsg) {
+	if r.repo == nil {
+		return
+	}
+	for _, p := range []player.PlayerInterface{red, black} {
+		if p.GetID() == 0 {
+			continue
+		}
+		if _, err := r.repo.EnsurePlayer(p.GetID(), fmt.Sprintf("玩家%d", p.GetID())); err != nil {
+			log.Printf("room %s: create player %d: %v", r.name, p.GetID(), err)
+		}
+	}
+	rec := storage.NewGameRecord(r.game, red, black, msg, r.startedAt)
+	rec.Room = r.name
+	if _, err := r.repo.SaveGame(rec); err != nil {
+		log.Printf("room %s: save game: %v", r.name, err)
+	}
+}
+
+// 结束对局，销毁房间，返回还在线的玩家的连接。调用方持有r.mu。
+// 对局结束之后座位和棋局不再变化，由调用方释放锁之后记录结果并关闭内核
+func (r *Room) finish() []*writer {
+	if r.finished {
+		return nil
+	}
+	r.finished = true
+	if r.flagTimer != nil {
+		r.flagTimer.Stop()
+	}
+	var writers []*writer
+	for _, s := range r.seats {
+		if s.graceTimer != nil {
+			s.graceTimer.Stop()
+		}
+		if s.w != nil {
+			writers = append(writers, s.w)
+		}
+	}
+	r.close()
+	return writers
+}
+
+// 销毁房间，房间名称可以被新的房间使用
+func (r *Room) close() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	if r.onClose != nil {
+		r.onClose(r)
+	}
+}
+
+func (r *Room) broadcast(p Packet) {
+	for _, s := range r.seats {
+		s.send(p)
+	}
+}
+
+func (r *Room) broadcastExcept(except *seat, p Packet) {
+	for _, s := range r.seats {
+		if s != except {
+			s.send(p)
+		}
+	}
+}
+
+// 拒绝玩家的下棋意图，只通知下棋的一方
+func (s *seat) reject(statement player.Statement, reason chessgame.RejectReason) {
+	msg := chessgame.NewRejected(reason, "")
+	s.send(Packet{Type: Result, Statement: &statement, GameMsg: &msg})
+}
+
+// 把数据包放进玩家连接的发送队列，玩家断线时丢弃
+func (s *seat) send(p Packet) {
+	if s.w == nil {
+		return
+	}
+	s.w.send(p)
+}
+
+func opponentGroup(group core.ChessmanGroup) core.ChessmanGroup {
+	if group == core.Group1 {
+		return core.Group2
+	}
+	return core.Group1
+}
+
+// 生成随机的会话令牌
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/matchmaking"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// Server 对战服务器，按房间名称管理棋局。
+// 所有的规则校验都由房间内运行的核心层棋局完成，服务器只负责转发下棋意图和广播结果
+type Server struct {
+	cfg     Config
+	ratings *rating.Service    //不为nil时记录实名玩家的对局结果
+	repo    storage.Repository //不为nil时归档结束的对局
+
+	matchmaker *matchmaking.Matchmaker //不为nil时接受自动匹配的排队请求
+
+	mu     sync.Mutex
+	rooms  map[string]*Room
+	queued map[int]*queuedConn //正在排队等待匹配的玩家
+}
+
+func NewServer(cfg Config) *Server {
+	return &Server{
+		cfg:    cfg,
+		rooms:  make(map[string]*Room),
+		queued: make(map[int]*queuedConn),
+	}
+}
+
+// UseRatings 使用评分服务记录对局结果，并提供积分榜查询
+func (s *Server) UseRatings(ratings *rating.Service) {
+	s.ratings = ratings
+}
+
+// UseRepository 使用存储归档结束的对局，并为实名玩家创建资料
+func (s *Server) UseRepository(repo storage.Repository) {
+	s.repo = repo
+}
+
+// ListenAndServe 监听tcp地址并处理客户端连接
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 在listener上接收客户端连接
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// 处理单个客户端连接：第一个数据包必须是加入房间。
+// 发送给客户端的数据包由连接的发送协程写入，处理结束时写完剩下的数据包再关闭连接
+func (s *Server) handleConn(conn net.Conn) {
+	w := newWriter(conn)
+	defer w.close()
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+
+	var p Packet
+	if err := dec.Decode(&p); err != nil {
+		return
+	}
+	if p.Type == Leaders {
+		var leaders []rating.Rating
+		if s.ratings != nil {
+			leaders = s.ratings.Leaderboard(p.N, rating.ByGlicko)
+		}
+		w.send(Packet{Type: Leaders, Ratings: leaders})
+		return
+	}
+	if p.Type == Spectate && len(p.Room) > 0 {
+		s.watch(dec, w, p.Room)
+		return
+	}
+	if p.Type == Queue {
+		s.queue(conn, dec, w, p)
+		return
+	}
+	if p.Type != Join || len(p.Room) == 0 {
+		w.send(Packet{Type: Fail, Msg: "join a room first"})
+		return
+	}
+
+	room := s.getOrCreateRoom(p.Room)
+	st, err := room.join(w, p.Token, p.PlayerID)
+	if err != nil {
+		w.send(Packet{Type: Fail, Room: p.Room, Msg: err.Error()})
+		return
+	}
+	s.play(conn, readPackets(dec), w, room, st)
+}
+
+// 处理已经入座的玩家：转发下棋意图，连接断开时离开座位
+func (s *Server) play(conn net.Conn, packets <-chan Packet, w *writer, room *Room, st *seat) {
+	log.Printf("room %s: seat %d joined from %s", room.name, st.group, conn.RemoteAddr())
+
+	for p := range packets {
+		if p.Type == Move && p.Statement != nil {
+			room.handleStatement(st, *p.Statement)
+		}
+	}
+
+	log.Printf("room %s: seat %d disconnected", room.name, st.group)
+	room.leave(st, w)
+}
+
+// 在单独的协程中读取连接上的数据包，连接断开时通道关闭
+func readPackets(dec *json.Decoder) <-chan Packet {
+	packets := make(chan Packet)
+	go func() {
+		defer close(packets)
+		for {
+			var p Packet
+			if err := dec.Decode(&p); err != nil {
+				return
+			}
+			packets <- p
+		}
+	}()
+	return packets
+}
+
+// 处理观战连接：推送延迟之后的对局消息，观战者不能下棋
+func (s *Server) watch(dec *json.Decoder, w *writer, name string) {
+	s.mu.Lock()
+	room := s.rooms[name]
+	s.mu.Unlock()
+	if room == nil {
+		w.send(Packet{Type: Fail, Room: name, Msg: "room not found"})
+		return
+	}
+	spectator, err := room.spectate()
+	if err != nil {
+		w.send(Packet{Type: Fail, Room: name, Msg: err.Error()})
+		return
+	}
+	defer spectator.Close()
+
+	w.send(Packet{
+		Type:    Sync,
+		Room:    name,
+		FEN:     spectator.Position.FEN(),
+		History: spectator.History,
+	})
+
+	//观战者发送的数据包一律拒绝，连接断开时退出观战
+	go func() {
+		for {
+			var p Packet
+			if err := dec.Decode(&p); err != nil {
+				spectator.Close()
+				return
+			}
+			msg := chessgame.NewRejected(RejectSpectator, "")
+			w.send(Packet{Type: Result, Room: name, Statement: p.Statement, GameMsg: &msg})
+		}
+	}()
+
+	for msg := range spectator.Messages() {
+		w.send(Packet{
+			Type:      Result,
+			Room:      name,
+			Statement: &msg.Statement,
+			GameMsg:   &msg.GameMsg,
+			FEN:       msg.Position.FEN(),
+		})
+	}
+}
+
+func (s *Server) getOrCreateRoom(name string) *Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	room, ok := s.rooms[name]
+	if !ok {
+		room = newRoom(name, s.cfg, s.ratings, s.repo, s.removeRoom)
+		s.rooms[name] = room
+	}
+	return room
+}
+
+func (s *Server) removeRoom(room *Room) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rooms[room.name] == room {
+		delete(s.rooms, room.name)
+	}
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessclock"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/rating"
+)
+
+// PacketType 客户端和服务器之间传输的数据包类型
+type PacketType string
+
+const (
+	Join     PacketType = "JOIN"     //客户端请求加入房间
+	Seat     PacketType = "SEAT"     //服务器为客户端分配座位
+	Start    PacketType = "START"    //双方就位，棋局开始
+	Move     PacketType = "MOVE"     //客户端提交下棋意图
+	Result   PacketType = "RESULT"   //服务器广播内核处理下棋意图的结果，意图被拒绝时只发给下棋的一方
+	Fail     PacketType = "FAIL"     //服务器拒绝客户端加入房间等请求或者房间异常
+	Sync     PacketType = "SYNC"     //断线重连后服务器下发完整的对局状态
+	Vacant   PacketType = "VACANT"   //有玩家断线，座位在宽限期内保留
+	Resume   PacketType = "RESUME"   //断线的玩家重新连接
+	Spectate PacketType = "SPECTATE" //客户端请求观战
+	Leaders  PacketType = "LEADERS"  //查询积分榜
+	Queue    PacketType = "QUEUE"    //客户端请求自动匹配，匹配成功后服务器在新房间分配座位
+)
+
+// Packet 客户端和服务器之间传输的数据包，每个数据包编码为一行json
+type Packet struct {
+	Type      PacketType         `json:"type"`
+	Room      string             `json:"room,omitempty"`      //房间名称
+	Group     core.ChessmanGroup `json:"group,omitempty"`     //座位对应的阵营
+	IsFirst   bool               `json:"isFirst,omitempty"`   //座位是否先手
+	IsDown    bool               `json:"isDown,omitempty"`    //座位在服务器棋盘俯视图是否位于下方
+	Statement *player.Statement  `json:"statement,omitempty"` //下棋意图，坐标使用服务器棋盘的坐标
+	GameMsg   *chessgame.GameMsg `json:"gameMsg,omitempty"`   //内核或者服务器产生的事件
+	Msg       string             `json:"msg,omitempty"`       //附带的文本消息
+
+	Token   string             `json:"token,omitempty"`   //会话令牌，断线重连时用来找回座位
+	FEN     string             `json:"fen,omitempty"`     //当前局面
+	History []player.Statement `json:"history,omitempty"` //已经生效的下棋记录
+	Clock   *chessclock.State  `json:"clock,omitempty"`   //棋钟状态
+
+	PlayerID    int             `json:"playerId,omitempty"`    //玩家id，为0表示匿名玩家，不计算评分
+	N           int             `json:"n,omitempty"`           //查询积分榜的人数
+	Ratings     []rating.Rating `json:"ratings,omitempty"`     //积分榜，或者对局结束后双方更新的评分
+	TimeControl time.Duration   `json:"timeControl,omitempty"` //自动匹配期望的对局时间，为0时使用服务器的对局时间
+}
+
+// 服务器在意图交给内核之前拒绝的原因，和内核的拒绝原因一样放在GameMsg.Rejected里发给客户端
+const (
+	RejectNotRunning      chessgame.RejectReason = "notRunning"      //对局没有进行
+	RejectUndoNotAllowed  chessgame.RejectReason = "undoNotAllowed"  //网络对战不允许悔棋
+	RejectNotYourChessman chessgame.RejectReason = "notYourChessman" //不是自己的棋子
+	RejectNotYourTurn     chessgame.RejectReason = "notYourTurn"     //还没轮到自己
+	RejectSpectator       chessgame.RejectReason = "spectator"       //观战者不能走棋
+)
+
+// ConnStatus 客户端的连接状态
+type ConnStatus string
+
+const (
+	Connecting   ConnStatus = "CONNECTING"   //正在连接服务器
+	Waiting      ConnStatus = "WAITING"      //已入座，等待对手
+	Playing      ConnStatus = "PLAYING"      //对局进行中
+	Reconnecting ConnStatus = "RECONNECTING" //连接断开，正在重新连接
+	Disconnected ConnStatus = "DISCONNECTED" //连接已断开
+)
+
+// Config 服务器配置
+type Config struct {
+	TimeControl time.Duration //每一方的对局时间
+	GracePeriod time.Duration //玩家断线后保留座位的时间，期间棋钟照常计时
+	WatchDelay  time.Duration //观战消息的推送延迟，防止观战者向对局双方透露棋步
+}
+
+// DefaultConfig 默认的服务器配置
+func DefaultConfig() Config {
+	return Config{
+		TimeControl: 20 * time.Minute,
+		GracePeriod: time.Minute,
+		WatchDelay:  30 * time.Second,
+	}
+}
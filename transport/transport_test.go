@@ -0,0 +1,350 @@
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/matchmaking"
+	"github.com/CXeon/xiangqi/rating"
+	"github.com/CXeon/xiangqi/storage"
+)
+
+// 测试中断线的客户端很快重连。在所有测试之前设置，避免和前面测试留下的客户端协程同时读写
+func init() {
+	reconnectInterval = 10 * time.Millisecond
+}
+
+func TestServerAndClient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(DefaultConfig()).Serve(l)
+
+	red, err := Dial(l.Addr().String(), "test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer red.Close()
+	black, err := Dial(l.Addr().String(), "test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer black.Close()
+
+	if !red.GetIsFirst() || black.GetIsFirst() {
+		t.Fatal("the first joined client should be the first player")
+	}
+	if p := <-red.Packets(); p.Type != Start {
+		t.Fatalf("expect start, got %s", p.Type)
+	}
+	if p := <-black.Packets(); p.Type != Start {
+		t.Fatalf("expect start, got %s", p.Type)
+	}
+
+	//后手不能抢先下棋
+	black.SendStatement(player.Statement{
+		Group:  black.GetGroup(),
+		Code:   core.BingZu,
+		Source: core.Coordinate{X: 0, Y: 3},
+		Target: core.Coordinate{X: 0, Y: 4},
+	})
+	if p := <-black.Packets(); p.Type != Result || p.GameMsg.Rejected == nil || p.GameMsg.Rejected.Reason != RejectNotYourTurn {
+		t.Fatalf("expect not your turn, got %+v", p)
+	}
+
+	//先手“炮二平五”，双方都能收到结果，后手收到的坐标是自己视角的坐标
+	red.SendStatement(player.Statement{
+		Group:  red.GetGroup(),
+		Code:   core.Pao,
+		Source: core.Coordinate{X: 1, Y: 2},
+		Target: core.Coordinate{X: 4, Y: 2},
+	})
+	p := <-red.Packets()
+	if p.Type != Result || p.GameMsg.Event != chessgame.Done || p.GameMsg.Move.To != (core.Coordinate{X: 4, Y: 2}) {
+		t.Fatalf("expect done, got %+v", p)
+	}
+	p = <-black.Packets()
+	if p.Type != Result || p.Statement.Target != (core.Coordinate{X: 4, Y: 7}) || p.GameMsg.Move.To != p.Statement.Target {
+		t.Fatalf("expect flipped statement, got %+v", p.Statement)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(DefaultConfig()).Serve(l)
+
+	red, err := Dial(l.Addr().String(), "reconnect", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer red.Close()
+	black, err := Dial(l.Addr().String(), "reconnect", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer black.Close()
+	<-red.Packets()
+	<-black.Packets()
+
+	red.SendStatement(player.Statement{
+		Group:  red.GetGroup(),
+		Code:   core.Pao,
+		Source: core.Coordinate{X: 1, Y: 2},
+		Target: core.Coordinate{X: 4, Y: 2},
+	})
+	<-red.Packets()
+	<-black.Packets()
+
+	//模拟黑方掉线，红方收到对手断线的通知
+	black.mu.Lock()
+	black.conn.Close()
+	black.mu.Unlock()
+	if p := <-red.Packets(); p.Type != Vacant || p.Group != black.GetGroup() {
+		t.Fatalf("expect vacant, got %+v", p)
+	}
+
+	//黑方自动重连后收到完整的对局状态
+	p := <-black.Packets()
+	if p.Type != Sync || len(p.History) != 1 || p.Clock == nil {
+		t.Fatalf("expect sync, got %+v", p)
+	}
+	if p.FEN != "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C2C4/9/RNBAKABNR b" {
+		t.Fatalf("unexpected fen %s", p.FEN)
+	}
+	if p := <-red.Packets(); p.Type != Resume {
+		t.Fatalf("expect resume, got %+v", p)
+	}
+	if black.GetStatus() != Playing {
+		t.Fatalf("expect playing, got %s", black.GetStatus())
+	}
+}
+
+func TestRatingsAndLeaderboard(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.GracePeriod = 10 * time.Millisecond
+	server := NewServer(cfg)
+	repo := storage.NewMemoryRepository()
+	server.UseRepository(repo)
+	server.UseRatings(rating.NewService(rating.DefaultConfig(), repo.Ratings()))
+	go server.Serve(l)
+
+	red, err := Dial(l.Addr().String(), "rated", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	black, err := Dial(l.Addr().String(), "rated", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer black.Close()
+	<-red.Packets()
+	<-black.Packets()
+
+	//红方离开且不再重连，宽限期过后黑方获胜，双方的评分随结果下发
+	red.Close()
+	if p := <-black.Packets(); p.Type != Vacant {
+		t.Fatalf("expect vacant, got %+v", p)
+	}
+	p := <-black.Packets()
+	if p.Type != Result || p.GameMsg.Event != chessgame.Fin || len(p.Ratings) != 2 {
+		t.Fatalf("expect result with ratings, got %+v", p)
+	}
+	if p.Ratings[0].PlayerID != 1 || p.Ratings[0].Losses != 1 || p.Ratings[1].PlayerID != 2 || p.Ratings[1].Wins != 1 {
+		t.Fatalf("unexpected ratings %+v", p.Ratings)
+	}
+
+	games := repo.ListGames(2)
+	if len(games) != 1 || games[0].Room != "rated" || games[0].Reason != "abandoned" {
+		t.Fatalf("unexpected archived games %+v", games)
+	}
+	if _, ok := repo.GetPlayer(1); !ok {
+		t.Fatal("player profile should be created")
+	}
+
+	leaders, err := QueryLeaderboard(l.Addr().String(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaders) != 2 || leaders[0].PlayerID != 2 {
+		t.Fatalf("unexpected leaderboard %+v", leaders)
+	}
+}
+
+func TestSpectatorSeesTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.TimeControl = 200 * time.Millisecond
+	cfg.WatchDelay = 0
+	go NewServer(cfg).Serve(l)
+
+	red, err := Dial(l.Addr().String(), "flag", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer red.Close()
+	black, err := Dial(l.Addr().String(), "flag", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer black.Close()
+	<-red.Packets()
+	<-black.Packets()
+
+	watcher, err := Watch(l.Addr().String(), "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if p := <-watcher.Packets(); p.Type != Sync {
+		t.Fatalf("expect sync, got %+v", p)
+	}
+
+	//红方一直不走棋，超时之后观战者也能收到对局结果
+	select {
+	case p := <-watcher.Packets():
+		if p.Type != Result || p.GameMsg == nil || p.GameMsg.GameOver == nil {
+			t.Fatalf("expect game over, got %+v", p)
+		}
+		if p.GameMsg.GameOver.Termination != chessgame.TerminationTimeout || p.GameMsg.GameOver.Winner != black.GetGroup() {
+			t.Fatalf("unexpected game over %+v", p.GameMsg.GameOver)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("spectator never received the result")
+	}
+}
+
+// 启动开启了自动匹配的服务器
+func serveWithMatchmaker(t *testing.T, cfg matchmaking.Config) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quit := make(chan struct{})
+	t.Cleanup(func() { close(quit) })
+	server := NewServer(DefaultConfig())
+	server.UseMatchmaker(matchmaking.NewMatchmaker(cfg, matchmaking.NewMemoryStore()), 10*time.Millisecond, quit)
+	go server.Serve(l)
+	return l.Addr().String()
+}
+
+func TestMatchmakingSeatsBothPlayers(t *testing.T) {
+	addr := serveWithMatchmaker(t, matchmaking.DefaultConfig())
+
+	//排队会一直等到匹配成功，所以两名玩家同时排队
+	clients := make(chan *Client, 2)
+	for _, id := range []int{1, 2} {
+		go func(id int) {
+			c, err := DialQueue(addr, id, 5*time.Minute)
+			if err != nil {
+				t.Error(err)
+			}
+			clients <- c
+		}(id)
+	}
+	a, b := <-clients, <-clients
+	if a == nil || b == nil {
+		t.FailNow()
+	}
+	defer a.Close()
+	defer b.Close()
+
+	if a.seat.Room == "" || a.seat.Room != b.seat.Room {
+		t.Fatalf("expect the same new room, got %q and %q", a.seat.Room, b.seat.Room)
+	}
+	if a.GetIsFirst() == b.GetIsFirst() {
+		t.Fatal("exactly one player should move first")
+	}
+	for _, c := range []*Client{a, b} {
+		p := <-c.Packets()
+		if p.Type != Start || p.Clock == nil || p.Clock.Remaining[core.Group1] > 5*time.Minute {
+			t.Fatalf("expect start with the queued time control, got %+v", p)
+		}
+	}
+
+	//匹配的房间和普通房间一样下棋
+	red, black := a, b
+	if !red.GetIsFirst() {
+		red, black = b, a
+	}
+	red.SendStatement(player.Statement{
+		Group:  red.GetGroup(),
+		Code:   core.Pao,
+		Source: core.Coordinate{X: 1, Y: 2},
+		Target: core.Coordinate{X: 4, Y: 2},
+	})
+	if p := <-black.Packets(); p.Type != Result || p.GameMsg.Event != chessgame.Done {
+		t.Fatalf("expect done, got %+v", p)
+	}
+}
+
+func TestMatchmakingRejects(t *testing.T) {
+	cfg := matchmaking.DefaultConfig()
+	cfg.QueueTimeout = 50 * time.Millisecond
+	addr := serveWithMatchmaker(t, cfg)
+
+	if _, err := DialQueue(addr, 0, 0); err == nil {
+		t.Fatal("anonymous players should not be able to queue")
+	}
+	_, err := DialQueue(addr, 1, 0)
+	if err == nil || err.Error() != errQueueTimeout.Error() {
+		t.Fatalf("expect queue timeout, got %v", err)
+	}
+
+	//没有开启自动匹配的服务器拒绝排队
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(DefaultConfig()).Serve(l)
+	if _, err := DialQueue(l.Addr().String(), 1, 0); err == nil {
+		t.Fatal("matchmaking should be disabled")
+	}
+}
+
+// 不读取数据的客户端不会卡住发送方，发送队列满了之后连接被关闭
+func TestWriterDropsStalledClient(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	w := newWriter(server)
+
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < writeQueueSize+2; i++ {
+			w.send(Packet{Type: Vacant})
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("send blocked on a client that is not reading")
+	}
+
+	//连接关闭之后，客户端读完已经写入的数据就会读到错误
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	for {
+		if _, err := client.Read(buf); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("the stalled connection was not closed")
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	writeQueueSize = 64               //每个连接最多排队的数据包数量
+	writeTimeout   = 10 * time.Second //写入一个数据包的期限
+)
+
+// 连接的发送队列，数据包由单独的协程写入连接。
+// 房间持有锁时只把数据包放进队列，不读取数据的客户端不会卡住房间
+type writer struct {
+	conn    net.Conn
+	enc     *json.Encoder
+	packets chan Packet
+
+	closeOnce sync.Once
+	closing   chan struct{} //关闭之后不再接受新的数据包
+}
+
+func newWriter(conn net.Conn) *writer {
+	w := &writer{
+		conn:    conn,
+		enc:     json.NewEncoder(conn),
+		packets: make(chan Packet, writeQueueSize),
+		closing: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// 把数据包放进发送队列，不等待写入。
+// 队列已满说明客户端不再读取，直接关闭连接，由读取协程负责离开房间
+func (w *writer) send(p Packet) {
+	select {
+	case <-w.closing:
+		return
+	default:
+	}
+	select {
+	case w.packets <- p:
+	default:
+		w.conn.Close()
+	}
+}
+
+// 写完队列里的数据包之后关闭连接
+func (w *writer) close() {
+	w.closeOnce.Do(func() {
+		close(w.closing)
+	})
+}
+
+func (w *writer) run() {
+	defer w.conn.Close()
+	for {
+		select {
+		case p := <-w.packets:
+			if !w.write(p) {
+				return
+			}
+		case <-w.closing:
+			for {
+				select {
+				case p := <-w.packets:
+					if !w.write(p) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// 写入一个数据包，写入失败或者超时说明连接已经断开
+func (w *writer) write(p Packet) bool {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return w.enc.Encode(p) == nil
+}
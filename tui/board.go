@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/ecco"
+)
+
+// ANSI控制序列
+const (
+	ansiClear   = "\x1b[H\x1b[2J"
+	ansiReset   = "\x1b[0m"
+	ansiRed     = "\x1b[1;31m"
+	ansiBlack   = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+)
+
+// 棋盘上的符号都是全角字符，每个交叉点占两列，交叉点之间用一列连接
+const (
+	emptyPoint = "＋"
+	fromPoint  = "〇" //不使用颜色时标记最近一步棋的起点
+	vertical   = "｜"
+	boardWidth = position.Files*3 - 1
+)
+
+// 红方纵线编号，从红方右手边开始
+var redFileNumbers = []string{"九", "八", "七", "六", "五", "四", "三", "二", "一"}
+
+// 显示记谱时最多显示的回合数
+const maxMoveRounds = 12
+
+// 绘制棋盘、吃子和着法记录
+func (g *Game) draw() {
+	if g.color {
+		fmt.Fprint(g.out, ansiClear)
+	}
+	board := g.boardLines()
+	panel := g.panelLines()
+	for i := 0; i < len(board) || i < len(panel); i++ {
+		line := ""
+		if i < len(board) {
+			line = board[i]
+		}
+		if i < len(panel) {
+			line = padRight(line, boardWidth+6) + panel[i]
+		}
+		fmt.Fprintln(g.out, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintln(g.out, g.status)
+}
+
+// 屏幕上第row行第col列对应的红方视角位置，棋盘下方的玩家在屏幕下方
+func (g *Game) squareAt(row, col int) position.Square {
+	if g.gameCore.GetRedIsDown() {
+		return position.Square{File: col, Rank: position.Ranks - 1 - row}
+	}
+	return position.Square{File: position.Files - 1 - col, Rank: row}
+}
+
+// 棋盘的每一行，左边是ICCS行号，上方是黑方纵线编号，下方是红方纵线编号和ICCS列号
+func (g *Game) boardLines() []string {
+	pos := g.gameCore.GetPosition()
+	lines := make([]string, 0, 2*position.Ranks+3)
+
+	top := make([]string, position.Files)
+	bottom := make([]string, position.Files)
+	letters := make([]string, position.Files)
+	for col := 0; col < position.Files; col++ {
+		sq := g.squareAt(0, col)
+		top[col] = fmt.Sprintf("%-2d", sq.File+1)
+		bottom[col] = redFileNumbers[sq.File]
+		letters[col] = fmt.Sprintf("%-2c", 'a'+sq.File)
+	}
+	lines = append(lines, "  "+strings.Join(top, " "))
+
+	for row := 0; row < position.Ranks; row++ {
+		cells := make([]string, position.Files)
+		for col := 0; col < position.Files; col++ {
+			cells[col] = g.cell(&pos, g.squareAt(row, col))
+		}
+		lines = append(lines, fmt.Sprintf("%d ", g.squareAt(row, 0).Rank)+strings.Join(cells, g.grid("-")))
+
+		switch {
+		case row == position.Ranks-1:
+		case row == position.Ranks/2-1:
+			//楚河汉界
+			lines = append(lines, "  "+g.grid(vertical+"   楚 河      汉 界   "+vertical))
+		default:
+			lines = append(lines, "  "+g.grid(strings.TrimRight(strings.Repeat(vertical+" ", position.Files), " ")))
+		}
+	}
+
+	lines = append(lines, "  "+strings.Join(bottom, " "))
+	lines = append(lines, "  "+strings.Join(letters, " "))
+	return lines
+}
+
+// 交叉点上的棋子或者空点，最近一步棋的起点和终点反色显示
+func (g *Game) cell(pos *position.Position, sq position.Square) string {
+	piece := pos.At(sq)
+	marked := g.lastMove != nil && (g.lastMove.From == sq || g.lastMove.To == sq)
+
+	if len(piece.Code) == 0 {
+		if marked && g.lastMove.From == sq {
+			if !g.color {
+				return fromPoint
+			}
+			return ansiReverse + emptyPoint + ansiReset
+		}
+		return g.grid(emptyPoint)
+	}
+
+	s := position.PieceName(piece)
+	if !g.color {
+		return s
+	}
+	c := ansiBlack
+	if piece.Red {
+		c = ansiRed
+	}
+	if marked {
+		c += ansiReverse
+	}
+	return c + s + ansiReset
+}
+
+// 棋盘线使用暗色
+func (g *Game) grid(s string) string {
+	if !g.color {
+		return s
+	}
+	return ansiDim + s + ansiReset
+}
+
+// 棋盘右边的信息：双方的吃子和失子，以及最近的着法记录
+func (g *Game) panelLines() []string {
+	lines := make([]string, 0, maxMoveRounds+6)
+	for _, p := range []player.PlayerInterface{g.redPlayer(), g.blackPlayer()} {
+		red := p.GetIsFirst()
+		won, _ := p.GetWonChessmen()
+		lost, _ := p.GetLostChessmen()
+		lines = append(lines, fmt.Sprintf("%s 吃子：%s", g.sideName(p.GetGroup()), g.pieceList(won, !red)))
+		lines = append(lines, fmt.Sprintf("     失子：%s", g.pieceList(lost, red)))
+	}
+	lines = append(lines, "")
+	if op, ok := ecco.Default().ClassifyGame(g.gameCore); ok {
+		lines = append(lines, "开局："+op.String())
+	}
+	lines = append(lines, "着法")
+
+	//红方先走时每两步为一回合
+	rounds := make([]string, 0, len(g.moves)/2+1)
+	for i := 0; i < len(g.moves); i += 2 {
+		round := fmt.Sprintf("%3d. %s", i/2+1, g.moves[i])
+		if i+1 < len(g.moves) {
+			round += "  " + g.moves[i+1]
+		}
+		rounds = append(rounds, round)
+	}
+	if len(rounds) > maxMoveRounds {
+		rounds = rounds[len(rounds)-maxMoveRounds:]
+	}
+	return append(lines, rounds...)
+}
+
+func (g *Game) pieceList(codes []core.ChessmanCode, red bool) string {
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = position.PieceName(position.Piece{Code: code, Red: red})
+	}
+	return strings.Join(names, " ")
+}
+
+func (g *Game) redPlayer() player.PlayerInterface {
+	if g.player1.GetIsFirst() {
+		return g.player1
+	}
+	return g.player2
+}
+
+func (g *Game) blackPlayer() player.PlayerInterface {
+	if g.player1.GetIsFirst() {
+		return g.player2
+	}
+	return g.player1
+}
+
+// 按照终端的显示宽度在行尾补空格，全角字符占两列，ANSI控制序列不占位置
+func padRight(s string, width int) string {
+	w := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			end := strings.IndexByte(s[i:], 'm')
+			if end < 0 {
+				break
+			}
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if r < 0x1100 {
+			w++
+		} else {
+			w += 2
+		}
+	}
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
+}
@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CXeon/xiangqi/core"
+	"github.com/CXeon/xiangqi/core/chessgame"
+	"github.com/CXeon/xiangqi/core/player"
+	"github.com/CXeon/xiangqi/core/position"
+	"github.com/CXeon/xiangqi/i18n"
+)
+
+// Game 终端界面的单机对战。和app.Game一样，玩家的下棋意图通过两个通道交给内核，内核的消息决定界面如何变化
+type Game struct {
+	in    *bufio.Scanner
+	out   io.Writer
+	color bool //是否使用ANSI颜色
+
+	player1 player.PlayerInterface //玩家1 开局时先手并位于棋盘下方
+	player2 player.PlayerInterface //玩家2
+
+	p1Ch chan player.Statement //玩家1的下棋意图
+	p2Ch chan player.Statement //玩家2的下棋意图
+
+	gameCore chessgame.ChessGameInterface //游戏内核
+	coreCh   chan chessgame.GameMsg       //管道接收内核返回的消息
+
+	nextRoundGroup core.ChessmanGroup //下一回合应该下棋的阵营
+	moves          []string           //中文记谱的着法记录
+	lastMove       *position.Move     //最近一步棋，用于在棋盘上标记
+	finished       bool               //对局已经结束
+	status         string             //最近一条提示
+}
+
+// NewGame 创建终端对战，从in读取玩家输入，向out绘制棋盘
+func NewGame(in io.Reader, out io.Writer, color bool) (*Game, error) {
+	var p1, p2 player.PlayerInterface
+
+	p1 = player.NewPlayer()
+	p1.SetIsFirst(true) //默认p1先手
+	p1.SetIsDown(true)  //默认p1在棋盘下方
+	p1.SetGroup(core.Group1)
+
+	p2 = player.NewPlayer()
+	p2.SetGroup(core.Group2)
+
+	g := &Game{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		color:   color,
+		player1: p1,
+		player2: p2,
+		p1Ch:    make(chan player.Statement, 1),
+		p2Ch:    make(chan player.Statement, 1),
+	}
+
+	//启动内核
+	g.gameCore = new(chessgame.ChessGame)
+	if err := g.gameCore.InitialGame(p1, p2); err != nil {
+		return nil, err
+	}
+	g.coreCh = g.gameCore.Run(g.p1Ch, g.p2Ch)
+	g.nextRoundGroup = g.gameCore.GetNextRoundGroup()
+	g.status = "输入着法开始对局，例如 炮二平五 或 h2e2，输入 help 查看命令"
+
+	return g, nil
+}
+
+// Run 读取玩家输入直到退出或者输入结束
+func (g *Game) Run() error {
+	for {
+		g.draw()
+		fmt.Fprintf(g.out, "%s> ", g.sideName(g.nextRoundGroup))
+		if !g.in.Scan() {
+			fmt.Fprintln(g.out)
+			return g.in.Err()
+		}
+		line := strings.TrimSpace(g.in.Text())
+		switch strings.ToLower(line) {
+		case "":
+		case "help", "h", "?", "帮助":
+			g.status = "命令：着法（炮二平五、马8进7、h2e2）| undo 悔棋 | new 再来一局 | quit 退出"
+		case "undo", "u", "悔棋":
+			g.undo()
+		case "new", "n", "再来一局":
+			if err := g.onceAgain(); err != nil {
+				return err
+			}
+		case "quit", "q", "exit", "退出":
+			return nil
+		default:
+			g.submit(line)
+		}
+	}
+}
+
+// Close 关闭内核
+func (g *Game) Close() {
+	g.gameCore.Close()
+}
+
+// 解析玩家输入的着法并交给内核校验，ICCS和中文记谱都可以
+func (g *Game) submit(input string) {
+	if g.finished {
+		g.status = "对局已经结束，输入 new 再来一局"
+		return
+	}
+	st, err := g.parseMove(input)
+	if err != nil {
+		g.status = fmt.Sprintf("无法识别的着法：%s", input)
+		return
+	}
+	if st.Group != g.nextRoundGroup {
+		g.status = fmt.Sprintf("不能走对方的棋子，现在轮到%s走棋", g.sideName(g.nextRoundGroup))
+		return
+	}
+
+	//内核移动棋子之前记下中文记谱
+	notation := g.gameCore.FormatChineseMove(st)
+	move := g.gameCore.FormatMove(st)
+
+	msg := g.send(st)
+	switch msg.Event {
+	case chessgame.Err:
+		g.status = fmt.Sprintf("无效的着法 %s：%s", notation, i18n.ZhCN.Reason(string(msg.Rejected.Reason)))
+	case chessgame.Done, chessgame.Fin:
+		g.moves = append(g.moves, notation)
+		g.lastMove = &move
+		g.nextRoundGroup = g.opponentGroup(st.Group)
+		g.status = fmt.Sprintf("%s %s", g.sideName(st.Group), notation)
+		if captured := msg.Captured(); len(captured) > 0 {
+			g.status += fmt.Sprintf("，吃%s", position.PieceName(position.Piece{Code: captured, Red: !g.isRed(st.Group)}))
+		}
+		if msg.Check != nil {
+			g.status += "，将军"
+		}
+		if msg.Event == chessgame.Fin {
+			g.finished = true
+			g.status += fmt.Sprintf("。%s，%s胜，输入 new 再来一局", i18n.ZhCN.Reason(string(msg.GameOver.Termination)), g.sideName(msg.Winner()))
+		}
+	}
+}
+
+func (g *Game) parseMove(input string) (player.Statement, error) {
+	if m, err := position.ParseMove(input); err == nil {
+		return g.gameCore.ParseMove(m)
+	}
+	return g.gameCore.ParseChineseMove(input)
+}
+
+// 悔棋，撤销最近一步棋
+func (g *Game) undo() {
+	if g.finished {
+		g.status = "对局已经结束，不能悔棋"
+		return
+	}
+	msg := g.send(player.Statement{Undo: true})
+	if msg.Event != chessgame.Undone {
+		g.status = "没有可以悔的棋"
+		if msg.Rejected != nil {
+			g.status = i18n.ZhCN.Reason(string(msg.Rejected.Reason))
+		}
+		return
+	}
+	g.moves = g.moves[:len(g.moves)-1]
+	g.nextRoundGroup = g.gameCore.GetNextRoundGroup()
+	g.lastMove = nil
+	if history := g.gameCore.GetHistory(); len(history) > 0 {
+		m := g.gameCore.FormatMove(history[len(history)-1])
+		g.lastMove = &m
+	}
+	g.status = fmt.Sprintf("%s悔棋", g.sideName(g.nextRoundGroup))
+}
+
+// 把下棋意图发送给下一回合阵营的玩家，等待内核的回复
+func (g *Game) send(st player.Statement) chessgame.GameMsg {
+	if g.player1.GetGroup() == g.nextRoundGroup {
+		g.p1Ch <- st
+	} else {
+		g.p2Ch <- st
+	}
+	return <-g.coreCh
+}
+
+// 对局结束后再来一局，交换先后手
+func (g *Game) onceAgain() error {
+	if !g.finished {
+		g.status = "对局还没有结束"
+		return nil
+	}
+	if g.player1.GetIsFirst() {
+		g.player2.SetIsFirst(true)
+		g.player1.SetIsFirst(false)
+	} else {
+		g.player1.SetIsFirst(true)
+		g.player2.SetIsFirst(false)
+	}
+	if err := g.gameCore.ResetGame(); err != nil {
+		return err
+	}
+	g.coreCh = g.gameCore.Run(g.p1Ch, g.p2Ch)
+	g.nextRoundGroup = g.gameCore.GetNextRoundGroup()
+	g.moves = nil
+	g.lastMove = nil
+	g.finished = false
+	g.status = "新的一局，交换先后手"
+	return nil
+}
+
+// 先手方执红
+func (g *Game) isRed(group core.ChessmanGroup) bool {
+	if g.player1.GetGroup() == group {
+		return g.player1.GetIsFirst()
+	}
+	return g.player2.GetIsFirst()
+}
+
+func (g *Game) sideName(group core.ChessmanGroup) string {
+	if g.isRed(group) {
+		return "红方"
+	}
+	return "黑方"
+}
+
+func (g *Game) opponentGroup(group core.ChessmanGroup) core.ChessmanGroup {
+	if g.player1.GetGroup() == group {
+		return g.player2.GetGroup()
+	}
+	return g.player1.GetGroup()
+}
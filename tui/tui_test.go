@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGame(t *testing.T) {
+	//炮二平五、马8进7，红方炮打中卒后悔棋，然后用ICCS着法走完一局
+	input := strings.Join([]string{
+		"炮二平五", "马8进7", "炮五进四", "undo", "b7e7",
+		"e2e6", "b7e7", "e6e9", "new", "h2e2", "quit",
+	}, "\n")
+	out := new(bytes.Buffer)
+	game, err := NewGame(strings.NewReader(input), out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.Close()
+	if err = game.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"1. 炮二平五  马8进7",
+		"开局：B05 中炮对进左马",
+		"红方 炮五进四，吃卒",
+		"红方悔棋",
+		"不能走对方的棋子，现在轮到红方走棋",
+		"红方 吃子：卒",
+		"红方胜",
+		"新的一局，交换先后手",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q", want)
+		}
+	}
+	//第二局玩家2执红先手，位于棋盘上方
+	if game.finished || len(game.moves) != 1 || game.nextRoundGroup != game.player1.GetGroup() {
+		t.Fatalf("unexpected second game %v", game.moves)
+	}
+}